@@ -0,0 +1,262 @@
+// Command rocketsctl runs maintenance tasks directly against the event store.
+//
+//	rocketsctl verify [-dir DIR | -brokers HOST:PORT,... [-topic TOPIC]] [-tenant ID]
+//	rocketsctl export [store flags] [-channels a,b] [-o FILE]
+//	rocketsctl import [store flags] [-i FILE]
+//
+// The store defaults to EVENT_STORE_DIR, KAFKA_BROKERS and KAFKA_TOPIC, as for the server;
+// -tenant selects the partition of a tenant inside it (default: the default tenant), and
+// -keys (default ENCRYPTION_KEY_DIR) the data keys needed to export or import encrypted events.
+// A file store can only be opened by one process (opening a locked one fails): stop the server
+// (or use its /admin/export and /admin/import endpoints) before exporting or importing.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"rockets/internal/application"
+	"rockets/internal/domain"
+	"rockets/internal/infrastructure"
+)
+
+// Exit codes
+const (
+	exitOK     = 0
+	exitFailed = 1 // the check found problems
+	exitError  = 2 // the command could not run
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(exitError)
+	}
+
+	switch os.Args[1] {
+	case "verify":
+		os.Exit(verify(os.Args[2:]))
+	case "export":
+		os.Exit(export(os.Args[2:]))
+	case "import":
+		os.Exit(importArchive(os.Args[2:]))
+	case "help", "-h", "--help":
+		usage()
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command: %s\n", os.Args[1])
+		usage()
+		os.Exit(exitError)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: rocketsctl <command> [flags]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "commands:")
+	fmt.Fprintln(os.Stderr, "  verify   check the hash chain of every channel and report the first broken link")
+	fmt.Fprintln(os.Stderr, "  export   write every event (or the events of some channels) as NDJSON")
+	fmt.Fprintln(os.Stderr, "  import   validate an NDJSON archive and append its events")
+}
+
+// storeFlags are the flags that select the event store
+type storeFlags struct {
+	dir     string
+	brokers string
+	topic   string
+	tenant  string
+	keys    string
+}
+
+func (f *storeFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.dir, "dir", os.Getenv("EVENT_STORE_DIR"), "directory of the file event store")
+	fs.StringVar(&f.brokers, "brokers", os.Getenv("KAFKA_BROKERS"), "Kafka bootstrap brokers")
+	fs.StringVar(&f.topic, "topic", os.Getenv("KAFKA_TOPIC"), "Kafka topic")
+	fs.StringVar(&f.tenant, "tenant", domain.DefaultTenantID, "tenant whose partition is used")
+	fs.StringVar(&f.keys, "keys", os.Getenv("ENCRYPTION_KEY_DIR"), "directory of the data keys of an encrypted store")
+}
+
+// codec returns the codec of the selected store: an encrypting one when -keys is set
+func (f *storeFlags) codec() (*infrastructure.EventCodec, error) {
+	if f.keys == "" {
+		return infrastructure.DefaultEventCodec(), nil
+	}
+	keys, err := infrastructure.NewFileKeyStore(infrastructure.TenantDir(f.keys, f.tenant))
+	if err != nil {
+		return nil, err
+	}
+	return infrastructure.EncryptedEventCodec(keys), nil
+}
+
+// partition returns the directory and topic of the selected tenant
+func (f *storeFlags) partition() (dir, topic string, err error) {
+	if err := domain.ValidateTenantID(f.tenant); err != nil {
+		return "", "", err
+	}
+	if f.dir != "" {
+		dir = infrastructure.TenantDir(f.dir, f.tenant)
+	}
+	return dir, infrastructure.TenantTopic(f.topic, f.tenant), nil
+}
+
+// open opens the selected event store with the codec of its events
+func (f *storeFlags) open() (domain.EventStore, *infrastructure.EventCodec, io.Closer, error) {
+	dir, topic, err := f.partition()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	codec, err := f.codec()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	switch {
+	case dir != "":
+		store, err := infrastructure.NewFileEventStore(infrastructure.FileEventStoreConfig{Dir: dir, Codec: codec})
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return store, codec, store, nil
+	case f.brokers != "":
+		store, err := infrastructure.NewKafkaEventStore(infrastructure.KafkaConfig{Brokers: f.brokers, Topic: topic, Codec: codec})
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return store, codec, store, nil
+	default:
+		return nil, nil, nil, fmt.Errorf("set -dir or -brokers")
+	}
+}
+
+// verify checks the hash chains of the selected store
+func verify(args []string) int {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	var store storeFlags
+	store.register(fs)
+	_ = fs.Parse(args)
+
+	dir, topic, err := store.partition()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "verify: %v\n", err)
+		return exitError
+	}
+
+	var report *domain.ChainReport
+	switch {
+	case dir != "":
+		report, err = infrastructure.VerifyFileEventLog(dir)
+	case store.brokers != "":
+		report, err = infrastructure.VerifyKafkaTopic(infrastructure.KafkaConfig{Brokers: store.brokers, Topic: topic})
+	default:
+		fmt.Fprintln(os.Stderr, "verify: set -dir or -brokers")
+		return exitError
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "verify: %v\n", err)
+		return exitError
+	}
+
+	fmt.Printf("%d channels, %d events checked\n", report.Channels, report.Events)
+	for _, b := range report.Breaks {
+		channel := b.Channel
+		if channel == "" {
+			channel = "(unknown channel)"
+		}
+		fmt.Printf("BROKEN %s event %d at %s: %s\n", channel, b.EventNumber, b.Location, b.Reason)
+	}
+	if !report.Intact {
+		return exitFailed
+	}
+	fmt.Println("OK: all hash chains intact")
+	return exitOK
+}
+
+// export writes the selected store as NDJSON
+func export(args []string) int {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	var store storeFlags
+	store.register(fs)
+	channels := fs.String("channels", "", "comma-separated channels to export (default: all, in commit order)")
+	output := fs.String("o", "-", "output file (- for stdout)")
+	_ = fs.Parse(args)
+
+	eventStore, codec, closer, err := store.open()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "export: %v\n", err)
+		return exitError
+	}
+	defer closer.Close()
+
+	w := io.Writer(os.Stdout)
+	if *output != "-" {
+		file, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "export: %v\n", err)
+			return exitError
+		}
+		defer file.Close()
+		w = file
+	}
+
+	var selected []string
+	for _, channel := range strings.Split(*channels, ",") {
+		if channel = strings.TrimSpace(channel); channel != "" {
+			selected = append(selected, channel)
+		}
+	}
+
+	archive := application.NewArchiveService(eventStore, codec, nil)
+	count, err := archive.Export(w, selected)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "export: %v (after %d events)\n", err, count)
+		return exitError
+	}
+	fmt.Fprintf(os.Stderr, "%d events exported\n", count)
+	return exitOK
+}
+
+// importArchive appends an NDJSON archive to the selected store
+func importArchive(args []string) int {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	var store storeFlags
+	store.register(fs)
+	input := fs.String("i", "-", "input file (- for stdin)")
+	_ = fs.Parse(args)
+
+	r := io.Reader(os.Stdin)
+	if *input != "-" {
+		file, err := os.Open(*input)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "import: %v\n", err)
+			return exitError
+		}
+		defer file.Close()
+		r = file
+	}
+
+	eventStore, codec, closer, err := store.open()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "import: %v\n", err)
+		return exitError
+	}
+	defer closer.Close()
+
+	archive := application.NewArchiveService(eventStore, codec, nil)
+	result, err := archive.Import(r)
+	if errors.Is(err, application.ErrInvalidArchive) {
+		fmt.Fprintf(os.Stderr, "import: %v\n", err)
+		return exitFailed
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "import: %v\n", err)
+		if result != nil && result.Channels > 0 {
+			_ = json.NewEncoder(os.Stdout).Encode(result)
+		}
+		return exitError
+	}
+	_ = json.NewEncoder(os.Stdout).Encode(result)
+	return exitOK
+}
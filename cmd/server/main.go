@@ -1,18 +1,25 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"rockets/internal/api"
 	"rockets/internal/application"
+	"rockets/internal/domain"
 	"rockets/internal/infrastructure"
+	"rockets/internal/metrics"
 )
 
 func main() {
@@ -21,12 +28,22 @@ func main() {
 		Level: slog.LevelInfo,
 	})))
 
-	// Initialize Kafka
-	kafkaEventStore := infrastructure.NewKafkaEventStore("localhost:9092")
-	// Initialize repository (in-memory)
-	repository := infrastructure.NewRocketRepository(kafkaEventStore)
-	// Initialize application service
-	rocketService := application.NewRocketApplicationService(repository, kafkaEventStore)
+	// Tenants: selected per request by API key (TENANT_API_KEYS="key=tenant,...") or the
+	// X-Tenant-ID header; each one gets its own store partition, service and worker pool
+	apiKeys, err := api.ParseAPIKeys(os.Getenv("TENANT_API_KEYS"))
+	if err != nil {
+		slog.Error("Invalid TENANT_API_KEYS", "err", err)
+		os.Exit(1)
+	}
+	// Admin endpoints need ADMIN_API_KEY, which also selects any tenant through X-Tenant-ID
+	adminKey := os.Getenv("ADMIN_API_KEY")
+	if adminKey == "" && len(apiKeys) == 0 {
+		slog.Warn("Neither TENANT_API_KEYS nor ADMIN_API_KEY is set: every endpoint is open, admin ones included")
+	}
+	tenantResolver := api.NewTenantResolver(apiKeys, adminKey)
+
+	registry := metrics.NewRegistry()
+	caches := &tenantCaches{}
 
 	// Configure worker pool
 	workerCount := 3
@@ -35,10 +52,120 @@ func main() {
 			workerCount = parsed
 		}
 	}
-	workerPool := application.NewWorkerPool(rocketService, workerCount)
 	workerCtx, workerCancel := context.WithCancel(context.Background())
 	defer workerCancel()
-	workerPool.Start(workerCtx)
+	rejected := registry.NewCounterVec("rockets_messages_rejected_total", "Messages rejected, by reason.", "reason")
+	serviceOptions := []application.ServiceOption{
+		application.WithRejectionHook(func(reason string) { rejected.With(reason).Inc() }),
+	}
+
+	// Rocket type catalog: commands exceeding the envelope of their type are rejected or flagged
+	if path := os.Getenv("ROCKET_CATALOG"); path != "" {
+		catalog, err := infrastructure.LoadRocketCatalog(path)
+		if err != nil {
+			slog.Error("Invalid ROCKET_CATALOG", "err", err)
+			os.Exit(1)
+		}
+		slog.Info("Rocket catalog loaded", "types", catalog.Len(), "policy", catalog.Policy())
+		serviceOptions = append(serviceOptions, application.WithRocketCatalog(catalog))
+	}
+
+	// Mission registry shared by every tenant: MISSIONS registers producer mission names at
+	// startup, POST /admin/missions while running; MISSION_STRICT rejects unregistered ones
+	missionStrict, _ := strconv.ParseBool(os.Getenv("MISSION_STRICT"))
+	missions := domain.NewMissionRegistry(missionStrict)
+	if err := api.ParseMissions(missions, os.Getenv("MISSIONS")); err != nil {
+		slog.Error("Invalid MISSIONS", "err", err)
+		os.Exit(1)
+	}
+	serviceOptions = append(serviceOptions, application.WithMissionRegistry(missions))
+
+	// Message times of a channel must not go back by more than MESSAGE_TIME_TOLERANCE
+	timePolicy, err := messageTimePolicy()
+	if err != nil {
+		slog.Error("Invalid message time policy", "err", err)
+		os.Exit(1)
+	}
+	if timePolicy != nil {
+		serviceOptions = append(serviceOptions, application.WithTimePolicy(*timePolicy))
+	}
+
+	// Reorder buffer bounds: messages too far ahead or beyond the caps are handled by BUFFER_OVERFLOW
+	limits, err := bufferLimits()
+	if err != nil {
+		slog.Error("Invalid buffer limits", "err", err)
+		os.Exit(1)
+	}
+	serviceOptions = append(serviceOptions, application.WithBufferLimits(limits))
+
+	// Reorder buffers that wait longer than GAP_TIMEOUT for a missing message apply GAP_POLICY
+	policies, err := gapPolicies()
+	if err != nil {
+		slog.Error("Invalid gap policy", "err", err)
+		os.Exit(1)
+	}
+	gapsResolved := registry.NewCounterVec("rockets_gaps_resolved_total", "Gaps of reorder buffers resolved, by outcome.", "outcome")
+	serviceOptions = append(serviceOptions,
+		application.WithGapPolicies(policies),
+		application.WithGapHook(func(outcome string) { gapsResolved.With(outcome).Inc() }))
+	if url := os.Getenv("REDELIVERY_URL"); url != "" {
+		serviceOptions = append(serviceOptions, application.WithRedelivery(redeliveryCallback(url)))
+	}
+
+	// Every tenant's repository publishes its committed events to an in-process event bus
+	committed := registry.NewCounterVec("rockets_events_committed_total", "Events committed, by type.", "type")
+	handlerFailures := registry.NewCounterVec("rockets_event_handler_failures_total", "Event handlers that failed, by event type.", "type")
+	newEventBus := func() *infrastructure.EventBus {
+		bus := infrastructure.NewEventBus(infrastructure.WithHandlerFailureHook(func(eventType string, _ error) {
+			handlerFailures.With(eventType).Inc()
+		}))
+		infrastructure.Subscribe(bus, infrastructure.DeliverSync, func(event domain.DomainEvent) error {
+			committed.With(event.GetEventType()).Inc()
+			return nil
+		})
+		return bus
+	}
+
+	// Unknown clients cannot open tenants without bound: cap them and close the idle ones
+	tenantBounds, err := tenantLimits()
+	if err != nil {
+		slog.Error("Invalid tenant limits", "err", err)
+		os.Exit(1)
+	}
+	tenants := application.NewTenantRegistry(workerCtx, func(tenantID string) (*application.TenantBackend, error) {
+		return openTenant(tenantID, caches, newEventBus)
+	}, workerCount, tenantBounds, serviceOptions...)
+	registerCacheMetrics(registry, caches)
+	registry.NewGaugeFunc("rockets_buffered_messages", "Messages waiting in the reorder buffers of every tenant.",
+		func() float64 {
+			buffered := 0
+			for _, tenant := range tenants.Tenants() {
+				buffered += tenant.Service.BufferedMessages()
+			}
+			return float64(buffered)
+		})
+	registry.NewGaugeFunc("rockets_tenants", "Tenants currently open.",
+		func() float64 { return float64(len(tenants.Tenants())) })
+
+	// Open the default tenant up front so a broken store stops the server right away
+	if _, err := tenants.Get(domain.DefaultTenantID); err != nil {
+		slog.Error("Failed to open event store", "err", err)
+		os.Exit(1)
+	}
+
+	// perTenant serves a route with the handler of the request's tenant
+	perTenant := func(handler func(*application.Tenant) http.HandlerFunc) http.HandlerFunc {
+		return api.WithTenant(tenantResolver, tenants, handler)
+	}
+	// admin mounts an admin endpoint behind ADMIN_API_KEY; with tenant API keys but no admin
+	// key it stays unmounted, as any tenant could use it otherwise
+	admin := func(pattern string, handler http.HandlerFunc) {
+		if adminKey == "" && len(apiKeys) > 0 {
+			slog.Warn("ADMIN_API_KEY is not set: admin endpoint disabled", "endpoint", pattern)
+			return
+		}
+		http.HandleFunc(pattern, api.WithAdminKey(adminKey, handler))
+	}
 
 	// Configure HTTP handlers
 	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -47,12 +174,52 @@ func main() {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 		}
 	})
-	http.HandleFunc("/messages", api.HandleMessages(workerPool))
+	http.HandleFunc("/messages", perTenant(func(t *application.Tenant) http.HandlerFunc {
+		return api.HandleMessages(t.Pool)
+	}))
 	// Register routes to list and get by channel
-	http.HandleFunc("/rockets", api.HandleListRockets(rocketService))
-	http.HandleFunc("/rockets/", api.HandleListRockets(rocketService))
+	listRockets := perTenant(func(t *application.Tenant) http.HandlerFunc {
+		return api.HandleListRockets(t.Service)
+	})
+	http.HandleFunc("/rockets", listRockets)
+	http.HandleFunc("/rockets/", listRockets)
+	// Fleets group rockets and report on them as a whole
+	fleets := perTenant(func(t *application.Tenant) http.HandlerFunc {
+		return api.HandleFleets(t.Fleets)
+	})
+	http.HandleFunc("/fleets", fleets)
+	http.HandleFunc("/fleets/", fleets)
+	// Global event log of the tenant in commit order
+	http.HandleFunc("/events", perTenant(func(t *application.Tenant) http.HandlerFunc {
+		return api.HandleReadAll(t.Service)
+	}))
+	// Admin endpoint to verify the hash chains of the event store
+	admin("/admin/verify", perTenant(func(t *application.Tenant) http.HandlerFunc {
+		return api.HandleVerifyChains(t.Service)
+	}))
+	// Admin endpoints to back up and restore the event store as NDJSON
+	admin("/admin/export", perTenant(func(t *application.Tenant) http.HandlerFunc {
+		return api.HandleExport(t.Archive)
+	}))
+	admin("/admin/import", perTenant(func(t *application.Tenant) http.HandlerFunc {
+		return api.HandleImport(t.Archive)
+	}))
+	// Admin endpoint to erase a channel (crypto-shredding when ENCRYPTION_KEY_DIR is set)
+	admin("/admin/erase", perTenant(func(t *application.Tenant) http.HandlerFunc {
+		return api.HandleErase(t.Service)
+	}))
+	// Admin endpoint to list and extend the mission registry. The registry is shared by every
+	// tenant, so it is mounted outside the tenant middleware.
+	admin("/admin/missions", api.HandleMissions(missions))
+	http.HandleFunc("/metrics", api.HandleMetrics(registry))
 	// Debug endpoint to see buffer state
-	http.HandleFunc("/debug/buffer", api.HandleDebugBuffer(rocketService))
+	http.HandleFunc("/debug/buffer", perTenant(func(t *application.Tenant) http.HandlerFunc {
+		return api.HandleDebugBuffer(t.Service)
+	}))
+	// Debug endpoint to see the messages that were rejected and why
+	http.HandleFunc("/debug/dead-letters", perTenant(func(t *application.Tenant) http.HandlerFunc {
+		return api.HandleDeadLetters(t.Service)
+	}))
 
 	// Start HTTP server
 	server := &http.Server{
@@ -84,9 +251,353 @@ func main() {
 		slog.Error("Server shutdown error", "err", err)
 	}
 
-	// Stop workers after shutting down server
+	// Stop workers after shutting down server, then close the event stores
 	workerCancel()
-	workerPool.Wait()
+	if err := tenants.Close(); err != nil {
+		slog.Error("Event store close error", "err", err)
+	}
 
 	slog.Info("Server stopped")
 }
+
+// openTenant opens the store partition of a tenant: durable on disk when EVENT_STORE_DIR is
+// set, Kafka otherwise (in-memory only when KAFKA_BROKERS is not set). newEventBus creates the
+// bus its committed events are published to.
+func openTenant(tenantID string, caches *tenantCaches, newEventBus func() *infrastructure.EventBus) (*application.TenantBackend, error) {
+	// Event payloads are encrypted with per-channel data keys when ENCRYPTION_KEY_DIR is set
+	codec := infrastructure.DefaultEventCodec()
+	var keys *infrastructure.FileKeyStore
+	if dir := os.Getenv("ENCRYPTION_KEY_DIR"); dir != "" {
+		keyStore, err := infrastructure.NewFileKeyStore(infrastructure.TenantDir(dir, tenantID))
+		if err != nil {
+			return nil, err
+		}
+		codec, keys = infrastructure.EncryptedEventCodec(keyStore), keyStore
+	}
+
+	storeDir, fleetDir := "", ""
+	if dir := os.Getenv("EVENT_STORE_DIR"); dir != "" {
+		storeDir, fleetDir = infrastructure.TenantDir(dir, tenantID), infrastructure.FleetDir(dir, tenantID)
+	}
+	topic := os.Getenv("KAFKA_TOPIC")
+	eventStore, closeRockets, err := openEventStore(storeDir, infrastructure.TenantTopic(topic, tenantID), codec)
+	if err != nil {
+		return nil, err
+	}
+	// Fleet streams get a store of their own so they never mix with rocket channels. They
+	// only name rockets, so they are not encrypted and erasing a channel leaves them alone.
+	fleetStore, closeFleets, err := openEventStore(fleetDir, infrastructure.FleetTopic(topic, tenantID), nil)
+	if err != nil {
+		_ = closeRockets()
+		return nil, err
+	}
+	closeStore := func() error {
+		return errors.Join(closeRockets(), closeFleets())
+	}
+
+	// Repository: bounded in-memory cache over the event store, snapshots when SNAPSHOT_DIR is set
+	repositoryOptions := []infrastructure.RepositoryOption{infrastructure.WithCache(cacheConfig())}
+	if dir := os.Getenv("SNAPSHOT_DIR"); dir != "" {
+		snapshotStore, err := infrastructure.NewFileSnapshotStore(infrastructure.TenantDir(dir, tenantID))
+		if err != nil {
+			_ = closeStore()
+			return nil, err
+		}
+		repositoryOptions = append(repositoryOptions, infrastructure.WithSnapshots(snapshotStore, snapshotPolicy()))
+	}
+	bus := newEventBus()
+	repositoryOptions = append(repositoryOptions, infrastructure.WithEventPublisher(bus))
+	repository := infrastructure.NewRocketRepository(eventStore, repositoryOptions...)
+	caches.add(repository)
+
+	backend := &application.TenantBackend{
+		EventStore: eventStore,
+		Repository: repository,
+		Fleets:     infrastructure.NewFleetRepository(fleetStore),
+		Serializer: codec,
+		Close: func() error {
+			// Let the async handlers finish before the store goes away
+			bus.Close()
+			caches.remove(repository)
+			return closeStore()
+		},
+	}
+	if keys != nil {
+		backend.Keys = keys
+	}
+	return backend, nil
+}
+
+// openEventStore opens a file event store in dir, or the Kafka topic when dir is empty
+// (in-memory only when KAFKA_BROKERS is not set). A nil codec is the default one.
+func openEventStore(dir, topic string, codec *infrastructure.EventCodec) (domain.EventStore, func() error, error) {
+	if dir != "" {
+		cfg := fileEventStoreConfig(dir)
+		cfg.Codec = codec
+		fileStore, err := infrastructure.NewFileEventStore(cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		return fileStore, fileStore.Close, nil
+	}
+	kafkaEventStore, err := infrastructure.NewKafkaEventStore(infrastructure.KafkaConfig{
+		Brokers: os.Getenv("KAFKA_BROKERS"),
+		Topic:   topic,
+		Codec:   codec,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return kafkaEventStore, kafkaEventStore.Close, nil
+}
+
+// fileEventStoreConfig builds the file event store configuration from the environment
+func fileEventStoreConfig(dir string) infrastructure.FileEventStoreConfig {
+	cfg := infrastructure.FileEventStoreConfig{
+		Dir:        dir,
+		SyncPolicy: infrastructure.SyncPolicy(os.Getenv("EVENT_STORE_FSYNC")),
+	}
+	if value := os.Getenv("EVENT_STORE_FSYNC_INTERVAL"); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil && parsed > 0 {
+			cfg.SyncInterval = parsed
+		}
+	}
+	if value := os.Getenv("EVENT_STORE_SEGMENT_BYTES"); value != "" {
+		if parsed, err := strconv.ParseInt(value, 10, 64); err == nil && parsed > 0 {
+			cfg.MaxSegmentBytes = parsed
+		}
+	}
+	return cfg
+}
+
+// snapshotPolicy builds the snapshot policy from the environment (default: every 1000 events)
+func snapshotPolicy() infrastructure.SnapshotPolicy {
+	policy := infrastructure.SnapshotPolicy{EveryEvents: 1000}
+	if value := os.Getenv("SNAPSHOT_EVERY_EVENTS"); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed >= 0 {
+			policy.EveryEvents = parsed
+		}
+	}
+	if value := os.Getenv("SNAPSHOT_INTERVAL"); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil && parsed > 0 {
+			policy.Interval = parsed
+		}
+	}
+	return policy
+}
+
+// cacheConfig builds the rocket cache bounds of each tenant from the environment
+// (default: 100000 rockets, no TTL)
+func cacheConfig() infrastructure.CacheConfig {
+	cfg := infrastructure.CacheConfig{MaxEntries: 100000}
+	if value := os.Getenv("ROCKET_CACHE_SIZE"); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed >= 0 {
+			cfg.MaxEntries = parsed
+		}
+	}
+	if value := os.Getenv("ROCKET_CACHE_TTL"); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil && parsed > 0 {
+			cfg.TTL = parsed
+		}
+	}
+	return cfg
+}
+
+// messageTimePolicy builds the message time policy from the environment: MESSAGE_TIME_POLICY
+// is "flag" (default), "reject" or "off" (nil), MESSAGE_TIME_TOLERANCE defaults to 1s
+func messageTimePolicy() (*domain.TimePolicy, error) {
+	policy := &domain.TimePolicy{Tolerance: time.Second}
+	switch mode := os.Getenv("MESSAGE_TIME_POLICY"); mode {
+	case "", "flag":
+	case "reject":
+		policy.Reject = true
+	case "off":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown MESSAGE_TIME_POLICY %q (want flag, reject or off)", mode)
+	}
+	if value := os.Getenv("MESSAGE_TIME_TOLERANCE"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed < 0 {
+			return nil, fmt.Errorf("invalid MESSAGE_TIME_TOLERANCE %q", value)
+		}
+		policy.Tolerance = parsed
+	}
+	return policy, nil
+}
+
+// tenantLimits builds the tenant limits from the environment: MAX_TENANTS tenants open at once
+// (default 100, 0 is unbounded) and TENANT_IDLE_TIMEOUT before an unused tenant is closed
+// (default 15m, 0 keeps them open)
+func tenantLimits() (application.TenantLimits, error) {
+	limits := application.TenantLimits{MaxTenants: 100, IdleTimeout: 15 * time.Minute}
+	if value := os.Getenv("MAX_TENANTS"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			return limits, fmt.Errorf("invalid MAX_TENANTS %q", value)
+		}
+		limits.MaxTenants = parsed
+	}
+	if value := os.Getenv("TENANT_IDLE_TIMEOUT"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed < 0 {
+			return limits, fmt.Errorf("invalid TENANT_IDLE_TIMEOUT %q", value)
+		}
+		limits.IdleTimeout = parsed
+	}
+	return limits, nil
+}
+
+// bufferLimits builds the reorder buffer bounds of each tenant from the environment (default:
+// 100000 messages, 1000 per channel, 1000 ahead of the next expected message; 0 is unbounded)
+// and the overflow policy, BUFFER_OVERFLOW ("reject" by default, "evict_furthest" or "dead_letter")
+func bufferLimits() (application.BufferLimits, error) {
+	limits := application.BufferLimits{MaxMessages: 100000, MaxPerChannel: 1000, MaxGap: 1000, Overflow: application.OverflowReject}
+	for name, limit := range map[string]*int{
+		"BUFFER_MAX_MESSAGES":    &limits.MaxMessages,
+		"BUFFER_MAX_PER_CHANNEL": &limits.MaxPerChannel,
+		"BUFFER_MAX_GAP":         &limits.MaxGap,
+	} {
+		if value := os.Getenv(name); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 0 {
+				return limits, fmt.Errorf("invalid %s %q", name, value)
+			}
+			*limit = parsed
+		}
+	}
+	if value := os.Getenv("BUFFER_OVERFLOW"); value != "" {
+		policy, err := application.ParseOverflowPolicy(value)
+		if err != nil {
+			return limits, fmt.Errorf("invalid BUFFER_OVERFLOW: %w", err)
+		}
+		limits.Overflow = policy
+	}
+	return limits, nil
+}
+
+// gapPolicies builds the gap policies from the environment: GAP_TIMEOUT (default 0, wait
+// forever), GAP_POLICY ("wait" by default, "skip" or "redeliver") and GAP_POLICY_CHANNELS,
+// per-channel overrides as "channel=action[:timeout],..."
+func gapPolicies() (application.GapPolicies, error) {
+	policies := application.GapPolicies{Default: application.GapPolicy{Action: application.GapWait}}
+	if value := os.Getenv("GAP_TIMEOUT"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed < 0 {
+			return policies, fmt.Errorf("invalid GAP_TIMEOUT %q", value)
+		}
+		policies.Default.Timeout = parsed
+	}
+	if value := os.Getenv("GAP_POLICY"); value != "" {
+		action, err := application.ParseGapAction(value)
+		if err != nil {
+			return policies, fmt.Errorf("invalid GAP_POLICY: %w", err)
+		}
+		policies.Default.Action = action
+	}
+	for _, entry := range strings.Split(os.Getenv("GAP_POLICY_CHANNELS"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		channel, spec, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(channel) == "" {
+			return policies, fmt.Errorf("invalid GAP_POLICY_CHANNELS entry %q (want channel=action[:timeout])", entry)
+		}
+		policy := policies.Default
+		name, timeout, hasTimeout := strings.Cut(spec, ":")
+		action, err := application.ParseGapAction(strings.TrimSpace(name))
+		if err != nil {
+			return policies, fmt.Errorf("invalid GAP_POLICY_CHANNELS entry %q: %w", entry, err)
+		}
+		policy.Action = action
+		if hasTimeout {
+			parsed, err := time.ParseDuration(strings.TrimSpace(timeout))
+			if err != nil || parsed < 0 {
+				return policies, fmt.Errorf("invalid GAP_POLICY_CHANNELS timeout %q", timeout)
+			}
+			policy.Timeout = parsed
+		}
+		if policies.Channels == nil {
+			policies.Channels = make(map[string]application.GapPolicy)
+		}
+		policies.Channels[strings.TrimSpace(channel)] = policy
+	}
+	return policies, nil
+}
+
+// redeliveryCallback posts every redelivery request as JSON to url; any status but 2xx fails
+func redeliveryCallback(url string) func(application.RedeliveryRequest) error {
+	client := &http.Client{Timeout: 5 * time.Second}
+	return func(request application.RedeliveryRequest) error {
+		body, err := json.Marshal(request)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return fmt.Errorf("redelivery endpoint answered %s", resp.Status)
+		}
+		return nil
+	}
+}
+
+// tenantCaches collects the repositories of every tenant, to report their caches together
+type tenantCaches struct {
+	mu           sync.Mutex
+	repositories []*infrastructure.RocketRepository
+	closed       infrastructure.CacheStats // counters of the tenants closed so far
+}
+
+func (c *tenantCaches) add(repository *infrastructure.RocketRepository) {
+	c.mu.Lock()
+	c.repositories = append(c.repositories, repository)
+	c.mu.Unlock()
+}
+
+// remove forgets the repository of a closed tenant, keeping its counters in the totals
+func (c *tenantCaches) remove(repository *infrastructure.RocketRepository) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, r := range c.repositories {
+		if r == repository {
+			stats := r.CacheStats()
+			c.closed.Hits += stats.Hits
+			c.closed.Misses += stats.Misses
+			c.closed.Evictions += stats.Evictions
+			c.repositories = append(c.repositories[:i], c.repositories[i+1:]...)
+			return
+		}
+	}
+}
+
+// stats sums the cache counters of every tenant
+func (c *tenantCaches) stats() infrastructure.CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	total := c.closed
+	for _, repository := range c.repositories {
+		stats := repository.CacheStats()
+		total.Hits += stats.Hits
+		total.Misses += stats.Misses
+		total.Evictions += stats.Evictions
+		total.Size += stats.Size
+	}
+	return total
+}
+
+// registerCacheMetrics exposes the rocket cache counters, summed over every tenant
+func registerCacheMetrics(registry *metrics.Registry, caches *tenantCaches) {
+	registry.NewCounterFunc("rockets_cache_hits_total", "Rocket lookups served from the cache.",
+		func() int64 { return caches.stats().Hits })
+	registry.NewCounterFunc("rockets_cache_misses_total", "Rocket lookups that rebuilt the rocket from the event store.",
+		func() int64 { return caches.stats().Misses })
+	registry.NewCounterFunc("rockets_cache_evictions_total", "Rockets evicted from the cache by size or TTL.",
+		func() int64 { return caches.stats().Evictions })
+	registry.NewGaugeFunc("rockets_cache_size", "Rockets currently cached.",
+		func() float64 { return float64(caches.stats().Size) })
+}
@@ -0,0 +1,207 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"rockets/internal/application"
+	"rockets/internal/domain"
+	"rockets/internal/metrics"
+)
+
+// HandleVerifyChains  GET /admin/verify
+// walks the event store and reports the first broken hash chain link of every channel
+func HandleVerifyChains(service *application.RocketApplicationService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		report, err := service.VerifyEventChains()
+		if errors.Is(err, domain.ErrHashChainUnsupported) {
+			http.Error(w, err.Error(), http.StatusNotImplemented)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if !report.Intact {
+			slog.Warn("Event hash chain verification failed", "breaks", len(report.Breaks))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(report); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
+
+// HandleMetrics  GET /metrics
+// exposes the server metrics in the Prometheus text format
+func HandleMetrics(registry *metrics.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		if err := registry.WriteText(w); err != nil {
+			slog.Error("Failed to write metrics", "err", err)
+		}
+	}
+}
+
+// HandleExport  GET /admin/export[?channel={channel}&channel=...]
+// streams every event (or the events of the given channels) as NDJSON
+func HandleExport(archive *application.ArchiveService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var channels []string
+		for _, value := range r.URL.Query()["channel"] {
+			for _, channel := range strings.Split(value, ",") {
+				if channel = strings.TrimSpace(channel); channel != "" {
+					channels = append(channels, channel)
+				}
+			}
+		}
+
+		// A full dump can outlive the server's write timeout
+		_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		// The status is sent with the first line, so a failure later on can only be logged
+		if count, err := archive.Export(w, channels); err != nil {
+			slog.Error("Export failed", "events_written", count, "err", err)
+		}
+	}
+}
+
+// HandleImport  POST /admin/import
+// appends the events of an NDJSON archive after validating the order of every channel
+func HandleImport(archive *application.ArchiveService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// A full dump can outlive the server's read timeout
+		_ = http.NewResponseController(w).SetReadDeadline(time.Time{})
+
+		result, err := archive.Import(r.Body)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
+
+type eraseRequest struct {
+	Channel string `json:"channel"`
+	Reason  string `json:"reason"`
+}
+
+// HandleErase  POST /admin/erase {"channel": "...", "reason": "..."}
+// erases a channel: its history is redacted and its data key destroyed
+func HandleErase(service *application.RocketApplicationService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req eraseRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request format", http.StatusBadRequest)
+			return
+		}
+
+		erasure, err := service.EraseChannel(req.Channel, req.Reason)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(erasure); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
+
+// missionRegistryDTO is the mission registry as exposed by /admin/missions
+type missionRegistryDTO struct {
+	Strict   bool                       `json:"strict"`
+	Missions []domain.RegisteredMission `json:"missions"`
+}
+
+// registerMissionRequest is the body of POST /admin/missions
+type registerMissionRequest struct {
+	Name     string `json:"name"`
+	Category string `json:"category"`
+}
+
+// HandleMissions  GET /admin/missions lists the mission registry
+// POST /admin/missions {"name": "Mars Mission", "category": "exploration"} registers a mission
+func HandleMissions(registry *domain.MissionRegistry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPost:
+			var req registerMissionRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, "Invalid request format", http.StatusBadRequest)
+				return
+			}
+			if err := registry.Register(req.Name, domain.Mission(req.Category)); err != nil {
+				writeError(w, err)
+				return
+			}
+			slog.Info("Mission registered", "name", req.Name, "category", req.Category)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(missionRegistryDTO{Strict: registry.Strict(), Missions: registry.List()}); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
+
+// ParseMissions parses startup mission registrations ("name=category,...", e.g.
+// "Mars Mission=exploration,ISS Cargo=resupply") into registry
+func ParseMissions(registry *domain.MissionRegistry, value string) error {
+	for _, pair := range strings.Split(value, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		name, category, ok := strings.Cut(pair, "=")
+		if !ok {
+			return fmt.Errorf("invalid mission %q: expected name=category", pair)
+		}
+		if err := registry.Register(name, domain.Mission(strings.TrimSpace(category))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"rockets/internal/application"
+	"rockets/internal/domain"
+)
+
+// headerRejectionReason carries the reason of a rejected request, as used in dead letters and metrics
+const headerRejectionReason = "X-Rejection-Reason"
+
+// statusForError maps an error to the HTTP status code it is reported with
+func statusForError(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrInvalidPayload),
+		errors.Is(err, domain.ErrUnknownAction),
+		errors.Is(err, domain.ErrUnknownMission),
+		errors.Is(err, domain.ErrInvalidTenant),
+		errors.Is(err, domain.ErrInvalidFleet),
+		errors.Is(err, application.ErrInvalidArchive):
+		return http.StatusBadRequest
+	case errors.Is(err, application.ErrRocketNotFound),
+		errors.Is(err, domain.ErrFleetNotFound),
+		errors.Is(err, domain.ErrNotFleetMember):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrAlreadyLaunched),
+		errors.Is(err, domain.ErrRocketExploded),
+		errors.Is(err, domain.ErrNotLaunched),
+		errors.Is(err, domain.ErrInvalidTransition),
+		errors.Is(err, domain.ErrOutOfOrder),
+		errors.Is(err, domain.ErrStageCount),
+		errors.Is(err, domain.ErrEnvelopeExceeded),
+		errors.Is(err, domain.ErrTimeRegression),
+		errors.Is(err, domain.ErrFleetExists),
+		errors.Is(err, domain.ErrAlreadyFleetMember),
+		errors.Is(err, domain.ErrConcurrencyConflict),
+		errors.Is(err, application.ErrGapTooLarge):
+		return http.StatusConflict
+	case errors.Is(err, application.ErrBufferFull):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, domain.ErrOutcomeUnknown):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, domain.ErrChannelErased):
+		return http.StatusGone
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// writeError reports err with its status code and, for rejections, its reason
+func writeError(w http.ResponseWriter, err error) {
+	status := statusForError(err)
+	if status != http.StatusInternalServerError && status != http.StatusNotFound {
+		w.Header().Set(headerRejectionReason, application.RejectionReason(err))
+	}
+	http.Error(w, err.Error(), status)
+}
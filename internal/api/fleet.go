@@ -0,0 +1,110 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"rockets/internal/application"
+)
+
+type createFleetRequest struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type addFleetRocketRequest struct {
+	Channel string `json:"channel"`
+}
+
+// HandleFleets serves the fleets of a tenant:
+//
+//	GET    /fleets                          list fleets
+//	POST   /fleets                          create a fleet {"id": "...", "name": "..."}
+//	GET    /fleets/{id}                     a fleet with its rockets and their stats
+//	GET    /fleets/{id}/events              the events of a fleet
+//	POST   /fleets/{id}/rockets             add a rocket {"channel": "..."}
+//	DELETE /fleets/{id}/rockets/{channel}   remove a rocket
+func HandleFleets(service *application.FleetService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/fleets"), "/")
+		if path == "" {
+			handleFleetCollection(service, w, r)
+			return
+		}
+
+		id, rest, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+		switch {
+		case id == "":
+			http.Error(w, "Not found", http.StatusNotFound)
+		case rest == "":
+			if r.Method != http.MethodGet {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			fleet, err := service.GetFleet(id)
+			writeResult(w, http.StatusOK, fleet, err)
+		case rest == "events":
+			if r.Method != http.MethodGet {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			events, err := service.ListFleetEvents(id)
+			writeResult(w, http.StatusOK, events, err)
+		case rest == "rockets":
+			if r.Method != http.MethodPost {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			var req addFleetRocketRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, "Invalid request format", http.StatusBadRequest)
+				return
+			}
+			fleet, err := service.AddRocket(id, req.Channel)
+			writeResult(w, http.StatusOK, fleet, err)
+		case strings.HasPrefix(rest, "rockets/"):
+			if r.Method != http.MethodDelete {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			fleet, err := service.RemoveRocket(id, strings.TrimPrefix(rest, "rockets/"))
+			writeResult(w, http.StatusOK, fleet, err)
+		default:
+			http.Error(w, "Not found", http.StatusNotFound)
+		}
+	}
+}
+
+// handleFleetCollection serves /fleets itself
+func handleFleetCollection(service *application.FleetService, w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		fleets, err := service.ListFleets()
+		writeResult(w, http.StatusOK, fleets, err)
+	case http.MethodPost:
+		var req createFleetRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request format", http.StatusBadRequest)
+			return
+		}
+		fleet, err := service.CreateFleet(req.ID, req.Name)
+		writeResult(w, http.StatusCreated, fleet, err)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// writeResult writes the result of a service call: err if there is one, value with status otherwise
+func writeResult(w http.ResponseWriter, status int, value any, err error) {
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		slog.Error("Failed to write response", "err", err)
+	}
+}
@@ -2,15 +2,18 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"rockets/internal/application"
+	"rockets/internal/domain"
 )
 
 type LunarMessage struct {
@@ -31,7 +34,7 @@ func convertLunarMessageToDTO(msg *LunarMessage) (*application.ProcessMessageDTO
 		// Try parsing with simpler format
 		t, err = time.Parse(time.RFC3339, msg.Metadata.MessageTime)
 		if err != nil {
-			return nil, fmt.Errorf("invalid messageTime: %w", err)
+			return nil, fmt.Errorf("%w: invalid messageTime: %v", domain.ErrInvalidPayload, err)
 		}
 	}
 	timestamp := t.UnixMilli()
@@ -52,7 +55,11 @@ func convertLunarMessageToDTO(msg *LunarMessage) (*application.ProcessMessageDTO
 			}
 		}
 		if mission, ok := msg.Message["mission"]; ok {
-			dto.Param = mission.(string)
+			v, ok := mission.(string)
+			if !ok {
+				return nil, fmt.Errorf("%w: mission must be a string", domain.ErrInvalidPayload)
+			}
+			dto.Param = v
 		}
 		if speed, ok := msg.Message["launchSpeed"]; ok {
 			if v, ok := speed.(float64); ok {
@@ -79,17 +86,51 @@ func convertLunarMessageToDTO(msg *LunarMessage) (*application.ProcessMessageDTO
 	case "RocketExploded":
 		dto.Action = "explode"
 		if reason, ok := msg.Message["reason"]; ok {
-			dto.Param = reason.(string)
+			v, ok := reason.(string)
+			if !ok {
+				return nil, fmt.Errorf("%w: reason must be a string", domain.ErrInvalidPayload)
+			}
+			dto.Param = v
 		}
 
 	case "RocketMissionChanged":
 		dto.Action = "change_mission"
 		if mission, ok := msg.Message["newMission"]; ok {
-			dto.Param = mission.(string)
+			v, ok := mission.(string)
+			if !ok {
+				return nil, fmt.Errorf("%w: newMission must be a string", domain.ErrInvalidPayload)
+			}
+			dto.Param = v
+		}
+
+	case "RocketLanded":
+		dto.Action = "land"
+		if site, ok := msg.Message["site"]; ok {
+			v, ok := site.(string)
+			if !ok {
+				return nil, fmt.Errorf("%w: site must be a string", domain.ErrInvalidPayload)
+			}
+			dto.Param = v
+		}
+
+	case "RocketRefueled":
+		dto.Action = "refuel"
+		amount, ok := msg.Message["amount"].(float64)
+		if !ok {
+			return nil, fmt.Errorf("%w: amount must be a number", domain.ErrInvalidPayload)
 		}
+		dto.Value = int(amount)
+
+	case "RocketStageSeparated":
+		dto.Action = "separate_stage"
+		remaining, ok := msg.Message["remainingStages"].(float64)
+		if !ok {
+			return nil, fmt.Errorf("%w: remainingStages must be a number", domain.ErrInvalidPayload)
+		}
+		dto.Value = int(remaining)
 
 	default:
-		return nil, fmt.Errorf("unknown messageType: %s", msg.Metadata.MessageType)
+		return nil, fmt.Errorf("%w: unknown messageType %s", domain.ErrUnknownAction, msg.Metadata.MessageType)
 	}
 
 	return dto, nil
@@ -109,6 +150,14 @@ func getNextMessageNumber() int64 {
 	return messageCounter
 }
 
+// Request headers that describe the origin of a message
+const (
+	headerMessageID     = "X-Message-ID"     // id of the message, the causation id of its events
+	headerCorrelationID = "X-Correlation-ID" // generated when missing and echoed in the response
+	headerSource        = "X-Source"         // producer that sent the message
+	defaultSource       = "http"
+)
+
 // HandleMessages  POST /messages
 func HandleMessages(pool *application.WorkerPool) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -116,6 +165,7 @@ func HandleMessages(pool *application.WorkerPool) http.HandlerFunc {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 			return
 		}
+		receivedAt := time.Now()
 
 		// Try to parse as official challenge format
 		var lunarMsg LunarMessage
@@ -132,7 +182,7 @@ func HandleMessages(pool *application.WorkerPool) http.HandlerFunc {
 		// Convert to internal format
 		dto, err := convertLunarMessageToDTO(&lunarMsg)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
+			writeError(w, err)
 			return
 		}
 
@@ -154,6 +204,19 @@ func HandleMessages(pool *application.WorkerPool) http.HandlerFunc {
 			dto.Time = time.Now().UnixMilli()
 		}
 
+		// Origin of the message, stored in the metadata of the resulting events
+		dto.MessageID = r.Header.Get(headerMessageID)
+		dto.CorrelationID = r.Header.Get(headerCorrelationID)
+		if dto.CorrelationID == "" {
+			dto.CorrelationID = domain.NewID()
+		}
+		dto.Source = r.Header.Get(headerSource)
+		if dto.Source == "" {
+			dto.Source = defaultSource
+		}
+		dto.ReceivedAt = receivedAt.UnixMilli()
+		w.Header().Set(headerCorrelationID, dto.CorrelationID)
+
 		slog.Debug("Enqueueing to worker pool",
 			"channel", dto.Channel,
 			"number", dto.Number,
@@ -164,6 +227,10 @@ func HandleMessages(pool *application.WorkerPool) http.HandlerFunc {
 				"channel", dto.Channel,
 				"number", dto.Number,
 				"err", err)
+			if errors.Is(err, application.ErrBufferFull) || errors.Is(err, application.ErrGapTooLarge) {
+				writeError(w, err)
+				return
+			}
 			http.Error(w, err.Error(), http.StatusServiceUnavailable)
 			return
 		}
@@ -174,7 +241,7 @@ func HandleMessages(pool *application.WorkerPool) http.HandlerFunc {
 
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusAccepted)
-		if err := json.NewEncoder(w).Encode(map[string]string{"status": "queued"}); err != nil {
+		if err := json.NewEncoder(w).Encode(map[string]string{"status": "queued", "correlationId": dto.CorrelationID}); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 		}
 	}
@@ -201,7 +268,7 @@ func HandleListRockets(service *application.RocketApplicationService) http.Handl
 
 				events, err := service.ListEvents(channel)
 				if err != nil {
-					http.Error(w, "Not found", http.StatusNotFound)
+					writeError(w, err)
 					return
 				}
 
@@ -220,7 +287,7 @@ func HandleListRockets(service *application.RocketApplicationService) http.Handl
 
 			rocket, err := service.GetRocket(channel)
 			if err != nil {
-				http.Error(w, "Not found", http.StatusNotFound)
+				writeError(w, err)
 				return
 			}
 
@@ -250,6 +317,70 @@ func HandleListRockets(service *application.RocketApplicationService) http.Handl
 	}
 }
 
+// Page size of GET /events
+const (
+	defaultEventPageSize = 100
+	maxEventPageSize     = 1000
+)
+
+// HandleReadAll  GET /events?from={position}&limit={n}
+// pages through every stored event in commit order
+func HandleReadAll(service *application.RocketApplicationService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		from := int64(1)
+		if value := r.URL.Query().Get("from"); value != "" {
+			parsed, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || parsed < 1 {
+				http.Error(w, "invalid from: must be a position >= 1", http.StatusBadRequest)
+				return
+			}
+			from = parsed
+		}
+
+		limit := defaultEventPageSize
+		if value := r.URL.Query().Get("limit"); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 1 {
+				http.Error(w, "invalid limit: must be >= 1", http.StatusBadRequest)
+				return
+			}
+			limit = min(parsed, maxEventPageSize)
+		}
+
+		page, err := service.ReadAll(from, limit)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(page); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
+
+// HandleDeadLetters  GET /debug/dead-letters
+// shows the most recently rejected messages with the reason they were rejected
+func HandleDeadLetters(service *application.RocketApplicationService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(service.GetDeadLetters()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
+
 // HandleDebugBuffer shows the messages in the buffer
 // not mandatory but good to have for debugging
 func HandleDebugBuffer(service *application.RocketApplicationService) http.HandlerFunc {
@@ -4,19 +4,21 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
 	"rockets/internal/application"
+	"rockets/internal/domain"
 	"rockets/internal/infrastructure"
 )
 
 func setupTestServer() (*application.WorkerPool, *application.RocketApplicationService) {
-	eventStore := infrastructure.NewKafkaEventStore("localhost:9092")
+	eventStore, _ := infrastructure.NewKafkaEventStore(infrastructure.KafkaConfig{})
 	repository := infrastructure.NewRocketRepository(eventStore)
-	service := application.NewRocketApplicationService(repository, eventStore)
+	service := application.NewRocketApplicationService(repository, eventStore, application.WithBufferShards(3))
 
 	ctx := context.Background()
 	pool := application.NewWorkerPool(service, 3)
@@ -197,4 +199,374 @@ func TestHandleListRocketsGetByChannel(t *testing.T) {
 	if rocket.Type != "Falcon-9" {
 		t.Errorf("Expected type Falcon-9, got %s", rocket.Type)
 	}
+	if rocket.Version != 1 {
+		t.Errorf("Expected version 1, got %d", rocket.Version)
+	}
+}
+
+// TestHandleReadAllPages verifies that GET /events pages through the global log.
+// Expected result: HTTP 200 OK, events in commit order with positions, nextPosition after the page.
+func TestHandleReadAllPages(t *testing.T) {
+	// Arrange
+	_, service := setupTestServer()
+	for _, channel := range []string{"rocket-log-1", "rocket-log-2", "rocket-log-3"} {
+		msg := &application.ProcessMessageDTO{
+			Channel:    channel,
+			Number:     1,
+			Action:     "launch",
+			RocketType: "Falcon-9",
+			Value:      15000,
+			Param:      "exploration",
+			Time:       1234567890,
+		}
+		if err := service.ProcessMessage(msg); err != nil {
+			t.Fatalf("Expected no error processing message, got %v", err)
+		}
+	}
+
+	handler := HandleReadAll(service)
+	req := httptest.NewRequest(http.MethodGet, "/events?from=2&limit=1", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	handler(w, req)
+
+	// Assert
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	var page application.EventPageDTO
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if len(page.Events) != 1 || page.Events[0].Position != 2 || page.Events[0].Channel != "rocket-log-2" {
+		t.Fatalf("Expected the event at position 2 of rocket-log-2, got %+v", page.Events)
+	}
+	if page.Events[0].Version != 1 {
+		t.Errorf("Expected version 1 within rocket-log-2, got %d", page.Events[0].Version)
+	}
+	if page.NextPosition != 3 {
+		t.Errorf("Expected nextPosition 3, got %d", page.NextPosition)
+	}
+
+	// An invalid position is rejected
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, "/events?from=0", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for from=0, got %d", w.Code)
+	}
+}
+
+// TestHandleVerifyChainsUnsupported verifies the admin endpoint on a store without hash chains.
+// Expected result: HTTP 501 Not Implemented for the in-memory store.
+func TestHandleVerifyChainsUnsupported(t *testing.T) {
+	// Arrange
+	_, service := setupTestServer()
+	handler := HandleVerifyChains(service)
+	req := httptest.NewRequest(http.MethodGet, "/admin/verify", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	handler(w, req)
+
+	// Assert
+	if w.Code != http.StatusNotImplemented {
+		t.Errorf("Expected status 501, got %d", w.Code)
+	}
+}
+
+// TestHandleMessagesEventMetadata verifies that the origin of a message reaches its events.
+// Expected result: GET /rockets/{channel}/events returns the correlation, causation and source headers,
+// an event ID and both timestamps.
+func TestHandleMessagesEventMetadata(t *testing.T) {
+	// Arrange
+	pool, service := setupTestServer()
+	payload := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"channel":       "rocket-meta",
+			"messageNumber": 1,
+			"messageTime":   "2024-01-01T10:00:00Z",
+			"messageType":   "RocketLaunched",
+		},
+		"message": map[string]interface{}{
+			"type":        "Falcon-9",
+			"launchSpeed": float64(15000),
+			"mission":     "exploration",
+		},
+	}
+	body, _ := json.Marshal(payload)
+	req := httptest.NewRequest(http.MethodPost, "/messages", bytes.NewReader(body))
+	req.Header.Set("X-Correlation-ID", "corr-42")
+	req.Header.Set("X-Message-ID", "msg-1")
+	req.Header.Set("X-Source", "telemetry-gateway")
+	w := httptest.NewRecorder()
+
+	// Act
+	HandleMessages(pool)(w, req)
+	time.Sleep(100 * time.Millisecond)
+	events := httptest.NewRecorder()
+	HandleListRockets(service)(events, httptest.NewRequest(http.MethodGet, "/rockets/rocket-meta/events", nil))
+
+	// Assert
+	if w.Header().Get("X-Correlation-ID") != "corr-42" {
+		t.Errorf("Expected correlation ID echoed in the response, got %q", w.Header().Get("X-Correlation-ID"))
+	}
+	var list []application.EventDTO
+	if err := json.Unmarshal(events.Body.Bytes(), &list); err != nil || len(list) != 1 {
+		t.Fatalf("Expected one event, got %s (err %v)", events.Body.String(), err)
+	}
+	metadata := list[0].Metadata
+	if metadata["correlationId"] != "corr-42" || metadata["causationId"] != "msg-1" || metadata["source"] != "telemetry-gateway" {
+		t.Errorf("Unexpected origin metadata: %v", metadata)
+	}
+	for _, key := range []string{"eventId", "receivedAt", "appliedAt"} {
+		if metadata[key] == "" {
+			t.Errorf("Expected %s in metadata, got %v", key, metadata)
+		}
+	}
+}
+
+// TestHandleExportImport verifies that a dump taken with GET /admin/export can be restored
+// with POST /admin/import, and that a dump cannot be imported twice.
+// Expected result: NDJSON export, 200 with 1 channel / 2 events, then 400 on the replay.
+func TestHandleExportImport(t *testing.T) {
+	// Arrange
+	eventStore, _ := infrastructure.NewKafkaEventStore(infrastructure.KafkaConfig{})
+	service := application.NewRocketApplicationService(infrastructure.NewRocketRepository(eventStore), eventStore)
+	_ = service.ProcessMessage(&application.ProcessMessageDTO{Channel: "rocket-dump", Number: 1, Action: "launch", RocketType: "Falcon-9", Value: 1000, Param: "ARTEMIS"})
+	_ = service.ProcessMessage(&application.ProcessMessageDTO{Channel: "rocket-dump", Number: 2, Action: "increase_speed", Value: 100})
+	source := application.NewArchiveService(eventStore, infrastructure.DefaultEventCodec(), nil)
+
+	targetStore, _ := infrastructure.NewKafkaEventStore(infrastructure.KafkaConfig{})
+	target := application.NewArchiveService(targetStore, infrastructure.DefaultEventCodec(), nil)
+
+	// Act
+	dump := httptest.NewRecorder()
+	HandleExport(source)(dump, httptest.NewRequest(http.MethodGet, "/admin/export?channel=rocket-dump", nil))
+	body := dump.Body.Bytes()
+	imported := httptest.NewRecorder()
+	HandleImport(target)(imported, httptest.NewRequest(http.MethodPost, "/admin/import", bytes.NewReader(body)))
+	replayed := httptest.NewRecorder()
+	HandleImport(target)(replayed, httptest.NewRequest(http.MethodPost, "/admin/import", bytes.NewReader(body)))
+
+	// Assert
+	if ct := dump.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("Expected Content-Type application/x-ndjson, got %q", ct)
+	}
+	if imported.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", imported.Code, imported.Body.String())
+	}
+	var result application.ImportResultDTO
+	if err := json.NewDecoder(imported.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if result.Channels != 1 || result.Events != 2 {
+		t.Errorf("Expected 1 channel and 2 events, got %+v", result)
+	}
+	if replayed.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 on replay, got %d", replayed.Code)
+	}
+}
+
+// TestHandleListRocketsUnknownChannel verifies that channels without events are not found.
+// Expected result: 404 for both the rocket and its events.
+func TestHandleListRocketsUnknownChannel(t *testing.T) {
+	// Arrange
+	_, service := setupTestServer()
+	handler := HandleListRockets(service)
+
+	for _, path := range []string{"/rockets/never-seen", "/rockets/never-seen/events"} {
+		w := httptest.NewRecorder()
+
+		// Act
+		handler(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+		// Assert
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404 for %s, got %d", path, w.Code)
+		}
+	}
+}
+
+// TestHandleMessagesRejectionReason verifies that invalid messages are refused with the
+// reason they would be dead-lettered with.
+// Expected result: 400 with unknown_action for an unknown messageType, invalid_payload for a
+// non-string mission.
+func TestHandleMessagesRejectionReason(t *testing.T) {
+	// Arrange
+	pool, _ := setupTestServer()
+	handler := HandleMessages(pool)
+	cases := map[string]string{
+		`{"metadata":{"channel":"r","messageNumber":1,"messageTime":"2026-01-22T10:00:00Z","messageType":"RocketTeleported"},"message":{}}`:           "unknown_action",
+		`{"metadata":{"channel":"r","messageNumber":1,"messageTime":"2026-01-22T10:00:00Z","messageType":"RocketLaunched"},"message":{"mission":42}}`: "invalid_payload",
+	}
+
+	for body, reason := range cases {
+		w := httptest.NewRecorder()
+
+		// Act
+		handler(w, httptest.NewRequest(http.MethodPost, "/messages", bytes.NewReader([]byte(body))))
+
+		// Assert
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", w.Code)
+		}
+		if got := w.Header().Get(headerRejectionReason); got != reason {
+			t.Errorf("Expected reason %s, got %q", reason, got)
+		}
+	}
+}
+
+// TestHandleMessagesBufferLimits verifies that, once the channel has a buffered message, a
+// message the reorder buffer would refuse is refused right away, with the reason.
+// Expected result: 202 for the first message, 409 gap_too_large for a message too far ahead,
+// 503 buffer_full once the channel's buffer is full.
+func TestHandleMessagesBufferLimits(t *testing.T) {
+	// Arrange
+	eventStore, _ := infrastructure.NewKafkaEventStore(infrastructure.KafkaConfig{})
+	service := application.NewRocketApplicationService(infrastructure.NewRocketRepository(eventStore), eventStore,
+		application.WithBufferLimits(application.BufferLimits{MaxPerChannel: 1, MaxGap: 10}))
+	pool := application.NewWorkerPool(service, 1)
+	pool.Start(context.Background())
+	handler := HandleMessages(pool)
+	post := func(number int) *httptest.ResponseRecorder {
+		body := fmt.Sprintf(`{"metadata":{"channel":"rocket-limits","messageNumber":%d,"messageTime":"2026-01-22T10:00:00Z","messageType":"RocketSpeedIncreased"},"message":{"by":100}}`, number)
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest(http.MethodPost, "/messages", bytes.NewReader([]byte(body))))
+		return w
+	}
+
+	// Act
+	buffered := post(3) // buffered: the worker publishes where the channel is
+	time.Sleep(100 * time.Millisecond)
+	tooFar := post(12)
+	full := post(4)
+
+	// Assert
+	if tooFar.Code != http.StatusConflict || tooFar.Header().Get(headerRejectionReason) != application.ReasonGapTooLarge {
+		t.Errorf("Expected 409 gap_too_large, got %d %q", tooFar.Code, tooFar.Header().Get(headerRejectionReason))
+	}
+	if buffered.Code != http.StatusAccepted {
+		t.Errorf("Expected 202 for message 3, got %d", buffered.Code)
+	}
+	if full.Code != http.StatusServiceUnavailable || full.Header().Get(headerRejectionReason) != application.ReasonBufferFull {
+		t.Errorf("Expected 503 buffer_full, got %d %q", full.Code, full.Header().Get(headerRejectionReason))
+	}
+}
+
+// TestConvertLunarMessageNewEvents verifies the mapping of landing, refueling and stage
+// separation messages.
+// Expected result: land/LZ-1, refuel/300, separate_stage/1.
+func TestConvertLunarMessageNewEvents(t *testing.T) {
+	cases := []struct {
+		messageType string
+		message     map[string]interface{}
+		action      string
+		value       int
+		param       string
+	}{
+		{"RocketLanded", map[string]interface{}{"site": "LZ-1"}, "land", 0, "LZ-1"},
+		{"RocketRefueled", map[string]interface{}{"amount": 300.0}, "refuel", 300, ""},
+		{"RocketStageSeparated", map[string]interface{}{"remainingStages": 1.0}, "separate_stage", 1, ""},
+	}
+
+	for _, c := range cases {
+		// Arrange
+		msg := &LunarMessage{Message: c.message}
+		msg.Metadata.Channel = "rocket-new"
+		msg.Metadata.MessageNumber = 2
+		msg.Metadata.MessageTime = "2026-01-22T10:00:00Z"
+		msg.Metadata.MessageType = c.messageType
+
+		// Act
+		dto, err := convertLunarMessageToDTO(msg)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("Expected no error for %s, got %v", c.messageType, err)
+		}
+		if dto.Action != c.action || dto.Value != c.value || dto.Param != c.param {
+			t.Errorf("Expected %s/%d/%q for %s, got %s/%d/%q", c.action, c.value, c.param, c.messageType, dto.Action, dto.Value, dto.Param)
+		}
+	}
+}
+
+// TestHandleMissionsRegister verifies that missions can be registered while running.
+// Expected result: 200 listing "Mars Mission" as exploration; an unknown category is a 400
+// with reason invalid_payload.
+func TestHandleMissionsRegister(t *testing.T) {
+	// Arrange
+	registry := domain.NewMissionRegistry(false)
+	handler := HandleMissions(registry)
+	valid := httptest.NewRequest(http.MethodPost, "/admin/missions", bytes.NewBufferString(`{"name":"Mars Mission","category":"exploration"}`))
+	invalid := httptest.NewRequest(http.MethodPost, "/admin/missions", bytes.NewBufferString(`{"name":"Moon Tourism","category":"tourism"}`))
+	validRec, invalidRec := httptest.NewRecorder(), httptest.NewRecorder()
+
+	// Act
+	handler(validRec, valid)
+	handler(invalidRec, invalid)
+
+	// Assert
+	if validRec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", validRec.Code)
+	}
+	var listed missionRegistryDTO
+	_ = json.NewDecoder(validRec.Body).Decode(&listed)
+	found := false
+	for _, mission := range listed.Missions {
+		found = found || mission.Name == "Mars Mission" && mission.Category == domain.MissionExploration
+	}
+	if !found {
+		t.Errorf("Expected Mars Mission to be listed as exploration, got %+v", listed.Missions)
+	}
+	if invalidRec.Code != http.StatusBadRequest || invalidRec.Header().Get(headerRejectionReason) != application.ReasonInvalidPayload {
+		t.Errorf("Expected 400 invalid_payload, got %d %q", invalidRec.Code, invalidRec.Header().Get(headerRejectionReason))
+	}
+}
+
+// TestHandleFleets verifies the fleet endpoints.
+// Expected result: a fleet is created (201) and gets a rocket; duplicates are 409 with their
+// reason, unknown fleets 404, and GET returns the members with their stats.
+func TestHandleFleets(t *testing.T) {
+	// Arrange
+	eventStore, _ := infrastructure.NewKafkaEventStore(infrastructure.KafkaConfig{})
+	service := application.NewRocketApplicationService(infrastructure.NewRocketRepository(eventStore), eventStore)
+	_ = service.ProcessMessage(&application.ProcessMessageDTO{Channel: "rocket-1", Number: 1, Action: "launch", RocketType: "Falcon-9", Value: 500, Param: "exploration", Time: 1})
+	fleetStore, _ := infrastructure.NewKafkaEventStore(infrastructure.KafkaConfig{})
+	handler := HandleFleets(application.NewFleetService(infrastructure.NewFleetRepository(fleetStore), service))
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(method, path, bytes.NewBufferString(body)))
+		return rec
+	}
+
+	// Act
+	created := serve(http.MethodPost, "/fleets", `{"id":"alpha","name":"Alpha"}`)
+	duplicate := serve(http.MethodPost, "/fleets", `{"id":"alpha"}`)
+	added := serve(http.MethodPost, "/fleets/alpha/rockets", `{"channel":"rocket-1"}`)
+	unknown := serve(http.MethodGet, "/fleets/beta", "")
+	fetched := serve(http.MethodGet, "/fleets/alpha", "")
+	removed := serve(http.MethodDelete, "/fleets/alpha/rockets/rocket-1", "")
+
+	// Assert
+	if created.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", created.Code, created.Body.String())
+	}
+	if duplicate.Code != http.StatusConflict || duplicate.Header().Get(headerRejectionReason) != application.ReasonFleetExists {
+		t.Errorf("Expected 409 fleet_exists, got %d %q", duplicate.Code, duplicate.Header().Get(headerRejectionReason))
+	}
+	if added.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d: %s", added.Code, added.Body.String())
+	}
+	if unknown.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", unknown.Code)
+	}
+	var fleet application.FleetDTO
+	_ = json.NewDecoder(fetched.Body).Decode(&fleet)
+	if fleet.Name != "Alpha" || len(fleet.Rockets) != 1 || fleet.Stats.TotalSpeed != 500 || fleet.Stats.StatusCounts["flying"] != 1 {
+		t.Errorf("Unexpected fleet %+v", fleet)
+	}
+	if removed.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d: %s", removed.Code, removed.Body.String())
+	}
 }
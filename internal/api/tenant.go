@@ -0,0 +1,142 @@
+package api
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"rockets/internal/application"
+	"rockets/internal/domain"
+)
+
+// Request headers that select the tenant
+const (
+	headerTenantID = "X-Tenant-ID" // tenant named by the client (only when no API keys are configured)
+	headerAPIKey   = "X-API-Key"   // key mapped to a tenant
+)
+
+var (
+	errAPIKeyRequired = errors.New("API key required")
+	errUnknownAPIKey  = errors.New("unknown API key")
+	errTenantMismatch = errors.New("tenant does not match the API key")
+	errAdminKey       = errors.New("admin API key required")
+)
+
+// TenantResolver picks the tenant of a request. With API keys configured every request
+// needs a known X-API-Key, which decides the tenant; otherwise X-Tenant-ID does, and
+// requests without it belong to the default tenant. The admin key may act on any tenant,
+// named by X-Tenant-ID.
+type TenantResolver struct {
+	apiKeys  map[string]string // API key -> tenant ID
+	adminKey string
+}
+
+// NewTenantResolver creates a resolver; apiKeys and adminKey may be empty
+func NewTenantResolver(apiKeys map[string]string, adminKey string) *TenantResolver {
+	return &TenantResolver{apiKeys: apiKeys, adminKey: adminKey}
+}
+
+// ParseAPIKeys parses "key=tenant,key=tenant" into a map of API keys to tenant IDs
+func ParseAPIKeys(value string) (map[string]string, error) {
+	apiKeys := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, tenant, ok := strings.Cut(pair, "=")
+		key, tenant = strings.TrimSpace(key), strings.TrimSpace(tenant)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid API key entry %q: expected key=tenant", pair)
+		}
+		if err := domain.ValidateTenantID(tenant); err != nil {
+			return nil, err
+		}
+		apiKeys[key] = tenant
+	}
+	return apiKeys, nil
+}
+
+// Resolve returns the tenant ID of a request
+func (t *TenantResolver) Resolve(r *http.Request) (string, error) {
+	named := strings.TrimSpace(r.Header.Get(headerTenantID))
+
+	if len(t.apiKeys) == 0 || isAdminKey(r, t.adminKey) {
+		if named == "" {
+			return domain.DefaultTenantID, nil
+		}
+		return named, domain.ValidateTenantID(named)
+	}
+
+	key := r.Header.Get(headerAPIKey)
+	if key == "" {
+		return "", errAPIKeyRequired
+	}
+	tenant, ok := t.apiKeys[key]
+	if !ok {
+		return "", errUnknownAPIKey
+	}
+	if named != "" && named != tenant {
+		return "", errTenantMismatch
+	}
+	return tenant, nil
+}
+
+// WithTenant resolves the tenant of each request and serves it with the handler built for
+// that tenant, so channels, buffers and listings never cross tenants
+func WithTenant(resolver *TenantResolver, registry *application.TenantRegistry, handler func(*application.Tenant) http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := resolver.Resolve(r)
+		switch {
+		case errors.Is(err, errAPIKeyRequired), errors.Is(err, errUnknownAPIKey):
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		case errors.Is(err, errTenantMismatch):
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		tenant, release, err := registry.Acquire(id)
+		if errors.Is(err, application.ErrTooManyTenants) {
+			slog.Warn("Tenant refused", "tenant", id, "err", err)
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+			return
+		}
+		if err != nil {
+			slog.Error("Failed to open tenant", "tenant", id, "err", err)
+			http.Error(w, "tenant unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		defer release()
+
+		w.Header().Set(headerTenantID, tenant.ID)
+		handler(tenant)(w, r)
+	}
+}
+
+// WithAdminKey guards an admin endpoint: with an admin key every request needs it as
+// X-API-Key, without one the endpoint is open. Tenant keys never grant access, since admin
+// endpoints are irreversible, expose data or change what every tenant sees.
+func WithAdminKey(adminKey string, handler http.HandlerFunc) http.HandlerFunc {
+	if adminKey == "" {
+		return handler
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !isAdminKey(r, adminKey) {
+			http.Error(w, errAdminKey.Error(), http.StatusUnauthorized)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// isAdminKey reports whether a request carries the admin key (never when there is none)
+func isAdminKey(r *http.Request, adminKey string) bool {
+	return adminKey != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get(headerAPIKey)), []byte(adminKey)) == 1
+}
@@ -0,0 +1,184 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"rockets/internal/application"
+	"rockets/internal/domain"
+	"rockets/internal/infrastructure"
+)
+
+// setupTenants creates a registry of in-memory tenants
+func setupTenants(t *testing.T) *application.TenantRegistry {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	tenants := application.NewTenantRegistry(ctx, func(string) (*application.TenantBackend, error) {
+		eventStore, _ := infrastructure.NewKafkaEventStore(infrastructure.KafkaConfig{})
+		return &application.TenantBackend{
+			EventStore: eventStore,
+			Repository: infrastructure.NewRocketRepository(eventStore),
+			Serializer: infrastructure.DefaultEventCodec(),
+		}, nil
+	}, 1, application.TenantLimits{})
+	t.Cleanup(func() {
+		cancel()
+		_ = tenants.Close()
+	})
+	return tenants
+}
+
+// TestWithTenantIsolatesListings verifies that GET /rockets only lists the rockets of the
+// tenant named in X-Tenant-ID.
+// Expected result: team-a lists its rocket, team-b and the default tenant list none.
+func TestWithTenantIsolatesListings(t *testing.T) {
+	// Arrange
+	tenants := setupTenants(t)
+	teamA, _ := tenants.Get("team-a")
+	_ = teamA.Service.ProcessMessage(&application.ProcessMessageDTO{Channel: "rocket-alpha", Number: 1, Action: "launch", RocketType: "Falcon-9", Value: 1000, Param: "ARTEMIS", Time: 1})
+	handler := WithTenant(NewTenantResolver(nil, ""), tenants, func(t *application.Tenant) http.HandlerFunc {
+		return HandleListRockets(t.Service)
+	})
+
+	for tenant, expected := range map[string]int{"team-a": 1, "team-b": 0, "": 0} {
+		req := httptest.NewRequest(http.MethodGet, "/rockets", nil)
+		if tenant != "" {
+			req.Header.Set(headerTenantID, tenant)
+		}
+		w := httptest.NewRecorder()
+
+		// Act
+		handler(w, req)
+
+		// Assert
+		var rockets []application.RocketDTO
+		if err := json.NewDecoder(w.Body).Decode(&rockets); err != nil {
+			t.Fatalf("Failed to decode response for %q: %v", tenant, err)
+		}
+		if len(rockets) != expected {
+			t.Errorf("Expected %d rockets for tenant %q, got %d", expected, tenant, len(rockets))
+		}
+	}
+}
+
+// TestWithTenantAPIKeys verifies tenant selection by API key.
+// Expected result: 401 without or with an unknown key, 403 when X-Tenant-ID contradicts the
+// key, 200 with the key's tenant echoed otherwise.
+func TestWithTenantAPIKeys(t *testing.T) {
+	// Arrange
+	tenants := setupTenants(t)
+	apiKeys, err := ParseAPIKeys("secret-a=team-a, secret-b=team-b")
+	if err != nil {
+		t.Fatalf("Expected valid API keys, got %v", err)
+	}
+	handler := WithTenant(NewTenantResolver(apiKeys, ""), tenants, func(t *application.Tenant) http.HandlerFunc {
+		return HandleListRockets(t.Service)
+	})
+	cases := []struct {
+		key, tenant string
+		expected    int
+	}{
+		{"", "", http.StatusUnauthorized},
+		{"wrong", "", http.StatusUnauthorized},
+		{"secret-a", "team-b", http.StatusForbidden},
+		{"secret-b", "", http.StatusOK},
+	}
+
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/rockets", nil)
+		if c.key != "" {
+			req.Header.Set(headerAPIKey, c.key)
+		}
+		if c.tenant != "" {
+			req.Header.Set(headerTenantID, c.tenant)
+		}
+		w := httptest.NewRecorder()
+
+		// Act
+		handler(w, req)
+
+		// Assert
+		if w.Code != c.expected {
+			t.Errorf("Expected status %d for key %q tenant %q, got %d", c.expected, c.key, c.tenant, w.Code)
+		}
+	}
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/rockets", nil)
+	req.Header.Set(headerAPIKey, "secret-b")
+	handler(w, req)
+	if got := w.Header().Get(headerTenantID); got != "team-b" {
+		t.Errorf("Expected tenant team-b to be echoed, got %q", got)
+	}
+}
+
+// TestWithTenantRejectsInvalidID verifies that a tenant ID unusable as a partition name is refused.
+// Expected result: 400 Bad Request.
+func TestWithTenantRejectsInvalidID(t *testing.T) {
+	// Arrange
+	handler := WithTenant(NewTenantResolver(nil, ""), setupTenants(t), func(t *application.Tenant) http.HandlerFunc {
+		return HandleListRockets(t.Service)
+	})
+	req := httptest.NewRequest(http.MethodGet, "/rockets", nil)
+	req.Header.Set(headerTenantID, "../other")
+	w := httptest.NewRecorder()
+
+	// Act
+	handler(w, req)
+
+	// Assert
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
+// TestWithAdminKey verifies that an endpoint shared by every tenant only accepts the admin key,
+// not the API key of a tenant.
+// Expected result: 401 without a key and with a tenant key, 200 with the admin key.
+func TestWithAdminKey(t *testing.T) {
+	// Arrange
+	handler := WithAdminKey("admin-secret", HandleMissions(domain.NewMissionRegistry(false)))
+
+	for key, expected := range map[string]int{"": http.StatusUnauthorized, "s3cr3t-a": http.StatusUnauthorized, "admin-secret": http.StatusOK} {
+		req := httptest.NewRequest(http.MethodPost, "/admin/missions", strings.NewReader(`{"name":"Mars Mission","category":"exploration"}`))
+		if key != "" {
+			req.Header.Set(headerAPIKey, key)
+		}
+		w := httptest.NewRecorder()
+
+		// Act
+		handler(w, req)
+
+		// Assert
+		if w.Code != expected {
+			t.Errorf("Expected status %d for key %q, got %d", expected, key, w.Code)
+		}
+	}
+}
+
+// TestResolveAdminKey verifies that the admin key acts on the tenant named in X-Tenant-ID, even
+// with tenant API keys configured.
+// Expected result: team-b with the admin key, default without X-Tenant-ID.
+func TestResolveAdminKey(t *testing.T) {
+	// Arrange
+	resolver := NewTenantResolver(map[string]string{"s3cr3t-a": "team-a"}, "admin-secret")
+
+	for named, expected := range map[string]string{"team-b": "team-b", "": "default"} {
+		req := httptest.NewRequest(http.MethodPost, "/admin/erase", nil)
+		req.Header.Set(headerAPIKey, "admin-secret")
+		if named != "" {
+			req.Header.Set(headerTenantID, named)
+		}
+
+		// Act
+		tenant, err := resolver.Resolve(req)
+
+		// Assert
+		if err != nil || tenant != expected {
+			t.Errorf("Expected tenant %s, got %q (%v)", expected, tenant, err)
+		}
+	}
+}
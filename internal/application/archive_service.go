@@ -0,0 +1,207 @@
+package application
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"rockets/internal/domain"
+)
+
+// exportPageSize is how many events are read from the global log at a time while exporting
+const exportPageSize = 1000
+
+// maxArchiveLine bounds the size of one event in an archive
+const maxArchiveLine = 16 << 20
+
+// ErrInvalidArchive is matched (errors.Is) by every problem found in an archive being imported
+var ErrInvalidArchive = errors.New("invalid archive")
+
+// ArchiveService exports the event store to NDJSON (one serialized event per line) and
+// imports such archives back
+type ArchiveService struct {
+	eventStore domain.EventStore
+	serializer domain.EventSerializer
+	repository domain.RocketRepository // optional: its cached rockets are refreshed after an import
+}
+
+// cacheInvalidator is implemented by repositories that cache rockets
+type cacheInvalidator interface {
+	Invalidate(channel *domain.Channel)
+}
+
+// NewArchiveService creates a new archive service. repository may be nil when no rockets
+// are loaded from the store (e.g. offline tools).
+func NewArchiveService(eventStore domain.EventStore, serializer domain.EventSerializer, repository domain.RocketRepository) *ArchiveService {
+	return &ArchiveService{
+		eventStore: eventStore,
+		serializer: serializer,
+		repository: repository,
+	}
+}
+
+// ImportResultDTO represents the outcome of an import
+type ImportResultDTO struct {
+	Channels         int      `json:"channels"`
+	Events           int      `json:"events"`
+	ImportedChannels []string `json:"importedChannels"` // in the order they were appended
+}
+
+// Export writes every event in commit order, or only the events of the given channels
+// (channel by channel, in the order given). It returns the number of events written.
+func (s *ArchiveService) Export(w io.Writer, channels []string) (int, error) {
+	bw := bufio.NewWriter(w)
+	written := 0
+	write := func(event domain.DomainEvent) error {
+		line, err := s.serializer.Serialize(event)
+		if err != nil {
+			return err
+		}
+		if _, err := bw.Write(append(line, '\n')); err != nil {
+			return err
+		}
+		written++
+		return nil
+	}
+
+	if len(channels) == 0 {
+		for from := int64(1); ; {
+			page, err := s.eventStore.ReadAll(from, exportPageSize)
+			if err != nil {
+				return written, err
+			}
+			if len(page) == 0 {
+				break
+			}
+			for _, recorded := range page {
+				if err := write(recorded.Event); err != nil {
+					return written, err
+				}
+			}
+			from = page[len(page)-1].Position + 1
+		}
+	} else {
+		for _, name := range channels {
+			channel, err := domain.NewChannel(name)
+			if err != nil {
+				return written, fmt.Errorf("invalid channel %q: %w", name, err)
+			}
+			events, err := s.eventStore.GetEventsByChannel(channel)
+			if err != nil {
+				return written, err
+			}
+			for _, event := range events {
+				if err := write(event); err != nil {
+					return written, err
+				}
+			}
+		}
+	}
+
+	if err := bw.Flush(); err != nil {
+		return written, err
+	}
+	slog.Info("Events exported", "events", written, "channels", len(channels))
+	return written, nil
+}
+
+// importedChannel collects the events of one channel found in an archive
+type importedChannel struct {
+	channel         *domain.Channel
+	events          []domain.DomainEvent
+	expectedVersion int
+}
+
+// Import reads an archive and appends its events. The whole archive is validated first:
+// every line must decode, and the message numbers of each channel must increase strictly,
+// starting after the last message already stored for that channel. If anything is wrong
+// nothing is appended. The version of every channel is checked again right before appending,
+// then each channel is appended as one atomic batch. The archive as a whole is not atomic: a
+// channel written concurrently after that check, or a store failure, stops the import with
+// the channels appended so far listed in the returned result.
+func (s *ArchiveService) Import(r io.Reader) (*ImportResultDTO, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxArchiveLine)
+
+	var order []string
+	byChannel := make(map[string]*importedChannel)
+	lastNumber := make(map[string]int)
+	line := 0
+	for scanner.Scan() {
+		line++
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		event, err := s.serializer.Deserialize(data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidArchive, line, err)
+		}
+
+		name := event.GetChannel().Value()
+		imported, ok := byChannel[name]
+		if !ok {
+			stored, err := s.eventStore.GetEventsByChannel(event.GetChannel())
+			if err != nil {
+				return nil, err
+			}
+			imported = &importedChannel{channel: event.GetChannel(), expectedVersion: len(stored)}
+			if len(stored) > 0 {
+				lastNumber[name] = stored[len(stored)-1].GetMessageNumber().Value()
+			}
+			byChannel[name] = imported
+			order = append(order, name)
+		}
+
+		// One message can raise several events (e.g. a command and its envelope warning), but
+		// the archive must start after the last message already stored. An erasure tombstone
+		// takes the number of the last message, so it may start the archive too.
+		number := event.GetMessageNumber().Value()
+		_, tombstone := event.(*domain.ChannelErased)
+		if number < lastNumber[name] || number == lastNumber[name] && len(imported.events) == 0 && !tombstone {
+			return nil, fmt.Errorf("%w: line %d: message %d of channel %s is out of order (after %d)",
+				ErrInvalidArchive, line, number, name, lastNumber[name])
+		}
+		lastNumber[name] = number
+		imported.events = append(imported.events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidArchive, line+1, err)
+	}
+
+	// Reading a large archive takes a while: make sure no channel moved meanwhile
+	for _, name := range order {
+		imported := byChannel[name]
+		stored, err := s.eventStore.GetEventsByChannel(imported.channel)
+		if err != nil {
+			return nil, err
+		}
+		if len(stored) != imported.expectedVersion {
+			return nil, fmt.Errorf("%w: channel %s changed while the archive was read (version %d, expected %d)",
+				domain.ErrConcurrencyConflict, name, len(stored), imported.expectedVersion)
+		}
+	}
+
+	result := &ImportResultDTO{ImportedChannels: []string{}}
+	for _, name := range order {
+		imported := byChannel[name]
+		if err := s.eventStore.AppendEvents(imported.channel, imported.expectedVersion, imported.events); err != nil {
+			if result.Channels > 0 {
+				return result, fmt.Errorf("failed to import channel %s after importing %v: %w", name, result.ImportedChannels, err)
+			}
+			return result, fmt.Errorf("failed to import channel %s: %w", name, err)
+		}
+		if invalidator, ok := s.repository.(cacheInvalidator); ok {
+			invalidator.Invalidate(imported.channel)
+		}
+		result.Channels++
+		result.Events += len(imported.events)
+		result.ImportedChannels = append(result.ImportedChannels, name)
+	}
+
+	slog.Info("Events imported", "channels", result.Channels, "events", result.Events)
+	return result, nil
+}
@@ -0,0 +1,255 @@
+package application
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"rockets/internal/domain"
+	"rockets/internal/infrastructure"
+)
+
+// setupArchiveSource processes a few messages on two channels and returns the service
+// together with its event store
+func setupArchiveSource(t *testing.T) (*RocketApplicationService, domain.EventStore) {
+	t.Helper()
+	eventStore, _ := infrastructure.NewKafkaEventStore(infrastructure.KafkaConfig{})
+	service := NewRocketApplicationService(infrastructure.NewRocketRepository(eventStore), eventStore)
+	messages := []*ProcessMessageDTO{
+		{Channel: "rocket-a", Number: 1, Action: "launch", RocketType: "Falcon-9", Value: 1000, Param: "ARTEMIS", Time: 1, MessageID: "msg-a1"},
+		{Channel: "rocket-b", Number: 1, Action: "launch", RocketType: "Saturn-V", Value: 2000, Param: "APOLLO", Time: 2},
+		{Channel: "rocket-a", Number: 2, Action: "increase_speed", Value: 500, Time: 3},
+	}
+	for _, msg := range messages {
+		if err := service.ProcessMessage(msg); err != nil {
+			t.Fatalf("Expected no error processing %s#%d, got %v", msg.Channel, msg.Number, err)
+		}
+	}
+	return service, eventStore
+}
+
+// TestArchiveRoundTrip verifies that an exported store can be imported into an empty one.
+// Expected result: 3 events on 2 channels are restored with their state and metadata.
+func TestArchiveRoundTrip(t *testing.T) {
+	// Arrange
+	_, source := setupArchiveSource(t)
+	target, _ := infrastructure.NewKafkaEventStore(infrastructure.KafkaConfig{})
+	codec := infrastructure.DefaultEventCodec()
+	var dump bytes.Buffer
+
+	// Act
+	exported, exportErr := NewArchiveService(source, codec, nil).Export(&dump, nil)
+	result, importErr := NewArchiveService(target, codec, nil).Import(&dump)
+
+	// Assert
+	if exportErr != nil || importErr != nil {
+		t.Fatalf("Expected no errors, got export=%v import=%v", exportErr, importErr)
+	}
+	if exported != 3 || result.Events != 3 || result.Channels != 2 {
+		t.Errorf("Expected 3 events on 2 channels, got exported=%d imported=%+v", exported, result)
+	}
+	restored := NewRocketApplicationService(infrastructure.NewRocketRepository(target), target)
+	rocket, err := restored.GetRocket("rocket-a")
+	if err != nil {
+		t.Fatalf("Expected rocket-a to be restored, got %v", err)
+	}
+	if rocket.Speed != 1500 {
+		t.Errorf("Expected speed 1500, got %d", rocket.Speed)
+	}
+	channel, _ := domain.NewChannel("rocket-a")
+	events, _ := target.GetEventsByChannel(channel)
+	if got := events[0].GetMetadata().Get(domain.MetadataEventID); got == "" {
+		t.Errorf("Expected the event ID to survive the round trip")
+	}
+	if got := events[0].GetMetadata().Get(domain.MetadataCausationID); got != "msg-a1" {
+		t.Errorf("Expected causation ID msg-a1, got %q", got)
+	}
+}
+
+// TestArchiveExportChannelSubset verifies that only the requested channels are exported.
+// Expected result: the 2 events of rocket-a, and no line of rocket-b.
+func TestArchiveExportChannelSubset(t *testing.T) {
+	// Arrange
+	_, source := setupArchiveSource(t)
+	var dump bytes.Buffer
+
+	// Act
+	exported, err := NewArchiveService(source, infrastructure.DefaultEventCodec(), nil).Export(&dump, []string{"rocket-a"})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(dump.String()), "\n")
+	if exported != 2 || len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %d (reported %d)", len(lines), exported)
+	}
+	if strings.Contains(dump.String(), "rocket-b") {
+		t.Errorf("Expected rocket-b not to be exported")
+	}
+}
+
+// TestArchiveImportRejectsOutOfOrder verifies that an archive whose channel goes backwards
+// is rejected as a whole.
+// Expected result: ErrInvalidArchive naming the line, and nothing appended (not even the
+// channel that was in order).
+func TestArchiveImportRejectsOutOfOrder(t *testing.T) {
+	// Arrange
+	_, source := setupArchiveSource(t)
+	var dump bytes.Buffer
+	codec := infrastructure.DefaultEventCodec()
+	_, _ = NewArchiveService(source, codec, nil).Export(&dump, []string{"rocket-b", "rocket-a"})
+	lines := strings.Split(strings.TrimSpace(dump.String()), "\n")
+	swapped := strings.Join([]string{lines[0], lines[2], lines[1]}, "\n")
+	target, _ := infrastructure.NewKafkaEventStore(infrastructure.KafkaConfig{})
+
+	// Act
+	_, err := NewArchiveService(target, codec, nil).Import(strings.NewReader(swapped))
+
+	// Assert
+	if !errors.Is(err, ErrInvalidArchive) {
+		t.Fatalf("Expected ErrInvalidArchive, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("Expected the error to name line 3, got %v", err)
+	}
+	if channels := target.GetAllChannels(); len(channels) != 0 {
+		t.Errorf("Expected nothing to be imported, got channels %v", channels)
+	}
+}
+
+// TestArchiveImportContinuesExistingChannel verifies that an archive must continue after
+// the events already stored.
+// Expected result: re-importing the same dump is rejected; the store is unchanged.
+func TestArchiveImportContinuesExistingChannel(t *testing.T) {
+	// Arrange
+	_, source := setupArchiveSource(t)
+	var dump bytes.Buffer
+	archive := NewArchiveService(source, infrastructure.DefaultEventCodec(), nil)
+	_, _ = archive.Export(&dump, nil)
+
+	// Act
+	_, err := archive.Import(&dump)
+
+	// Assert
+	if !errors.Is(err, ErrInvalidArchive) {
+		t.Fatalf("Expected ErrInvalidArchive, got %v", err)
+	}
+	page, _ := source.ReadAll(1, 100)
+	if len(page) != 3 {
+		t.Errorf("Expected 3 stored events, got %d", len(page))
+	}
+}
+
+// TestArchiveImportErasureAfterLastImport verifies that a top-up archive may start with the
+// tombstone of a channel erased after the last import, although it reuses the last number.
+// Expected result: the tombstone is imported and the channel is erased in the target.
+func TestArchiveImportErasureAfterLastImport(t *testing.T) {
+	// Arrange
+	service, source := setupArchiveSource(t)
+	codec := infrastructure.DefaultEventCodec()
+	var full bytes.Buffer
+	_, _ = NewArchiveService(source, codec, nil).Export(&full, []string{"rocket-a"})
+	target, _ := infrastructure.NewKafkaEventStore(infrastructure.KafkaConfig{})
+	if _, err := NewArchiveService(target, codec, nil).Import(&full); err != nil {
+		t.Fatalf("Expected no error importing, got %v", err)
+	}
+	if _, err := service.EraseChannel("rocket-a", "customer request"); err != nil {
+		t.Fatalf("Expected no error erasing, got %v", err)
+	}
+	var dump bytes.Buffer
+	_, _ = NewArchiveService(source, codec, nil).Export(&dump, []string{"rocket-a"})
+	lines := strings.Split(strings.TrimSpace(dump.String()), "\n")
+	topUp := lines[len(lines)-1]
+
+	// Act
+	result, err := NewArchiveService(target, codec, nil).Import(strings.NewReader(topUp))
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Events != 1 {
+		t.Errorf("Expected 1 imported event, got %d", result.Events)
+	}
+	channel, _ := domain.NewChannel("rocket-a")
+	events, _ := target.GetEventsByChannel(channel)
+	if _, ok := events[len(events)-1].(*domain.ChannelErased); !ok || len(events) != 3 {
+		t.Errorf("Expected 3 events ending with the tombstone, got %d ending with %T", len(events), events[len(events)-1])
+	}
+}
+
+// TestArchiveImportRejectsUndecodableLine verifies that a corrupt line stops the import.
+// Expected result: ErrInvalidArchive naming line 2.
+func TestArchiveImportRejectsUndecodableLine(t *testing.T) {
+	// Arrange
+	_, source := setupArchiveSource(t)
+	var dump bytes.Buffer
+	codec := infrastructure.DefaultEventCodec()
+	_, _ = NewArchiveService(source, codec, nil).Export(&dump, []string{"rocket-b"})
+	archive := dump.String() + "{\"type\":\"RocketTeleported\"}\n"
+	target, _ := infrastructure.NewKafkaEventStore(infrastructure.KafkaConfig{})
+
+	// Act
+	_, err := NewArchiveService(target, codec, nil).Import(strings.NewReader(archive))
+
+	// Assert
+	if !errors.Is(err, ErrInvalidArchive) || !strings.Contains(err.Error(), "line 2") {
+		t.Fatalf("Expected ErrInvalidArchive at line 2, got %v", err)
+	}
+	if channels := target.GetAllChannels(); len(channels) != 0 {
+		t.Errorf("Expected nothing to be imported, got channels %v", channels)
+	}
+}
+
+// racingStore lets another writer append to a channel right before the import does
+type racingStore struct {
+	domain.EventStore
+	channel string
+	racing  []domain.DomainEvent
+}
+
+func (s *racingStore) AppendEvents(channel *domain.Channel, expectedVersion int, events []domain.DomainEvent) error {
+	if channel.Value() == s.channel && s.racing != nil {
+		if err := s.EventStore.AppendEvents(channel, domain.AnyVersion, s.racing); err != nil {
+			return err
+		}
+		s.racing = nil
+	}
+	return s.EventStore.AppendEvents(channel, expectedVersion, events)
+}
+
+// TestArchiveImportReportsPartialImport verifies that a channel written concurrently stops
+// the import and that the result lists the channels appended before it.
+// Expected result: ErrConcurrencyConflict naming rocket-b; rocket-a imported and listed;
+// rocket-b holds only the concurrent writer's event.
+func TestArchiveImportReportsPartialImport(t *testing.T) {
+	// Arrange
+	_, source := setupArchiveSource(t)
+	var dump bytes.Buffer
+	codec := infrastructure.DefaultEventCodec()
+	_, _ = NewArchiveService(source, codec, nil).Export(&dump, nil)
+	channelB, _ := domain.NewChannel("rocket-b")
+	racing, _ := source.GetEventsByChannel(channelB)
+	inner, _ := infrastructure.NewKafkaEventStore(infrastructure.KafkaConfig{})
+	target := &racingStore{EventStore: inner, channel: "rocket-b", racing: racing}
+
+	// Act
+	result, err := NewArchiveService(target, codec, nil).Import(&dump)
+
+	// Assert
+	if !errors.Is(err, domain.ErrConcurrencyConflict) || !strings.Contains(err.Error(), "rocket-b") {
+		t.Fatalf("Expected a concurrency conflict on rocket-b, got %v", err)
+	}
+	if result == nil || result.Channels != 1 || len(result.ImportedChannels) != 1 || result.ImportedChannels[0] != "rocket-a" {
+		t.Fatalf("Expected rocket-a reported as imported, got %+v", result)
+	}
+	channelA, _ := domain.NewChannel("rocket-a")
+	if events, _ := inner.GetEventsByChannel(channelA); len(events) != 2 {
+		t.Errorf("Expected 2 events imported for rocket-a, got %d", len(events))
+	}
+	if events, _ := inner.GetEventsByChannel(channelB); len(events) != 1 {
+		t.Errorf("Expected only the concurrent event on rocket-b, got %d", len(events))
+	}
+}
@@ -0,0 +1,188 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"rockets/internal/domain"
+)
+
+// FleetService manages the fleets of a tenant and reports on the rockets they group
+type FleetService struct {
+	repository domain.FleetRepository
+	rockets    *RocketApplicationService
+}
+
+// NewFleetService creates a fleet service whose fleets group the rockets of rockets
+func NewFleetService(repository domain.FleetRepository, rockets *RocketApplicationService) *FleetService {
+	return &FleetService{repository: repository, rockets: rockets}
+}
+
+// FleetDTO represents a fleet to be exposed via API
+type FleetDTO struct {
+	ID        string        `json:"id"`
+	Name      string        `json:"name"`
+	CreatedAt int64         `json:"createdAt"`
+	Rockets   []*RocketDTO  `json:"rockets"`
+	Stats     FleetStatsDTO `json:"stats"`
+}
+
+// FleetStatsDTO aggregates the state of a fleet's rockets. Rockets without events yet count
+// as not launched; speeds are the current ones, so exploded and landed rockets add theirs too.
+type FleetStatsDTO struct {
+	Rockets      int            `json:"rockets"`
+	StatusCounts map[string]int `json:"statusCounts"`
+	TotalSpeed   int            `json:"totalSpeed"`
+	AverageSpeed float64        `json:"averageSpeed"`
+	Exploded     int            `json:"exploded"`
+}
+
+// CreateFleet creates a fleet; the name defaults to the ID
+func (s *FleetService) CreateFleet(id, name string) (*FleetDTO, error) {
+	fleet, err := s.repository.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := fleet.Create(name, time.Now().UnixMilli()); err != nil {
+		return nil, err
+	}
+	if err := s.repository.Save(fleet); err != nil {
+		return nil, err
+	}
+	slog.Info("Fleet created", "fleet", id)
+	return s.toFleetDTO(fleet)
+}
+
+// AddRocket adds the rocket of channel to a fleet. The rocket does not have to be launched yet.
+func (s *FleetService) AddRocket(id, channelStr string) (*FleetDTO, error) {
+	channel, err := domain.NewChannel(channelStr)
+	if err != nil {
+		return nil, err
+	}
+	return s.update(id, func(fleet *domain.Fleet) error {
+		return fleet.AddRocket(channel, time.Now().UnixMilli())
+	})
+}
+
+// RemoveRocket removes the rocket of channel from a fleet
+func (s *FleetService) RemoveRocket(id, channelStr string) (*FleetDTO, error) {
+	channel, err := domain.NewChannel(channelStr)
+	if err != nil {
+		return nil, err
+	}
+	return s.update(id, func(fleet *domain.Fleet) error {
+		return fleet.RemoveRocket(channel, time.Now().UnixMilli())
+	})
+}
+
+// update runs a command on a fleet and saves it, reloading the fleet if another writer got first
+func (s *FleetService) update(id string, command func(*domain.Fleet) error) (*FleetDTO, error) {
+	for attempt := 1; ; attempt++ {
+		fleet, err := s.repository.GetByID(id)
+		if err != nil {
+			return nil, err
+		}
+		if err := command(fleet); err != nil {
+			return nil, err
+		}
+		err = s.repository.Save(fleet)
+		if err == nil {
+			return s.toFleetDTO(fleet)
+		}
+		// Only a lost write race is worth retrying right away
+		if !errors.Is(err, domain.ErrConcurrencyConflict) || attempt > maxConflictRetries {
+			return nil, err
+		}
+		slog.Warn("Retrying fleet update after concurrent write", "fleet", id, "attempt", attempt, "err", err)
+	}
+}
+
+// GetFleet gets a fleet with the current state of its rockets
+func (s *FleetService) GetFleet(id string) (*FleetDTO, error) {
+	fleet, err := s.repository.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !fleet.Exists() {
+		return nil, fmt.Errorf("%w: %s", domain.ErrFleetNotFound, id)
+	}
+	return s.toFleetDTO(fleet)
+}
+
+// ListFleets gets all fleets, in the order they were created
+func (s *FleetService) ListFleets() ([]*FleetDTO, error) {
+	fleets, err := s.repository.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	dtos := []*FleetDTO{}
+	for _, fleet := range fleets {
+		if !fleet.Exists() {
+			continue
+		}
+		dto, err := s.toFleetDTO(fleet)
+		if err != nil {
+			return nil, err
+		}
+		dtos = append(dtos, dto)
+	}
+	return dtos, nil
+}
+
+// ListFleetEvents gets the events of a fleet's stream
+func (s *FleetService) ListFleetEvents(id string) ([]*EventDTO, error) {
+	events, err := s.repository.GetEvents(id)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, fmt.Errorf("%w: %s", domain.ErrFleetNotFound, id)
+	}
+
+	var dtos []*EventDTO
+	for i, ev := range events {
+		dtos = append(dtos, toEventDTO(ev, i+1))
+	}
+	return dtos, nil
+}
+
+// toFleetDTO converts a fleet to its API representation, loading its rockets without
+// caching them, so reports do not evict the rockets that are actually in use
+func (s *FleetService) toFleetDTO(fleet *domain.Fleet) (*FleetDTO, error) {
+	dto := &FleetDTO{
+		ID:        fleet.GetID(),
+		Name:      fleet.GetName(),
+		CreatedAt: fleet.GetCreatedAt(),
+		Rockets:   []*RocketDTO{},
+		Stats:     FleetStatsDTO{StatusCounts: map[string]int{}},
+	}
+
+	for _, member := range fleet.GetMembers() {
+		channel, err := domain.NewChannel(member)
+		if err != nil {
+			return nil, err
+		}
+		rocket, err := s.rockets.repository.Peek(channel)
+		if err != nil {
+			return nil, err
+		}
+		dto.Rockets = append(dto.Rockets, toRocketDTO(rocket))
+	}
+
+	stats := &dto.Stats
+	for _, rocket := range dto.Rockets {
+		stats.Rockets++
+		stats.StatusCounts[rocket.Status]++
+		stats.TotalSpeed += rocket.Speed
+		if rocket.Status == string(domain.StatusExploded) {
+			stats.Exploded++
+		}
+	}
+	if stats.Rockets > 0 {
+		stats.AverageSpeed = float64(stats.TotalSpeed) / float64(stats.Rockets)
+	}
+	return dto, nil
+}
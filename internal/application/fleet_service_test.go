@@ -0,0 +1,70 @@
+package application
+
+import (
+	"errors"
+	"testing"
+
+	"rockets/internal/domain"
+	"rockets/internal/infrastructure"
+)
+
+// TestFleetServiceAggregates verifies that a fleet reports the state of its rockets.
+// Expected result: members with status counts, total and average speed and the exploded count;
+// the fleet stream is not listed as a rocket, a rocket may use its channel, and reading the
+// members leaves the rocket cache alone.
+func TestFleetServiceAggregates(t *testing.T) {
+	// Arrange
+	eventStore, _ := infrastructure.NewKafkaEventStore(infrastructure.KafkaConfig{})
+	fleetStore, _ := infrastructure.NewKafkaEventStore(infrastructure.KafkaConfig{})
+	repository := infrastructure.NewRocketRepository(eventStore)
+	rockets := NewRocketApplicationService(repository, eventStore)
+	fleets := NewFleetService(infrastructure.NewFleetRepository(fleetStore), rockets)
+	messages := []*ProcessMessageDTO{
+		{Channel: "rocket-a", Number: 1, Action: "launch", RocketType: "Falcon-9", Value: 1000, Param: "exploration", Time: 1},
+		{Channel: "rocket-b", Number: 1, Action: "launch", RocketType: "Falcon-9", Value: 3000, Param: "exploration", Time: 1},
+		{Channel: "rocket-b", Number: 2, Action: "explode", Param: "engine failure", Time: 2},
+		{Channel: "fleet:alpha", Number: 1, Action: "launch", RocketType: "Falcon-9", Value: 500, Param: "exploration", Time: 1},
+	}
+	for _, msg := range messages {
+		if err := rockets.ProcessMessage(msg); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	// Act
+	_, errCreate := fleets.CreateFleet("alpha", "Alpha")
+	_, errA := fleets.AddRocket("alpha", "rocket-a")
+	_, errB := fleets.AddRocket("alpha", "rocket-b")
+	fleet, errC := fleets.AddRocket("alpha", "rocket-c") // not launched yet
+	_, errUnknown := fleets.AddRocket("beta", "rocket-a")
+	listed, _ := rockets.ListRockets()
+	cacheBefore := repository.CacheStats()
+	_, errGet := fleets.GetFleet("alpha")
+	cacheAfter := repository.CacheStats()
+
+	// Assert
+	for _, err := range []error{errCreate, errA, errB, errC, errGet} {
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if !errors.Is(errUnknown, domain.ErrFleetNotFound) {
+		t.Errorf("Expected ErrFleetNotFound, got %v", errUnknown)
+	}
+	stats := fleet.Stats
+	if len(fleet.Rockets) != 3 || stats.Rockets != 3 {
+		t.Fatalf("Expected 3 rockets, got %d", len(fleet.Rockets))
+	}
+	if stats.StatusCounts["flying"] != 1 || stats.StatusCounts["exploded"] != 1 || stats.StatusCounts["not_launched"] != 1 {
+		t.Errorf("Unexpected status counts %v", stats.StatusCounts)
+	}
+	if stats.TotalSpeed != 4000 || stats.AverageSpeed < 1333 || stats.AverageSpeed > 1334 || stats.Exploded != 1 {
+		t.Errorf("Expected total 4000, average 1333.3 and 1 exploded, got %+v", stats)
+	}
+	if len(listed) != 3 {
+		t.Errorf("Expected rockets a, b and fleet:alpha and no fleet stream, got %d rockets", len(listed))
+	}
+	if cacheAfter != cacheBefore {
+		t.Errorf("Expected reading a fleet to leave the rocket cache alone, got %+v then %+v", cacheBefore, cacheAfter)
+	}
+}
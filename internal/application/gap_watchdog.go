@@ -0,0 +1,232 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"rockets/internal/domain"
+)
+
+// GapAction is what happens when the reorder buffer of a channel waited too long for a message
+type GapAction string
+
+const (
+	GapWait      GapAction = "wait"      // keep waiting; the gap is only logged and counted
+	GapSkip      GapAction = "skip"      // record a MessagesSkipped event and apply the buffered messages
+	GapRedeliver GapAction = "redeliver" // ask the producer to send the missing messages again
+)
+
+// ParseGapAction parses the name of a GapAction
+func ParseGapAction(s string) (GapAction, error) {
+	switch action := GapAction(s); action {
+	case GapWait, GapSkip, GapRedeliver:
+		return action, nil
+	default:
+		return "", fmt.Errorf("unknown gap action %q (want wait, skip or redeliver)", s)
+	}
+}
+
+// GapPolicy is how long a channel's buffer waits for a missing message, and what happens then.
+// A zero Timeout waits forever.
+type GapPolicy struct {
+	Timeout time.Duration
+	Action  GapAction
+}
+
+// GapPolicies holds the gap policy of every channel
+type GapPolicies struct {
+	Default  GapPolicy
+	Channels map[string]GapPolicy // overrides the default, by channel
+}
+
+// For returns the gap policy of a channel
+func (p GapPolicies) For(channel string) GapPolicy {
+	if policy, ok := p.Channels[channel]; ok {
+		return policy
+	}
+	return p.Default
+}
+
+// shortestTimeout returns the shortest timeout of all policies (0 if none has one)
+func (p GapPolicies) shortestTimeout() time.Duration {
+	shortest := p.Default.Timeout
+	for _, policy := range p.Channels {
+		if policy.Timeout > 0 && (shortest <= 0 || policy.Timeout < shortest) {
+			shortest = policy.Timeout
+		}
+	}
+	return shortest
+}
+
+// RedeliveryRequest asks a producer to send messages From to To of a channel again
+type RedeliveryRequest struct {
+	Tenant  string `json:"tenant,omitempty"`
+	Channel string `json:"channel"`
+	From    int    `json:"from"`
+	To      int    `json:"to"`
+}
+
+// gapWatchdogSource is the metadata source of the events the watchdog records
+const gapWatchdogSource = "gap_watchdog"
+
+// gapCheckInterval returns how often the WorkerPool should call ResolveGaps: a quarter of the
+// shortest timeout, between 10ms and 1s. It is 0 when every channel waits forever.
+func (s *RocketApplicationService) gapCheckInterval() time.Duration {
+	timeout := s.gapPolicies.shortestTimeout()
+	if timeout <= 0 {
+		return 0
+	}
+	interval := timeout / 4
+	if interval < 10*time.Millisecond {
+		interval = 10 * time.Millisecond
+	}
+	if interval > time.Second {
+		interval = time.Second
+	}
+	return interval
+}
+
+// pendingRedelivery is a redelivery request sent once the shard's lock is released
+type pendingRedelivery struct {
+	request RedeliveryRequest
+	waited  time.Duration
+}
+
+// ResolveGaps applies its gap policy to every channel whose buffer has waited longer than its
+// timeout for a missing message. Every resolution is logged and reported to the gap hook as
+// its action, with a "_failed" suffix when it failed.
+func (s *RocketApplicationService) ResolveGaps(now time.Time) {
+	var redeliveries []pendingRedelivery
+	for _, shard := range s.shards {
+		shard.mu.Lock()
+		for channel, gap := range shard.gaps {
+			policy := s.gapPolicies.For(channel)
+			if policy.Timeout <= 0 || now.Sub(gap.since) < policy.Timeout {
+				continue
+			}
+			if r := s.resolveGap(shard, channel, policy.Action, now); r != nil {
+				redeliveries = append(redeliveries, *r)
+			}
+		}
+		shard.mu.Unlock()
+	}
+
+	// The producer may answer with the missing messages right away: do not hold any lock
+	for _, r := range redeliveries {
+		err := errors.New("no redelivery callback configured")
+		if s.redeliver != nil {
+			err = s.redeliver(r.request)
+		}
+		s.gapResolved(r.request.Channel, GapRedeliver, r.request.From, r.request.To, r.waited, err)
+	}
+}
+
+// resolveGap applies action to the gap of a channel. The caller holds the shard's lock.
+func (s *RocketApplicationService) resolveGap(shard *reorderShard, channel string, action GapAction, now time.Time) *pendingRedelivery {
+	gap := shard.gaps[channel]
+	numbers := shard.numbers(channel)
+	if len(numbers) == 0 {
+		delete(shard.gaps, channel)
+		return nil
+	}
+	// Another writer may have moved the channel on since the wait started
+	ch, err := domain.NewChannel(channel)
+	if err != nil {
+		return nil
+	}
+	rocket, err := s.repository.GetByChannel(ch)
+	if err != nil {
+		slog.Warn("Gap check failed", "channel", channel, "err", err)
+		return nil
+	}
+	from, to := rocket.GetLastMessageNumber().Value()+1, numbers[0]-1
+	waited := now.Sub(gap.since)
+	if to < from {
+		// Nothing is missing: the next message is buffered because it failed, try it again
+		if err := s.drainBuffer(shard, channel, from-1); err != nil {
+			slog.Warn("Buffered message failed again", "channel", channel, "number", from, "err", err)
+		}
+		return nil
+	}
+
+	switch action {
+	case GapSkip:
+		s.gapResolved(channel, GapSkip, from, to, waited, s.skipGap(shard, ch, from, to, waited))
+	case GapRedeliver:
+		gap.since = now
+		return &pendingRedelivery{request: RedeliveryRequest{Tenant: s.tenant, Channel: channel, From: from, To: to}, waited: waited}
+	default:
+		gap.since = now
+		s.gapResolved(channel, GapWait, from, to, waited, nil)
+	}
+	return nil
+}
+
+// skipGap records that messages from to to of a channel will never come, then applies the
+// buffered messages that follow them. The caller holds the shard's lock.
+func (s *RocketApplicationService) skipGap(shard *reorderShard, ch *domain.Channel, from, to int, waited time.Duration) error {
+	channel := ch.Value()
+	fromNum, err := domain.NewMessageNumber(from)
+	if err != nil {
+		return err
+	}
+	toNum, err := domain.NewMessageNumber(to)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 1; ; attempt++ {
+		rocket, err := s.repository.GetByChannel(ch)
+		if err != nil {
+			return err
+		}
+		rocket.SetCommandMetadata(domain.EventMetadata{
+			domain.MetadataSource:    gapWatchdogSource,
+			domain.MetadataAppliedAt: time.Now().UTC().Format(time.RFC3339Nano),
+		})
+		reason := fmt.Sprintf("no message after %s", waited.Round(time.Millisecond))
+		if err := rocket.SkipMessages(fromNum, toNum, reason, time.Now().UnixMilli()); err != nil {
+			return err
+		}
+		err = s.repository.Save(rocket)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, domain.ErrConcurrencyConflict) || attempt > maxConflictRetries {
+			return err
+		}
+	}
+
+	// A buffered message that fails is kept and tried again by the next check
+	if err := s.drainBuffer(shard, channel, to); err != nil {
+		slog.Warn("Buffered message failed after skipping a gap", "channel", channel, "number", to+1, "err", err)
+	}
+	return nil
+}
+
+// gapResolved logs the resolution of a gap and reports it to the gap hook
+func (s *RocketApplicationService) gapResolved(channel string, action GapAction, from, to int, waited time.Duration, err error) {
+	outcome := string(action)
+	if err != nil {
+		outcome += "_failed"
+		slog.Error("Gap resolution failed",
+			"channel", channel,
+			"action", action,
+			"from", from,
+			"to", to,
+			"waited", waited,
+			"err", err)
+	} else {
+		slog.Warn("Gap resolved",
+			"channel", channel,
+			"action", action,
+			"from", from,
+			"to", to,
+			"waited", waited)
+	}
+	if s.onGap != nil {
+		s.onGap(outcome)
+	}
+}
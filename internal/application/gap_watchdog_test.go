@@ -0,0 +1,95 @@
+package application
+
+import (
+	"testing"
+	"time"
+
+	"rockets/internal/infrastructure"
+)
+
+// setupGapService creates a service with gap policies that counts gap resolutions by outcome
+func setupGapService(policies GapPolicies, opts ...ServiceOption) (*RocketApplicationService, map[string]int) {
+	eventStore, _ := infrastructure.NewKafkaEventStore(infrastructure.KafkaConfig{})
+	repository := infrastructure.NewRocketRepository(eventStore)
+	outcomes := map[string]int{}
+	opts = append(opts, WithGapPolicies(policies), WithGapHook(func(outcome string) { outcomes[outcome]++ }))
+	return NewRocketApplicationService(repository, eventStore, opts...), outcomes
+}
+
+// TestResolveGapsSkip verifies that a gap that outlives its timeout is skipped under the skip
+// policy, and that the buffered messages are then applied.
+// Expected result: nothing happens before the timeout; after it a MessagesSkipped event covers
+// messages 2-3, message 4 is applied and the buffer is empty.
+func TestResolveGapsSkip(t *testing.T) {
+	// Arrange
+	service, outcomes := setupGapService(GapPolicies{Default: GapPolicy{Timeout: time.Minute, Action: GapSkip}})
+	_ = service.ProcessMessage(rocketMessage("rocket-1", 1))
+	_ = service.ProcessMessage(rocketMessage("rocket-1", 4))
+
+	// Act
+	service.ResolveGaps(time.Now())
+	service.ResolveGaps(time.Now().Add(2 * time.Minute))
+
+	// Assert
+	if outcomes["skip"] != 1 || len(outcomes) != 1 {
+		t.Fatalf("Expected one skip, got %v", outcomes)
+	}
+	events, _ := service.ListEvents("rocket-1")
+	if len(events) != 3 || events[1].Type != "messages_skipped" || events[1].Details != "skipped=2-3 reason=no message after 2m0s" {
+		t.Fatalf("Expected launch, skip of 2-3 and message 4, got %+v", events)
+	}
+	if events[1].Metadata["source"] != "gap_watchdog" {
+		t.Errorf("Expected the skip to come from the watchdog, got %v", events[1].Metadata)
+	}
+	rocket, _ := service.GetRocket("rocket-1")
+	if rocket.Version != 3 || rocket.Speed != 10100 {
+		t.Errorf("Expected message 4 applied at speed 10100, got version %d speed %d", rocket.Version, rocket.Speed)
+	}
+	if buffered := service.GetBufferStatus(); len(buffered) != 0 {
+		t.Errorf("Expected nothing buffered, got %+v", buffered)
+	}
+}
+
+// TestResolveGapsRedeliver verifies that the redeliver policy asks the producer for the missing
+// messages, once per timeout, and that the channel resumes when they arrive.
+// Expected result: two requests for messages 2-3 of rocket-1; the channel that keeps waiting
+// under its own policy is only counted; no event is recorded for the gaps.
+func TestResolveGapsRedeliver(t *testing.T) {
+	// Arrange
+	var requests []RedeliveryRequest
+	service, outcomes := setupGapService(GapPolicies{
+		Default:  GapPolicy{Timeout: time.Minute, Action: GapRedeliver},
+		Channels: map[string]GapPolicy{"rocket-2": {Timeout: time.Minute, Action: GapWait}},
+	}, WithRedelivery(func(request RedeliveryRequest) error {
+		requests = append(requests, request)
+		return nil
+	}))
+	for _, channel := range []string{"rocket-1", "rocket-2"} {
+		_ = service.ProcessMessage(rocketMessage(channel, 1))
+		_ = service.ProcessMessage(rocketMessage(channel, 4))
+	}
+	start := time.Now()
+
+	// Act
+	service.ResolveGaps(start.Add(2 * time.Minute))
+	service.ResolveGaps(start.Add(150 * time.Second))
+	service.ResolveGaps(start.Add(4 * time.Minute))
+	_ = service.ProcessMessage(rocketMessage("rocket-1", 2))
+	_ = service.ProcessMessage(rocketMessage("rocket-1", 3))
+
+	// Assert
+	want := RedeliveryRequest{Channel: "rocket-1", From: 2, To: 3}
+	if len(requests) != 2 || requests[0] != want || requests[1] != want {
+		t.Fatalf("Expected two requests for %+v, got %+v", want, requests)
+	}
+	if outcomes["redeliver"] != 2 || outcomes["wait"] != 2 {
+		t.Errorf("Expected 2 redeliveries and 2 waits, got %v", outcomes)
+	}
+	rocket, _ := service.GetRocket("rocket-1")
+	if rocket.Speed != 10300 {
+		t.Errorf("Expected rocket-1 to resume up to message 4 at speed 10300, got %d", rocket.Speed)
+	}
+	if events, _ := service.ListEvents("rocket-2"); len(events) != 1 {
+		t.Errorf("Expected only the launch of rocket-2, got %+v", events)
+	}
+}
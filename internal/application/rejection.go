@@ -0,0 +1,121 @@
+package application
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"rockets/internal/domain"
+)
+
+// Rejection reasons, used as dead-letter reasons and metrics labels
+const (
+	ReasonAlreadyLaunched     = "already_launched"
+	ReasonExploded            = "exploded"
+	ReasonNotLaunched         = "not_launched"
+	ReasonInvalidTransition   = "invalid_transition"
+	ReasonOutOfOrder          = "out_of_order"
+	ReasonInvalidPayload      = "invalid_payload"
+	ReasonUnknownAction       = "unknown_action"
+	ReasonUnknownMission      = "unknown_mission"
+	ReasonStageCount          = "stage_count"
+	ReasonEnvelopeExceeded    = "envelope_exceeded"
+	ReasonTimeRegression      = "time_regression"
+	ReasonChannelErased       = "channel_erased"
+	ReasonConcurrencyConflict = "concurrency_conflict"
+	ReasonInvalidFleet        = "invalid_fleet"
+	ReasonFleetExists         = "fleet_exists"
+	ReasonAlreadyFleetMember  = "already_fleet_member"
+	ReasonBufferFull          = "buffer_full"
+	ReasonGapTooLarge         = "gap_too_large"
+	ReasonOutcomeUnknown      = "outcome_unknown"
+	ReasonInternal            = "internal"
+)
+
+// rejectionReasons maps the errors a message can be rejected with to their reason
+var rejectionReasons = []struct {
+	err    error
+	reason string
+}{
+	{domain.ErrAlreadyLaunched, ReasonAlreadyLaunched},
+	{domain.ErrRocketExploded, ReasonExploded},
+	{domain.ErrNotLaunched, ReasonNotLaunched},
+	{domain.ErrInvalidTransition, ReasonInvalidTransition},
+	{domain.ErrOutOfOrder, ReasonOutOfOrder},
+	{domain.ErrInvalidPayload, ReasonInvalidPayload},
+	{domain.ErrUnknownAction, ReasonUnknownAction},
+	{domain.ErrUnknownMission, ReasonUnknownMission},
+	{domain.ErrStageCount, ReasonStageCount},
+	{domain.ErrEnvelopeExceeded, ReasonEnvelopeExceeded},
+	{domain.ErrTimeRegression, ReasonTimeRegression},
+	{domain.ErrChannelErased, ReasonChannelErased},
+	{domain.ErrConcurrencyConflict, ReasonConcurrencyConflict},
+	{domain.ErrInvalidFleet, ReasonInvalidFleet},
+	{domain.ErrFleetExists, ReasonFleetExists},
+	{domain.ErrAlreadyFleetMember, ReasonAlreadyFleetMember},
+	{ErrBufferFull, ReasonBufferFull},
+	{ErrGapTooLarge, ReasonGapTooLarge},
+	{domain.ErrOutcomeUnknown, ReasonOutcomeUnknown},
+}
+
+// RejectionReason returns the reason a message was rejected with err
+// (ReasonInternal for failures that are not the message's fault, e.g. the store being down)
+func RejectionReason(err error) string {
+	for _, r := range rejectionReasons {
+		if errors.Is(err, r.err) {
+			return r.reason
+		}
+	}
+	return ReasonInternal
+}
+
+// IsPermanent reports whether processing the same message again can only fail the same way
+func IsPermanent(err error) bool {
+	switch RejectionReason(err) {
+	case ReasonInternal, ReasonConcurrencyConflict, ReasonOutcomeUnknown:
+		return false
+	default:
+		return true
+	}
+}
+
+// maxDeadLetters bounds how many rejected messages are kept (oldest are dropped first)
+const maxDeadLetters = 1000
+
+// DeadLetterDTO represents a message that was rejected
+type DeadLetterDTO struct {
+	Channel    string `json:"channel"`
+	Number     int    `json:"messageNumber"`
+	Action     string `json:"action"`
+	Reason     string `json:"reason"`
+	Error      string `json:"error"`
+	RejectedAt int64  `json:"rejectedAt"`
+}
+
+// deadLetters keeps the most recent rejected messages
+type deadLetters struct {
+	mu      sync.Mutex
+	entries []*DeadLetterDTO
+}
+
+func (d *deadLetters) add(dto *ProcessMessageDTO, reason string, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.entries) == maxDeadLetters {
+		d.entries = d.entries[1:]
+	}
+	d.entries = append(d.entries, &DeadLetterDTO{
+		Channel:    dto.Channel,
+		Number:     dto.Number,
+		Action:     dto.Action,
+		Reason:     reason,
+		Error:      err.Error(),
+		RejectedAt: time.Now().UnixMilli(),
+	})
+}
+
+func (d *deadLetters) list() []*DeadLetterDTO {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]*DeadLetterDTO{}, d.entries...)
+}
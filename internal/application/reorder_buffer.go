@@ -0,0 +1,417 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"rockets/internal/domain"
+)
+
+var (
+	// ErrBufferFull is returned for a message the reorder buffer has no room for
+	ErrBufferFull = errors.New("reorder buffer full")
+	// ErrGapTooLarge is returned for a message too far ahead of the next one of its channel
+	ErrGapTooLarge = errors.New("message too far ahead")
+)
+
+// OverflowPolicy is what happens to a message that comes ahead of its channel when the reorder
+// buffer has no room for it
+type OverflowPolicy string
+
+const (
+	OverflowReject        OverflowPolicy = "reject"         // refuse the new message
+	OverflowEvictFurthest OverflowPolicy = "evict_furthest" // drop the buffered message furthest ahead of its channel, if further than the new one
+	OverflowDeadLetter    OverflowPolicy = "dead_letter"    // refuse the new message and keep it in the dead letters
+)
+
+// ParseOverflowPolicy parses the name of an OverflowPolicy
+func ParseOverflowPolicy(s string) (OverflowPolicy, error) {
+	switch policy := OverflowPolicy(s); policy {
+	case OverflowReject, OverflowEvictFurthest, OverflowDeadLetter:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown overflow policy %q (want reject, evict_furthest or dead_letter)", s)
+	}
+}
+
+// BufferLimits bound the reorder buffer of a service. Zero limits are unbounded.
+type BufferLimits struct {
+	MaxMessages   int // buffered messages of all channels
+	MaxPerChannel int // buffered messages of one channel
+	MaxGap        int // how far ahead of the next expected message of its channel a message may be
+	Overflow      OverflowPolicy
+}
+
+// bounded reports whether any limit is set
+func (l BufferLimits) bounded() bool {
+	return l.MaxMessages > 0 || l.MaxPerChannel > 0 || l.MaxGap > 0
+}
+
+// reorderShard holds the out-of-order messages of the channels that hash to it. Its lock is
+// held while one of its messages is processed: each channel stays strictly ordered while
+// channels of different shards are processed in parallel.
+type reorderShard struct {
+	mu        sync.Mutex
+	pending   map[string]map[int]*ProcessMessageDTO // by channel, then message number
+	gaps      map[string]*gapState                  // channels with buffered messages, by channel
+	total     *atomic.Int64                         // buffered messages of all the shards of the service
+	admission admission
+}
+
+// admission is what Admit needs to know about the channels of a shard that have buffered
+// messages. It has its own lock, so that requests never wait for the shard's lock while its
+// messages are processed.
+type admission struct {
+	mu       sync.Mutex
+	expected map[string]int // next expected message number, by channel
+	buffered map[string]int // buffered messages, by channel
+}
+
+// gapState is the missing message the buffer of a channel is waiting for
+type gapState struct {
+	expected int       // first missing message number
+	since    time.Time // when the wait started, or was last resolved by waiting or a redelivery request
+}
+
+// newReorderShards creates n empty shards (at least one)
+func newReorderShards(n int) []*reorderShard {
+	if n <= 0 {
+		n = 1
+	}
+	shards := make([]*reorderShard, n)
+	total := new(atomic.Int64)
+	for i := range shards {
+		shards[i] = &reorderShard{
+			pending: make(map[string]map[int]*ProcessMessageDTO),
+			gaps:    make(map[string]*gapState),
+			total:   total,
+			admission: admission{
+				expected: make(map[string]int),
+				buffered: make(map[string]int),
+			},
+		}
+	}
+	return shards
+}
+
+// shardIndex maps a channel to one of n shards (FNV-1a). The WorkerPool routes jobs with the
+// same function, so with as many shards as workers each worker owns one shard.
+func shardIndex(channel string, n int) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(channel))
+	return int(h.Sum32() % uint32(n))
+}
+
+// shard returns the buffer shard of a channel
+func (s *RocketApplicationService) shard(channel string) *reorderShard {
+	return s.shards[shardIndex(channel, len(s.shards))]
+}
+
+// has reports whether a message with the same number is buffered for the channel of dto
+func (b *reorderShard) has(dto *ProcessMessageDTO) bool {
+	_, ok := b.pending[dto.Channel][dto.Number]
+	return ok
+}
+
+// reserve takes one of max buffer slots shared by all shards (max 0: unbounded)
+func (b *reorderShard) reserve(max int) bool {
+	for {
+		n := b.total.Load()
+		if max > 0 && n >= int64(max) {
+			return false
+		}
+		if b.total.CompareAndSwap(n, n+1) {
+			return true
+		}
+	}
+}
+
+// store buffers a message until the ones before it were processed. A message whose number is
+// not buffered yet needs a reserved slot.
+func (b *reorderShard) store(dto *ProcessMessageDTO) {
+	if b.pending[dto.Channel] == nil {
+		b.pending[dto.Channel] = make(map[int]*ProcessMessageDTO)
+	}
+	b.pending[dto.Channel][dto.Number] = dto
+	b.countBuffered(dto.Channel)
+}
+
+// remove drops a buffered message
+func (b *reorderShard) remove(channel string, number int) {
+	if _, ok := b.pending[channel][number]; !ok {
+		return
+	}
+	delete(b.pending[channel], number)
+	b.total.Add(-1)
+	if len(b.pending[channel]) == 0 {
+		b.forget(channel)
+		return
+	}
+	b.countBuffered(channel)
+}
+
+// countBuffered publishes the number of buffered messages of a channel to Admit. A channel
+// without buffered messages is dropped, so only those are tracked.
+func (b *reorderShard) countBuffered(channel string) {
+	b.admission.mu.Lock()
+	defer b.admission.mu.Unlock()
+	if n := len(b.pending[channel]); n > 0 {
+		b.admission.buffered[channel] = n
+	} else {
+		delete(b.admission.buffered, channel)
+		delete(b.admission.expected, channel)
+	}
+}
+
+// expect publishes the next expected message number of a channel with buffered messages to Admit
+func (b *reorderShard) expect(channel string, expected int) {
+	b.admission.mu.Lock()
+	defer b.admission.mu.Unlock()
+	if len(b.pending[channel]) > 0 {
+		b.admission.expected[channel] = expected
+	} else {
+		delete(b.admission.expected, channel)
+	}
+}
+
+// admissionState returns what Admit knows about a channel: its next expected message number
+// (known is false for a channel without buffered messages) and its buffered messages
+func (b *reorderShard) admissionState(channel string) (expected, buffered int, known bool) {
+	b.admission.mu.Lock()
+	defer b.admission.mu.Unlock()
+	expected, known = b.admission.expected[channel]
+	return expected, b.admission.buffered[channel], known
+}
+
+// waitFor records that a channel's buffer waits for message expected, keeping the start of
+// the wait if it already waited for it
+func (b *reorderShard) waitFor(channel string, expected int, now time.Time) {
+	if gap := b.gaps[channel]; gap != nil && gap.expected == expected {
+		return
+	}
+	b.gaps[channel] = &gapState{expected: expected, since: now}
+}
+
+// forget drops the buffered messages of a channel
+func (b *reorderShard) forget(channel string) {
+	b.total.Add(-int64(len(b.pending[channel])))
+	delete(b.pending, channel)
+	delete(b.gaps, channel)
+	b.countBuffered(channel)
+}
+
+// numbers returns the buffered message numbers of a channel, in ascending order
+func (b *reorderShard) numbers(channel string) []int {
+	numbers := []int{}
+	for num := range b.pending[channel] {
+		numbers = append(numbers, num)
+	}
+	sort.Ints(numbers)
+	return numbers
+}
+
+// drainBuffer processes the buffered messages of a channel that follow message number last.
+// It stops at a gap or at a message that failed but may succeed later (whose error it returns).
+// The caller holds the shard's lock.
+func (s *RocketApplicationService) drainBuffer(shard *reorderShard, channel string, last int) error {
+	for {
+		nextNum := last + 1
+		shard.expect(channel, nextNum)
+		nextDTO := shard.pending[channel][nextNum]
+		if nextDTO == nil {
+			if len(shard.pending[channel]) == 0 {
+				shard.forget(channel)
+			} else {
+				shard.waitFor(channel, nextNum, time.Now())
+			}
+			return nil
+		}
+
+		slog.Debug("Processing buffered message", "channel", channel, "number", nextNum, "action", nextDTO.Action)
+		if err := s.processMessageDirect(nextDTO); err != nil {
+			if !IsPermanent(err) {
+				// Keep it buffered, it may succeed when it is processed again
+				return err
+			}
+			// The messages before it are fine: only the buffered one is rejected
+			shard.remove(channel, nextNum)
+			_ = s.reject(nextDTO, err)
+			if !consumesMessageNumber(err) {
+				return nil
+			}
+			last = nextNum
+			continue
+		}
+		shard.remove(channel, nextNum)
+		last = nextNum
+	}
+}
+
+// furthest returns the buffered message furthest ahead of the next expected message of its
+// channel, among the messages of channel or, if channel is empty, of the whole shard
+func (b *reorderShard) furthest(channel string) (*ProcessMessageDTO, int) {
+	var victim *ProcessMessageDTO
+	distance := 0
+	for ch, messages := range b.pending {
+		if channel != "" && ch != channel {
+			continue
+		}
+		expected := 0
+		if gap := b.gaps[ch]; gap != nil {
+			expected = gap.expected
+		}
+		for num, dto := range messages {
+			if victim == nil || num-expected > distance {
+				victim, distance = dto, num-expected
+			}
+		}
+	}
+	return victim, distance
+}
+
+// overLimit checks the buffer limits for dto, which comes ahead of message expected of its
+// channel, whose buffer holds buffered messages. It returns ErrGapTooLarge or ErrBufferFull.
+func (s *RocketApplicationService) overLimit(dto *ProcessMessageDTO, expected, buffered int) error {
+	distance := dto.Number - expected
+	switch {
+	case s.limits.MaxGap > 0 && distance > s.limits.MaxGap:
+		return fmt.Errorf("%w: message %d is %d ahead of message %d of channel %s (max %d)",
+			ErrGapTooLarge, dto.Number, distance, expected, dto.Channel, s.limits.MaxGap)
+	case s.limits.MaxPerChannel > 0 && buffered >= s.limits.MaxPerChannel:
+		return fmt.Errorf("%w: channel %s already has %d buffered messages", ErrBufferFull, dto.Channel, s.limits.MaxPerChannel)
+	case s.limits.MaxMessages > 0 && s.BufferedMessages() >= s.limits.MaxMessages:
+		return fmt.Errorf("%w: %d buffered messages", ErrBufferFull, s.limits.MaxMessages)
+	}
+	return nil
+}
+
+// roomFor checks the buffer limits for dto, which comes ahead of message expected of its
+// channel. It returns the buffered message to evict to make room for it (OverflowEvictFurthest),
+// ErrGapTooLarge or ErrBufferFull, or nothing when there is room. The caller holds the shard's lock.
+func (s *RocketApplicationService) roomFor(shard *reorderShard, dto *ProcessMessageDTO, expected int) (*ProcessMessageDTO, error) {
+	buffered := len(shard.pending[dto.Channel])
+	err := s.overLimit(dto, expected, buffered)
+	if err == nil || errors.Is(err, ErrGapTooLarge) || s.limits.Overflow != OverflowEvictFurthest {
+		return nil, err
+	}
+
+	// A full channel can only make room among its own messages
+	scope := ""
+	if s.limits.MaxPerChannel > 0 && buffered >= s.limits.MaxPerChannel {
+		scope = dto.Channel
+	}
+	victim, victimDistance := shard.furthest(scope)
+	// The new message is kept only if it is closer to being applied than the evicted one
+	if victim == nil || victimDistance <= dto.Number-expected {
+		return nil, err
+	}
+	return victim, nil
+}
+
+// buffer stores dto, which comes ahead of message expected of its channel, within the buffer
+// limits. The caller holds the shard's lock.
+func (s *RocketApplicationService) buffer(shard *reorderShard, dto *ProcessMessageDTO, expected int) error {
+	for !shard.has(dto) {
+		victim, err := s.roomFor(shard, dto, expected)
+		if err != nil {
+			return s.refuse(dto, err)
+		}
+		if victim != nil {
+			shard.remove(victim.Channel, victim.Number)
+			_ = s.refuse(victim, fmt.Errorf("%w: evicted for message %d of channel %s", ErrBufferFull, dto.Number, dto.Channel))
+			continue
+		}
+		// Another shard may have taken the last slot meanwhile: check again
+		if shard.reserve(s.limits.MaxMessages) {
+			break
+		}
+	}
+	shard.store(dto)
+	shard.expect(dto.Channel, expected)
+	shard.waitFor(dto.Channel, expected, time.Now())
+	return nil
+}
+
+// refuse reports a message the reorder buffer has no room for and returns err. It is counted
+// and logged, and kept in the dead letters only under OverflowDeadLetter.
+func (s *RocketApplicationService) refuse(dto *ProcessMessageDTO, err error) error {
+	if s.limits.Overflow == OverflowDeadLetter {
+		return s.reject(dto, err)
+	}
+	if s.onRejected != nil {
+		s.onRejected(RejectionReason(err))
+	}
+	slog.Warn("Message refused", "channel", dto.Channel, "number", dto.Number, "reason", RejectionReason(err), "err", err)
+	return err
+}
+
+// Admit checks, before a message is queued, that the reorder buffer would take it if it
+// comes ahead of its channel, so that the producer learns right away that it was refused
+// (ErrGapTooLarge or ErrBufferFull). It runs on the request path, so it only reads what the
+// workers published for the channel and never waits for them: a channel without buffered
+// messages is admitted, and under OverflowEvictFurthest only the maximum gap is checked, as
+// the worker picks what to evict. The worker checks the limits again when it buffers the message.
+func (s *RocketApplicationService) Admit(dto *ProcessMessageDTO) error {
+	if dto == nil || !s.limits.bounded() {
+		return nil
+	}
+	expected, buffered, known := s.shard(dto.Channel).admissionState(dto.Channel)
+	if !known || dto.Number <= expected {
+		return nil
+	}
+
+	err := s.overLimit(dto, expected, buffered)
+	if err == nil || (s.limits.Overflow == OverflowEvictFurthest && errors.Is(err, ErrBufferFull)) {
+		return nil
+	}
+	return s.refuse(dto, err)
+}
+
+// BufferedMessages returns how many messages the reorder buffer holds
+func (s *RocketApplicationService) BufferedMessages() int {
+	return int(s.shards[0].total.Load())
+}
+
+// getBufferedMessageNumbers returns the message numbers in the buffer of a channel
+func (s *RocketApplicationService) getBufferedMessageNumbers(channel string) []int {
+	shard := s.shard(channel)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	return shard.numbers(channel)
+}
+
+// BufferStatusDTO represents the buffer status for debugging
+type BufferStatusDTO struct {
+	Channel          string `json:"channel"`
+	ExpectedNext     int    `json:"expectedNext"`
+	BufferedMessages []int  `json:"bufferedMessages"`
+}
+
+// GetBufferStatus returns the buffer status for all channels
+func (s *RocketApplicationService) GetBufferStatus() []*BufferStatusDTO {
+	var status []*BufferStatusDTO
+	for _, shard := range s.shards {
+		shard.mu.Lock()
+		for channel := range shard.pending {
+			// Get last processed message
+			ch, _ := domain.NewChannel(channel)
+			rocket, _ := s.repository.GetByChannel(ch)
+			expectedNext := rocket.GetLastMessageNumber().Value() + 1
+
+			status = append(status, &BufferStatusDTO{
+				Channel:          channel,
+				ExpectedNext:     expectedNext,
+				BufferedMessages: shard.numbers(channel),
+			})
+		}
+		shard.mu.Unlock()
+	}
+
+	return status
+}
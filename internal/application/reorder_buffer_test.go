@@ -0,0 +1,191 @@
+package application
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"rockets/internal/infrastructure"
+)
+
+// setupLimitedService creates a service whose reorder buffer has the given limits, and the
+// list its rejection hook appends the reasons to
+func setupLimitedService(limits BufferLimits) (*RocketApplicationService, *[]string) {
+	eventStore, _ := infrastructure.NewKafkaEventStore(infrastructure.KafkaConfig{})
+	repository := infrastructure.NewRocketRepository(eventStore)
+	reasons := &[]string{}
+	service := NewRocketApplicationService(repository, eventStore, WithBufferLimits(limits),
+		WithRejectionHook(func(reason string) { *reasons = append(*reasons, reason) }))
+	return service, reasons
+}
+
+// TestBufferRejectsMessagesBeyondLimits verifies the maximum gap and the per-channel cap under
+// the reject and dead_letter policies.
+// Expected result: a message 19 ahead is rejected with ErrGapTooLarge, the third future
+// message with ErrBufferFull; both are counted, but dead-lettered under dead_letter only, and
+// only two messages stay buffered.
+func TestBufferRejectsMessagesBeyondLimits(t *testing.T) {
+	for _, policy := range []OverflowPolicy{OverflowReject, OverflowDeadLetter} {
+		// Arrange
+		service, reasons := setupLimitedService(BufferLimits{MaxPerChannel: 2, MaxGap: 10, Overflow: policy})
+		_ = service.ProcessMessage(rocketMessage("rocket-1", 1))
+
+		// Act
+		errTooFar := service.ProcessMessage(rocketMessage("rocket-1", 21))
+		err3 := service.ProcessMessage(rocketMessage("rocket-1", 3))
+		err4 := service.ProcessMessage(rocketMessage("rocket-1", 4))
+		errFull := service.ProcessMessage(rocketMessage("rocket-1", 5))
+
+		// Assert
+		if !errors.Is(errTooFar, ErrGapTooLarge) {
+			t.Errorf("%s: expected ErrGapTooLarge, got %v", policy, errTooFar)
+		}
+		if err3 != nil || err4 != nil {
+			t.Fatalf("%s: expected messages 3 and 4 buffered, got %v and %v", policy, err3, err4)
+		}
+		if !errors.Is(errFull, ErrBufferFull) {
+			t.Errorf("%s: expected ErrBufferFull, got %v", policy, errFull)
+		}
+		if got := service.getBufferedMessageNumbers("rocket-1"); len(got) != 2 || service.BufferedMessages() != 2 {
+			t.Errorf("%s: expected messages 3 and 4 buffered, got %v (%d in total)", policy, got, service.BufferedMessages())
+		}
+		if len(*reasons) != 2 || (*reasons)[0] != ReasonGapTooLarge || (*reasons)[1] != ReasonBufferFull {
+			t.Errorf("%s: expected gap_too_large and buffer_full to be counted, got %v", policy, *reasons)
+		}
+		rejected := service.GetDeadLetters()
+		if policy == OverflowReject && len(rejected) != 0 {
+			t.Errorf("%s: expected no dead letters, got %+v", policy, rejected)
+		}
+		if policy == OverflowDeadLetter && (len(rejected) != 2 || rejected[0].Reason != ReasonGapTooLarge || rejected[1].Reason != ReasonBufferFull) {
+			t.Errorf("%s: expected gap_too_large and buffer_full dead letters, got %+v", policy, rejected)
+		}
+	}
+}
+
+// TestBufferEvictsFurthestMessage verifies that under the evict_furthest policy a full buffer
+// drops the message furthest ahead of its channel, unless the new message is further.
+// Expected result: rocket-a #6 is evicted for rocket-b #3; rocket-b #9 is refused; both are
+// counted but not dead-lettered; the buffer count follows evictions and drains.
+func TestBufferEvictsFurthestMessage(t *testing.T) {
+	// Arrange
+	service, reasons := setupLimitedService(BufferLimits{MaxMessages: 2, Overflow: OverflowEvictFurthest})
+	for _, channel := range []string{"rocket-a", "rocket-b"} {
+		_ = service.ProcessMessage(rocketMessage(channel, 1))
+	}
+	_ = service.ProcessMessage(rocketMessage("rocket-a", 3))
+	_ = service.ProcessMessage(rocketMessage("rocket-a", 6))
+
+	// Act
+	errCloser := service.ProcessMessage(rocketMessage("rocket-b", 3))
+	errFurther := service.ProcessMessage(rocketMessage("rocket-b", 9))
+	_ = service.ProcessMessage(rocketMessage("rocket-a", 2))
+
+	// Assert
+	if errCloser != nil {
+		t.Fatalf("Expected rocket-b #3 buffered, got %v", errCloser)
+	}
+	if !errors.Is(errFurther, ErrBufferFull) {
+		t.Errorf("Expected ErrBufferFull for rocket-b #9, got %v", errFurther)
+	}
+	if len(*reasons) != 2 || (*reasons)[0] != ReasonBufferFull || (*reasons)[1] != ReasonBufferFull {
+		t.Errorf("Expected the eviction and the refusal counted as buffer_full, got %v", *reasons)
+	}
+	if rejected := service.GetDeadLetters(); len(rejected) != 0 {
+		t.Errorf("Expected no dead letters, got %+v", rejected)
+	}
+	if got := service.getBufferedMessageNumbers("rocket-b"); len(got) != 1 || got[0] != 3 || service.BufferedMessages() != 1 {
+		t.Errorf("Expected only rocket-b #3 buffered, got %v (%d in total)", got, service.BufferedMessages())
+	}
+}
+
+// TestAdmitAppliesOverflowPolicy verifies that messages are refused before being queued, and
+// that only the dead_letter policy keeps them.
+// Expected result: both policies refuse message 3 with ErrBufferFull; the dead letters hold it
+// under dead_letter only; messages that are next or fit are admitted.
+func TestAdmitAppliesOverflowPolicy(t *testing.T) {
+	for _, policy := range []OverflowPolicy{OverflowReject, OverflowDeadLetter} {
+		// Arrange
+		service, _ := setupLimitedService(BufferLimits{MaxPerChannel: 1, Overflow: policy})
+		_ = service.ProcessMessage(rocketMessage("rocket-1", 1))
+		_ = service.ProcessMessage(rocketMessage("rocket-1", 4))
+
+		// Act
+		errNext := service.Admit(rocketMessage("rocket-1", 2))
+		errFull := service.Admit(rocketMessage("rocket-1", 3))
+		errOther := service.Admit(rocketMessage("rocket-2", 3))
+
+		// Assert
+		if errNext != nil || errOther != nil {
+			t.Errorf("%s: expected messages that fit to be admitted, got %v and %v", policy, errNext, errOther)
+		}
+		if !errors.Is(errFull, ErrBufferFull) {
+			t.Errorf("%s: expected ErrBufferFull, got %v", policy, errFull)
+		}
+		wantDeadLetters := 0
+		if policy == OverflowDeadLetter {
+			wantDeadLetters = 1
+		}
+		if got := len(service.GetDeadLetters()); got != wantDeadLetters {
+			t.Errorf("%s: expected %d dead letters, got %d", policy, wantDeadLetters, got)
+		}
+		if service.BufferedMessages() != 1 {
+			t.Errorf("%s: expected only message 4 buffered, got %d", policy, service.BufferedMessages())
+		}
+	}
+}
+
+// TestAdmitDoesNotWaitForTheWorker verifies that Admit neither takes the shard's lock, held
+// while a message is processed, nor reads the event store.
+// Expected result: with the shard locked, message 13 is refused with ErrGapTooLarge and
+// message 3 is admitted without waiting.
+func TestAdmitDoesNotWaitForTheWorker(t *testing.T) {
+	// Arrange
+	service, _ := setupLimitedService(BufferLimits{MaxGap: 10})
+	_ = service.ProcessMessage(rocketMessage("rocket-1", 1))
+	_ = service.ProcessMessage(rocketMessage("rocket-1", 4))
+	shard := service.shard("rocket-1")
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	// Act
+	done := make(chan [2]error, 1)
+	go func() {
+		done <- [2]error{service.Admit(rocketMessage("rocket-1", 13)), service.Admit(rocketMessage("rocket-1", 3))}
+	}()
+
+	// Assert
+	select {
+	case errs := <-done:
+		if !errors.Is(errs[0], ErrGapTooLarge) || errs[1] != nil {
+			t.Errorf("Expected ErrGapTooLarge then nil, got %v and %v", errs[0], errs[1])
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected Admit not to wait for the shard's lock")
+	}
+}
+
+// TestAdmitForgetsDrainedChannels verifies that the admission state of a channel is dropped once
+// nothing of it is buffered, so it does not grow with every channel ever processed.
+// Expected result: rocket-1 is tracked while #3 is buffered, and no channel is once it drained.
+func TestAdmitForgetsDrainedChannels(t *testing.T) {
+	// Arrange
+	service, _ := setupLimitedService(BufferLimits{MaxGap: 10})
+	_ = service.ProcessMessage(rocketMessage("rocket-1", 1))
+	_ = service.ProcessMessage(rocketMessage("rocket-1", 3))
+	_ = service.ProcessMessage(rocketMessage("rocket-2", 1))
+	shard := service.shard("rocket-1")
+	_, _, tracked := shard.admissionState("rocket-1")
+
+	// Act
+	_ = service.ProcessMessage(rocketMessage("rocket-1", 2))
+
+	// Assert
+	if !tracked {
+		t.Errorf("Expected rocket-1 to be tracked while #3 is buffered")
+	}
+	for _, s := range service.shards {
+		if len(s.admission.expected) != 0 || len(s.admission.buffered) != 0 {
+			t.Errorf("Expected no admission state once drained, got %v and %v", s.admission.expected, s.admission.buffered)
+		}
+	}
+}
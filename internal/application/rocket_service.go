@@ -1,29 +1,141 @@
 package application
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
-	"sync"
+	"strconv"
+	"time"
 
 	"rockets/internal/domain"
 )
 
+// ErrRocketNotFound is returned for channels that have no events
+var ErrRocketNotFound = errors.New("rocket not found")
+
 // RocketApplicationService is the application service for rockets
 type RocketApplicationService struct {
 	repository domain.RocketRepository
 	eventStore domain.EventStore
-	// Buffer for out-of-order messages -> ordering by messageNumber per channel
-	pendingMessages map[string]map[int]*ProcessMessageDTO
-	bufferMutex     sync.Mutex // Mutex to protect access to pendingMessages map
+	// Buffers for out-of-order messages -> ordering by messageNumber per channel
+	shards []*reorderShard
+
+	deadLetters deadLetters
+	onRejected  func(reason string)   // optional, e.g. to count rejections
+	catalog     *domain.RocketCatalog // optional, rocket type envelopes commands are checked against
+	missions    *domain.MissionRegistry
+	timePolicy  *domain.TimePolicy // optional, how message times that go back are handled
+	keys        domain.KeyShredder // optional, data keys destroyed when a channel is erased
+	gapPolicies GapPolicies        // what happens to buffers that wait too long for a message
+	redeliver   func(RedeliveryRequest) error
+	onGap       func(outcome string) // optional, e.g. to count gap resolutions
+	tenant      string               // set by the TenantRegistry, copied into redelivery requests
+	limits      BufferLimits         // bounds of the reorder buffer (default: unbounded)
+}
+
+// ServiceOption configures optional features of the RocketApplicationService
+type ServiceOption func(*RocketApplicationService)
+
+// WithRejectionHook calls fn with the reason of every rejected message
+func WithRejectionHook(fn func(reason string)) ServiceOption {
+	return func(s *RocketApplicationService) {
+		s.onRejected = fn
+	}
+}
+
+// WithRocketCatalog checks every command against the envelope of the rocket's type
+func WithRocketCatalog(catalog *domain.RocketCatalog) ServiceOption {
+	return func(s *RocketApplicationService) {
+		s.catalog = catalog
+	}
+}
+
+// WithMissionRegistry resolves the missions of incoming messages with registry
+// (default: the built-in categories, not strict)
+func WithMissionRegistry(registry *domain.MissionRegistry) ServiceOption {
+	return func(s *RocketApplicationService) {
+		s.missions = registry
+	}
+}
+
+// WithTimePolicy checks that the message times of every channel do not go back
+func WithTimePolicy(policy domain.TimePolicy) ServiceOption {
+	return func(s *RocketApplicationService) {
+		s.timePolicy = &policy
+	}
+}
+
+// WithBufferShards splits the reorder buffer into n shards (default 1). Channels of different
+// shards are processed in parallel; give it the number of workers of the pool that feeds the
+// service, so that each worker owns one shard.
+func WithBufferShards(n int) ServiceOption {
+	return func(s *RocketApplicationService) {
+		s.shards = newReorderShards(n)
+	}
+}
+
+// WithBufferLimits bounds the reorder buffer: messages beyond the limits are handled by
+// limits.Overflow (default: OverflowReject)
+func WithBufferLimits(limits BufferLimits) ServiceOption {
+	return func(s *RocketApplicationService) {
+		if limits.Overflow == "" {
+			limits.Overflow = OverflowReject
+		}
+		s.limits = limits
+	}
+}
+
+// WithGapPolicies sets how long the reorder buffer of each channel waits for a missing message
+// and what happens then (default: wait forever). The WorkerPool checks them periodically.
+func WithGapPolicies(policies GapPolicies) ServiceOption {
+	return func(s *RocketApplicationService) {
+		s.gapPolicies = policies
+	}
+}
+
+// WithRedelivery sets the producer callback asked to send missing messages again (GapRedeliver)
+func WithRedelivery(fn func(RedeliveryRequest) error) ServiceOption {
+	return func(s *RocketApplicationService) {
+		s.redeliver = fn
+	}
+}
+
+// WithGapHook calls fn with the outcome of every gap resolution (see ResolveGaps)
+func WithGapHook(fn func(outcome string)) ServiceOption {
+	return func(s *RocketApplicationService) {
+		s.onGap = fn
+	}
+}
+
+// withTenant names the tenant the service belongs to
+func withTenant(id string) ServiceOption {
+	return func(s *RocketApplicationService) {
+		s.tenant = id
+	}
+}
+
+// WithKeyShredder destroys the data key of every erased channel, so its stored events can no
+// longer be decrypted
+func WithKeyShredder(keys domain.KeyShredder) ServiceOption {
+	return func(s *RocketApplicationService) {
+		s.keys = keys
+	}
 }
 
 // NewRocketApplicationService creates a new application service
-func NewRocketApplicationService(repository domain.RocketRepository, eventStore domain.EventStore) *RocketApplicationService {
-	return &RocketApplicationService{
-		repository:      repository,
-		eventStore:      eventStore,
-		pendingMessages: make(map[string]map[int]*ProcessMessageDTO),
+func NewRocketApplicationService(repository domain.RocketRepository, eventStore domain.EventStore, opts ...ServiceOption) *RocketApplicationService {
+	s := &RocketApplicationService{
+		repository: repository,
+		eventStore: eventStore,
+		shards:     newReorderShards(1),
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	if s.missions == nil {
+		s.missions = domain.NewMissionRegistry(false)
+	}
+	return s
 }
 
 // ProcessMessageDTO represents an incoming message
@@ -35,29 +147,61 @@ type ProcessMessageDTO struct {
 	Value      int    `json:"value,omitempty"`
 	Time       int64  `json:"time"`
 	RocketType string `json:"rocketType,omitempty"`
+
+	// Origin of the message, copied into the metadata of the events it causes
+	MessageID     string `json:"messageId,omitempty"`
+	CorrelationID string `json:"correlationId,omitempty"`
+	Source        string `json:"source,omitempty"`
+	ReceivedAt    int64  `json:"receivedAt,omitempty"` // Unix milliseconds
+}
+
+// eventMetadata builds the metadata of the events caused by the message
+func (dto *ProcessMessageDTO) eventMetadata() domain.EventMetadata {
+	causationID := dto.MessageID
+	if causationID == "" {
+		causationID = fmt.Sprintf("%s#%d", dto.Channel, dto.Number)
+	}
+	metadata := domain.EventMetadata{
+		domain.MetadataCausationID: causationID,
+		domain.MetadataAppliedAt:   time.Now().UTC().Format(time.RFC3339Nano),
+	}
+	if dto.CorrelationID != "" {
+		metadata[domain.MetadataCorrelationID] = dto.CorrelationID
+	}
+	if dto.Source != "" {
+		metadata[domain.MetadataSource] = dto.Source
+	}
+	if dto.ReceivedAt > 0 {
+		metadata[domain.MetadataReceivedAt] = time.UnixMilli(dto.ReceivedAt).UTC().Format(time.RFC3339Nano)
+		metadata[domain.MetadataClockSkew] = strconv.FormatInt(dto.Time-dto.ReceivedAt, 10)
+	}
+	return metadata
 }
 
-// ProcessMessage process a message with ordering guarantees
+// ProcessMessage process a message with ordering guarantees. Only the buffer shard of the
+// message's channel is locked, so messages of other shards can be processed meanwhile.
 func (s *RocketApplicationService) ProcessMessage(dto *ProcessMessageDTO) error {
 	if dto == nil {
 		return fmt.Errorf("message DTO cannot be nil")
 	}
 
-	s.bufferMutex.Lock()
-	defer s.bufferMutex.Unlock()
+	shard := s.shard(dto.Channel)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
 
 	// Get the last expected messageNumber
 	channel, err := domain.NewChannel(dto.Channel)
 	if err != nil {
-		return fmt.Errorf("invalid channel: %w", err)
+		return s.reject(dto, fmt.Errorf("invalid channel: %w", err))
 	}
 
 	rocket, err := s.repository.GetByChannel(channel)
 	if err != nil {
-		return fmt.Errorf("failed to get rocket: %w", err)
+		return s.reject(dto, fmt.Errorf("failed to get rocket: %w", err))
 	}
 
 	expected := rocket.GetLastMessageNumber().Value() + 1
+	shard.expect(dto.Channel, expected)
 
 	slog.Debug("Message ordering check", "channel", dto.Channel, "received", dto.Number, "expected", expected)
 
@@ -65,53 +209,95 @@ func (s *RocketApplicationService) ProcessMessage(dto *ProcessMessageDTO) error
 	if dto.Number == expected {
 		slog.Info("Processing message", "channel", dto.Channel, "number", dto.Number, "action", dto.Action)
 		if err := s.processMessageDirect(dto); err != nil {
-			return err
-		}
-
-		// Process consecutive messages from the buffer
-		for {
-			nextNum := expected + 1
-			if s.pendingMessages[dto.Channel] == nil {
-				break
+			err = s.reject(dto, err)
+			if IsPermanent(err) {
+				// A copy buffered earlier would fail the same way
+				shard.remove(dto.Channel, expected)
 			}
-			nextDTO := s.pendingMessages[dto.Channel][nextNum]
-			if nextDTO == nil {
-				break
+			if consumesMessageNumber(err) {
+				// Rejected, but recorded in the channel: the buffered messages can follow
+				_ = s.drainBuffer(shard, dto.Channel, expected)
 			}
-
-			slog.Debug("Processing buffered message", "channel", dto.Channel, "number", nextNum, "action", nextDTO.Action)
-			if err := s.processMessageDirect(nextDTO); err != nil {
-				return err
-			}
-			delete(s.pendingMessages[dto.Channel], nextNum)
-			expected = nextNum
+			return err
 		}
+		// A copy of a redelivered message may still be buffered after it failed there
+		shard.remove(dto.Channel, expected)
 
-		return nil
+		// Process consecutive messages from the buffer
+		return s.drainBuffer(shard, dto.Channel, expected)
 	}
 
 	// If it is a future message, store it in the buffer
 	if dto.Number > expected {
-		if s.pendingMessages[dto.Channel] == nil {
-			s.pendingMessages[dto.Channel] = make(map[int]*ProcessMessageDTO)
+		if err := s.buffer(shard, dto, expected); err != nil {
+			return err
 		}
-		s.pendingMessages[dto.Channel][dto.Number] = dto
 		slog.Debug("Message stored in buffer", "channel", dto.Channel, "number", dto.Number, "waiting_for", expected)
-		slog.Debug("Buffered messages", "channel", dto.Channel, "pending", s.getBufferedMessageNumbers(dto.Channel))
+		slog.Debug("Buffered messages", "channel", dto.Channel, "pending", shard.numbers(dto.Channel))
 		return nil // Not an error, just waiting
 	}
 
 	// If it is an old or duplicate message, reject
 	slog.Warn("Message rejected - already processed", "channel", dto.Channel, "number", dto.Number, "expected", expected)
-	return fmt.Errorf("message %d already processed (expected %d)", dto.Number, expected)
+	return s.reject(dto, fmt.Errorf("%w: message %d already processed (expected %d)", domain.ErrOutOfOrder, dto.Number, expected))
+}
+
+// consumesMessageNumber reports whether a message rejected with err was still recorded in its
+// channel (a RocketEnvelopeExceeded or RocketTimeRegressed event), so the next message number
+// is expected after it
+func consumesMessageNumber(err error) bool {
+	return errors.Is(err, domain.ErrEnvelopeExceeded) || errors.Is(err, domain.ErrTimeRegression)
 }
 
-// processMessageDirect processes a message directly (without buffer)
+// reject records a message that could not be processed and returns err
+func (s *RocketApplicationService) reject(dto *ProcessMessageDTO, err error) error {
+	reason := RejectionReason(err)
+	s.deadLetters.add(dto, reason, err)
+	if s.onRejected != nil {
+		s.onRejected(reason)
+	}
+	slog.Warn("Message rejected",
+		"channel", dto.Channel,
+		"number", dto.Number,
+		"action", dto.Action,
+		"reason", reason,
+		"err", err)
+	return err
+}
+
+// GetDeadLetters returns the most recently rejected messages, oldest first
+func (s *RocketApplicationService) GetDeadLetters() []*DeadLetterDTO {
+	return s.deadLetters.list()
+}
+
+// maxConflictRetries bounds how many times a message is re-applied after losing a write race
+const maxConflictRetries = 3
+
+// processMessageDirect processes a message directly (without buffer).
+// When another writer appended to the channel first, the rocket is reloaded and the
+// message is applied again on top of the new state.
 func (s *RocketApplicationService) processMessageDirect(dto *ProcessMessageDTO) error {
 	if dto == nil {
 		return fmt.Errorf("message DTO cannot be nil")
 	}
 
+	for attempt := 1; ; attempt++ {
+		err := s.applyMessage(dto)
+		// Only a lost write race is worth retrying right away
+		if err == nil || !errors.Is(err, domain.ErrConcurrencyConflict) || attempt > maxConflictRetries {
+			return err
+		}
+		slog.Warn("Retrying message after concurrent write",
+			"channel", dto.Channel,
+			"number", dto.Number,
+			"attempt", attempt,
+			"err", err)
+	}
+}
+
+// applyMessage loads the rocket, executes the action and saves the resulting events
+func (s *RocketApplicationService) applyMessage(dto *ProcessMessageDTO) error {
+
 	// Validate and create value objects
 	channel, err := domain.NewChannel(dto.Channel)
 	if err != nil {
@@ -129,16 +315,42 @@ func (s *RocketApplicationService) processMessageDirect(dto *ProcessMessageDTO)
 		return fmt.Errorf("failed to get rocket: %w", err)
 	}
 
+	rocket.SetCommandMetadata(dto.eventMetadata())
+	rocket.SetCatalog(s.catalog)
+	rocket.SetTimePolicy(s.timePolicy)
+
 	// Process action
+	if err := s.executeAction(rocket, msgNum, dto); err != nil {
+		if consumesMessageNumber(err) {
+			// The rejection is recorded in the channel
+			if saveErr := s.repository.Save(rocket); saveErr != nil {
+				return saveErr
+			}
+		}
+		return err
+	}
+
+	// Save changes
+	return s.repository.Save(rocket)
+}
+
+// executeAction executes the action of a message on the rocket
+func (s *RocketApplicationService) executeAction(rocket *domain.Rocket, msgNum *domain.MessageNumber, dto *ProcessMessageDTO) error {
 	switch dto.Action {
 	case "launch":
-		speed, _ := domain.NewSpeed(dto.Value)
-		mission := domain.NewMission(dto.Param)
+		speed, err := domain.NewSpeed(dto.Value)
+		if err != nil {
+			return fmt.Errorf("invalid launch speed: %w", err)
+		}
+		mission, err := s.missions.Resolve(dto.Param)
+		if err != nil {
+			return err
+		}
 		rocketType := dto.RocketType
 		if rocketType == "" {
 			rocketType = "unknown"
 		}
-		if err := rocket.Launch(msgNum, rocketType, speed, mission, dto.Time); err != nil {
+		if err := rocket.LaunchNamed(msgNum, rocketType, speed, mission, dto.Time); err != nil {
 			return err
 		}
 
@@ -158,46 +370,86 @@ func (s *RocketApplicationService) processMessageDirect(dto *ProcessMessageDTO)
 		}
 
 	case "change_mission":
-		mission := domain.NewMission(dto.Param)
-		if err := rocket.ChangeMission(msgNum, mission, dto.Time); err != nil {
+		mission, err := s.missions.Resolve(dto.Param)
+		if err != nil {
+			return err
+		}
+		if err := rocket.ChangeMissionNamed(msgNum, mission, dto.Time); err != nil {
 			return err
 		}
 
-	default:
-		return fmt.Errorf("unknown action: %s", dto.Action)
-	}
+	case "land":
+		if err := rocket.Land(msgNum, dto.Param, dto.Time); err != nil {
+			return err
+		}
 
-	// Save changes
-	return s.repository.Save(rocket)
-}
+	case "refuel":
+		if err := rocket.Refuel(msgNum, dto.Value, dto.Time); err != nil {
+			return err
+		}
 
-// getBufferedMessageNumbers returns the message numbers in the buffer
-func (s *RocketApplicationService) getBufferedMessageNumbers(channel string) []int {
-	numbers := []int{}
-	if s.pendingMessages[channel] == nil {
-		return numbers
-	}
-	for num := range s.pendingMessages[channel] {
-		numbers = append(numbers, num)
+	case "separate_stage":
+		if err := rocket.SeparateStage(msgNum, dto.Value, dto.Time); err != nil {
+			return err
+		}
+
+	default:
+		return fmt.Errorf("%w: %s", domain.ErrUnknownAction, dto.Action)
 	}
-	return numbers
+	return nil
 }
 
 // RocketDTO represents a rocket to be exposed via API
 type RocketDTO struct {
-	Channel string `json:"channel"`
-	Type    string `json:"type"`
-	Status  string `json:"status"`
-	Speed   int    `json:"speed"`
-	Mission string `json:"mission"`
+	Channel     string `json:"channel"`
+	Type        string `json:"type"`
+	Status      string `json:"status"`
+	Speed       int    `json:"speed"`
+	Mission     string `json:"mission"`
+	MissionName string `json:"missionName,omitempty"` // as reported by the producer
+	Fuel        int    `json:"fuel"`
+	Stages      *int   `json:"remainingStages,omitempty"` // set once a stage has separated
+	Version     int    `json:"version"`                   // events in the rocket's stream
+	// Latest message time applied, when the last message was received (Unix milliseconds) and
+	// how far ahead of the server the producer's clock was for it
+	LastMessageTime int64  `json:"lastMessageTime,omitempty"`
+	LastReceivedAt  int64  `json:"lastReceivedAt,omitempty"`
+	ClockSkew       *int64 `json:"clockSkewMs,omitempty"`
+}
+
+// toRocketDTO converts a rocket to its API representation
+func toRocketDTO(rocket *domain.Rocket) *RocketDTO {
+	dto := &RocketDTO{
+		Channel:     rocket.GetChannel().Value(),
+		Type:        rocket.GetRocketType(),
+		Status:      string(rocket.GetStatus()),
+		Speed:       rocket.GetSpeed().Value(),
+		Mission:     string(rocket.GetMission()),
+		MissionName: rocket.GetRawMission(),
+		Fuel:        rocket.GetFuel(),
+		Version:     rocket.GetVersion(),
+	}
+	if stages, ok := rocket.GetRemainingStages(); ok {
+		dto.Stages = &stages
+	}
+	dto.LastMessageTime = rocket.GetLastMessageTime()
+	dto.LastReceivedAt = rocket.GetLastReceivedAt()
+	if skew, ok := rocket.GetClockSkew(); ok {
+		dto.ClockSkew = &skew
+	}
+	return dto
 }
 
 // EventDTO represents an event to be exposed via API
 type EventDTO struct {
-	Type          string `json:"type"`
-	MessageNumber int    `json:"messageNumber"`
-	Timestamp     int64  `json:"timestamp"`
-	Details       string `json:"details,omitempty"`
+	Type          string            `json:"type"`
+	Version       int               `json:"version"` // position of the event in its stream, from 1
+	MessageNumber int               `json:"messageNumber"`
+	Timestamp     int64             `json:"timestamp"`
+	ReceivedAt    int64             `json:"receivedAt,omitempty"` // Unix milliseconds, from the metadata
+	Details       string            `json:"details,omitempty"`
+	Metadata      map[string]string `json:"metadata,omitempty"`
+	Redacted      bool              `json:"redacted,omitempty"` // the event belongs to an erased channel
 }
 
 // GetRocket gets the current state of a rocket
@@ -211,14 +463,19 @@ func (s *RocketApplicationService) GetRocket(channelStr string) (*RocketDTO, err
 	if err != nil {
 		return nil, err
 	}
+	if rocket.IsNew() {
+		return nil, fmt.Errorf("%w: %s", ErrRocketNotFound, channel.Value())
+	}
+	if rocket.GetStatus() == domain.StatusErased {
+		return nil, fmt.Errorf("%w: %s", domain.ErrChannelErased, channel.Value())
+	}
 
-	return &RocketDTO{
-		Channel: channel.Value(),
-		Type:    rocket.GetRocketType(),
-		Status:  string(rocket.GetStatus()),
-		Speed:   rocket.GetSpeed().Value(),
-		Mission: string(rocket.GetMission()),
-	}, nil
+	return toRocketDTO(rocket), nil
+}
+
+// GetMissionRegistry returns the registry the missions of incoming messages are resolved with
+func (s *RocketApplicationService) GetMissionRegistry() *domain.MissionRegistry {
+	return s.missions
 }
 
 // ListRockets gets all rockets
@@ -230,13 +487,10 @@ func (s *RocketApplicationService) ListRockets() ([]*RocketDTO, error) {
 
 	var dtos []*RocketDTO
 	for _, rocket := range rockets {
-		dtos = append(dtos, &RocketDTO{
-			Channel: rocket.GetChannel().Value(),
-			Type:    rocket.GetRocketType(),
-			Status:  string(rocket.GetStatus()),
-			Speed:   rocket.GetSpeed().Value(),
-			Mission: string(rocket.GetMission()),
-		})
+		if rocket.GetStatus() == domain.StatusErased {
+			continue
+		}
+		dtos = append(dtos, toRocketDTO(rocket))
 	}
 
 	return dtos, nil
@@ -253,62 +507,169 @@ func (s *RocketApplicationService) ListEvents(channelStr string) ([]*EventDTO, e
 	if err != nil {
 		return nil, err
 	}
+	if len(events) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrRocketNotFound, channel.Value())
+	}
 
 	var dtos []*EventDTO
-	for _, ev := range events {
-		e := &EventDTO{
-			Type:          ev.GetEventType(),
-			MessageNumber: ev.GetMessageNumber().Value(),
-			Timestamp:     ev.GetTimestamp(),
-		}
-		switch v := ev.(type) {
-		case *domain.RocketLaunched:
-			e.Details = fmt.Sprintf("mission=%s speed=%d", v.Mission, v.Speed.Value())
-		case *domain.RocketSpeedIncreased:
-			e.Details = fmt.Sprintf("delta=%d newSpeed=%d", v.Delta, v.NewSpeed.Value())
-		case *domain.RocketSpeedDecreased:
-			e.Details = fmt.Sprintf("delta=%d newSpeed=%d", v.Delta, v.NewSpeed.Value())
-		case *domain.RocketMissionChanged:
-			e.Details = fmt.Sprintf("mission=%s", v.NewMission)
-		case *domain.RocketExploded:
-			e.Details = fmt.Sprintf("reason=%s", v.Reason)
-		}
-		dtos = append(dtos, e)
+	for i, ev := range events {
+		dtos = append(dtos, toEventDTO(ev, i+1))
 	}
 
 	return dtos, nil
 }
 
-// BufferStatusDTO represents the buffer status for debugging
-type BufferStatusDTO struct {
-	Channel          string `json:"channel"`
-	ExpectedNext     int    `json:"expectedNext"`
-	BufferedMessages []int  `json:"bufferedMessages"`
+// ErasureDTO reports the erasure of a channel
+type ErasureDTO struct {
+	Channel      string `json:"channel"`
+	KeyDestroyed bool   `json:"keyDestroyed"` // false when events are not encrypted
 }
 
-// GetBufferStatus returns the buffer status for all channels
-func (s *RocketApplicationService) GetBufferStatus() []*BufferStatusDTO {
-	s.bufferMutex.Lock()
-	defer s.bufferMutex.Unlock()
+// EraseChannel erases a channel: a ChannelErased tombstone is appended, its buffered messages
+// are dropped and its data key is destroyed. Erasing an erased channel again only destroys
+// the key, which completes an erasure that was interrupted.
+func (s *RocketApplicationService) EraseChannel(channelStr, reason string) (*ErasureDTO, error) {
+	channel, err := domain.NewChannel(channelStr)
+	if err != nil {
+		return nil, err
+	}
 
-	var status []*BufferStatusDTO
-	for channel, messages := range s.pendingMessages {
-		// Get last processed message
-		ch, _ := domain.NewChannel(channel)
-		rocket, _ := s.repository.GetByChannel(ch)
-		expectedNext := rocket.GetLastMessageNumber().Value() + 1
+	// No message of the channel may be applied meanwhile
+	shard := s.shard(channel.Value())
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	shard.forget(channel.Value())
 
-		numbers := []int{}
-		for num := range messages {
-			numbers = append(numbers, num)
+	for attempt := 1; ; attempt++ {
+		rocket, err := s.repository.GetByChannel(channel)
+		if err != nil {
+			return nil, err
+		}
+		if rocket.IsNew() {
+			return nil, fmt.Errorf("%w: %s", ErrRocketNotFound, channel.Value())
+		}
+		if rocket.GetStatus() == domain.StatusErased {
+			break
+		}
+		if err := rocket.Erase(reason, time.Now().UnixMilli()); err != nil {
+			return nil, err
+		}
+		err = s.repository.Save(rocket)
+		if err == nil {
+			break
 		}
+		if !errors.Is(err, domain.ErrConcurrencyConflict) || attempt > maxConflictRetries {
+			return nil, err
+		}
+	}
 
-		status = append(status, &BufferStatusDTO{
-			Channel:          channel,
-			ExpectedNext:     expectedNext,
-			BufferedMessages: numbers,
+	erasure := &ErasureDTO{Channel: channel.Value()}
+	if s.keys != nil {
+		if err := s.keys.DestroyKey(channel.Value()); err != nil {
+			return nil, fmt.Errorf("failed to destroy data key: %w", err)
+		}
+		erasure.KeyDestroyed = true
+	}
+	slog.Warn("Channel erased", "channel", channel.Value(), "reason", reason, "key_destroyed", erasure.KeyDestroyed)
+	return erasure, nil
+}
+
+// LogEventDTO represents an event of the global log to be exposed via API
+type LogEventDTO struct {
+	Position int64  `json:"position"`
+	Channel  string `json:"channel"`
+	*EventDTO
+}
+
+// EventPageDTO represents a page of the global log
+type EventPageDTO struct {
+	Events       []*LogEventDTO `json:"events"`
+	NextPosition int64          `json:"nextPosition"`
+}
+
+// ReadAll gets up to limit events of the global log starting at fromPosition.
+// NextPosition is where the following page starts.
+func (s *RocketApplicationService) ReadAll(fromPosition int64, limit int) (*EventPageDTO, error) {
+	if fromPosition < 1 {
+		fromPosition = 1
+	}
+
+	recorded, err := s.eventStore.ReadAll(fromPosition, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &EventPageDTO{Events: []*LogEventDTO{}, NextPosition: fromPosition}
+	for _, r := range recorded {
+		page.Events = append(page.Events, &LogEventDTO{
+			Position: r.Position,
+			Channel:  r.Event.GetChannel().Value(),
+			EventDTO: toEventDTO(r.Event, r.Version),
 		})
+		page.NextPosition = r.Position + 1
+	}
+
+	return page, nil
+}
+
+// toEventDTO converts a domain event to its API representation; version is its position in
+// its stream
+func toEventDTO(ev domain.DomainEvent, version int) *EventDTO {
+	e := &EventDTO{
+		Type:          ev.GetEventType(),
+		Version:       version,
+		MessageNumber: ev.GetMessageNumber().Value(),
+		Timestamp:     ev.GetTimestamp(),
+		Metadata:      ev.GetMetadata(),
+	}
+	if receivedAt, err := time.Parse(time.RFC3339Nano, e.Metadata[domain.MetadataReceivedAt]); err == nil {
+		e.ReceivedAt = receivedAt.UnixMilli()
 	}
+	switch v := ev.(type) {
+	case *domain.RocketLaunched:
+		e.Details = fmt.Sprintf("mission=%s%s speed=%d", v.Mission, missionNameDetail(v.RawMission), v.Speed.Value())
+	case *domain.RocketSpeedIncreased:
+		e.Details = fmt.Sprintf("delta=%d newSpeed=%d", v.Delta, v.NewSpeed.Value())
+	case *domain.RocketSpeedDecreased:
+		e.Details = fmt.Sprintf("delta=%d newSpeed=%d", v.Delta, v.NewSpeed.Value())
+	case *domain.RocketMissionChanged:
+		e.Details = fmt.Sprintf("mission=%s%s", v.NewMission, missionNameDetail(v.RawMission))
+	case *domain.RocketExploded:
+		e.Details = fmt.Sprintf("reason=%s", v.Reason)
+	case *domain.RocketLanded:
+		e.Details = fmt.Sprintf("site=%s", v.Site)
+	case *domain.RocketRefueled:
+		e.Details = fmt.Sprintf("amount=%d fuel=%d", v.Amount, v.Fuel)
+	case *domain.RocketStageSeparated:
+		e.Details = fmt.Sprintf("remainingStages=%d", v.RemainingStages)
+	case *domain.RocketEnvelopeExceeded:
+		e.Details = fmt.Sprintf("command=%s rule=%s rejected=%t: %s", v.Command, v.Rule, v.Rejected, v.Detail)
+	case *domain.RocketTimeRegressed:
+		e.Details = fmt.Sprintf("command=%s previousTime=%d regressionMs=%d rejected=%t", v.Command, v.PreviousTime, v.Regression(), v.Rejected)
+	case *domain.ChannelErased:
+		e.Details = fmt.Sprintf("reason=%s", v.Reason)
+	case *domain.MessagesSkipped:
+		e.Details = fmt.Sprintf("skipped=%d-%d reason=%s", v.From, v.MessageNumber.Value(), v.Reason)
+	case *domain.RedactedEvent:
+		e.Redacted = true
+	}
+	return e
+}
 
-	return status
+// missionNameDetail describes the producer's mission in event details, when it was recorded
+func missionNameDetail(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	return fmt.Sprintf(" missionName=%q", raw)
+}
+
+// VerifyEventChains walks the event store and reports the first broken link of the hash
+// chain of every channel
+func (s *RocketApplicationService) VerifyEventChains() (*domain.ChainReport, error) {
+	verifier, ok := s.eventStore.(domain.ChainVerifier)
+	if !ok {
+		return nil, domain.ErrHashChainUnsupported
+	}
+	return verifier.VerifyChains()
 }
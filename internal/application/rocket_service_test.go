@@ -1,13 +1,16 @@
 package application
 
 import (
+	"errors"
 	"testing"
+	"time"
 
+	"rockets/internal/domain"
 	"rockets/internal/infrastructure"
 )
 
 func setupTestService() *RocketApplicationService {
-	eventStore := infrastructure.NewKafkaEventStore("localhost:9092")
+	eventStore, _ := infrastructure.NewKafkaEventStore(infrastructure.KafkaConfig{})
 	repository := infrastructure.NewRocketRepository(eventStore)
 	return NewRocketApplicationService(repository, eventStore)
 }
@@ -285,3 +288,292 @@ func TestBufferReprocessing(t *testing.T) {
 		t.Errorf("Expected status exploded, got %s", rocket.Status)
 	}
 }
+
+// TestProcessMessageRetriesAfterConcurrentWrite verifies that two service instances sharing one
+// event store do not interleave writes on a channel.
+// Instance B has a stale copy of the rocket when instance A commits message #2.
+// Expected result: B's message #3 is retried on top of A's write; final speed 15000 + 5000 - 2000.
+func TestProcessMessageRetriesAfterConcurrentWrite(t *testing.T) {
+	// Arrange
+	eventStore, _ := infrastructure.NewKafkaEventStore(infrastructure.KafkaConfig{})
+	serviceA := NewRocketApplicationService(infrastructure.NewRocketRepository(eventStore), eventStore)
+	serviceB := NewRocketApplicationService(infrastructure.NewRocketRepository(eventStore), eventStore)
+
+	launch := &ProcessMessageDTO{Channel: "rocket-shared", Number: 1, Action: "launch", RocketType: "Falcon-9", Value: 15000, Param: "exploration", Time: 1}
+	if err := serviceA.ProcessMessage(launch); err != nil {
+		t.Fatalf("Expected no error launching, got %v", err)
+	}
+	// B loads (and caches) the rocket at version 1
+	if _, err := serviceB.GetRocket("rocket-shared"); err != nil {
+		t.Fatalf("Expected no error loading rocket, got %v", err)
+	}
+	if err := serviceA.ProcessMessage(&ProcessMessageDTO{Channel: "rocket-shared", Number: 2, Action: "increase_speed", Value: 5000, Time: 2}); err != nil {
+		t.Fatalf("Expected no error for msg2, got %v", err)
+	}
+
+	// Act
+	err := serviceB.processMessageDirect(&ProcessMessageDTO{Channel: "rocket-shared", Number: 3, Action: "decrease_speed", Value: 2000, Time: 3})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected msg3 to succeed after retry, got %v", err)
+	}
+	rocket, _ := serviceB.GetRocket("rocket-shared")
+	if rocket.Speed != 18000 {
+		t.Errorf("Expected speed 18000, got %d", rocket.Speed)
+	}
+	events, _ := serviceA.ListEvents("rocket-shared")
+	if len(events) != 3 {
+		t.Errorf("Expected 3 events in the shared store, got %d", len(events))
+	}
+}
+
+// TestProcessMessageDeadLetters verifies that rejected messages are dead-lettered with
+// their reason and reported to the rejection hook.
+// Expected result: exploded, out_of_order and unknown_action, in that order.
+func TestProcessMessageDeadLetters(t *testing.T) {
+	// Arrange
+	eventStore, _ := infrastructure.NewKafkaEventStore(infrastructure.KafkaConfig{})
+	var reasons []string
+	service := NewRocketApplicationService(infrastructure.NewRocketRepository(eventStore), eventStore,
+		WithRejectionHook(func(reason string) { reasons = append(reasons, reason) }))
+	_ = service.ProcessMessage(&ProcessMessageDTO{Channel: "rocket-dl", Number: 1, Action: "launch", RocketType: "Falcon-9", Value: 1000, Param: "ARTEMIS", Time: 1})
+	_ = service.ProcessMessage(&ProcessMessageDTO{Channel: "rocket-dl", Number: 2, Action: "explode", Param: "PRESSURE", Time: 2})
+
+	// Act
+	crashedErr := service.ProcessMessage(&ProcessMessageDTO{Channel: "rocket-dl", Number: 3, Action: "increase_speed", Value: 100, Time: 3})
+	duplicateErr := service.ProcessMessage(&ProcessMessageDTO{Channel: "rocket-dl", Number: 2, Action: "explode", Time: 2})
+	unknownErr := service.ProcessMessage(&ProcessMessageDTO{Channel: "rocket-dl", Number: 3, Action: "teleport", Time: 3})
+
+	// Assert
+	if !errors.Is(crashedErr, domain.ErrRocketExploded) || !errors.Is(duplicateErr, domain.ErrOutOfOrder) || !errors.Is(unknownErr, domain.ErrUnknownAction) {
+		t.Fatalf("Expected exploded, out of order and unknown action errors, got %v / %v / %v", crashedErr, duplicateErr, unknownErr)
+	}
+	expected := []string{ReasonExploded, ReasonOutOfOrder, ReasonUnknownAction}
+	letters := service.GetDeadLetters()
+	if len(letters) != len(expected) || len(reasons) != len(expected) {
+		t.Fatalf("Expected %d dead letters and hook calls, got %d and %d", len(expected), len(letters), len(reasons))
+	}
+	for i, reason := range expected {
+		if letters[i].Reason != reason || reasons[i] != reason {
+			t.Errorf("Expected reason %s at %d, got %s (hook %s)", reason, i, letters[i].Reason, reasons[i])
+		}
+	}
+}
+
+// TestProcessMessageDropsRejectedBufferedMessage verifies that a buffered message rejected
+// for good is dead-lettered and removed from the buffer, without failing the message that
+// released it.
+// Expected result: message #1 succeeds, #2 (buffered, not launched) is dead-lettered, buffer empty.
+func TestProcessMessageDropsRejectedBufferedMessage(t *testing.T) {
+	// Arrange
+	service := setupTestService()
+	_ = service.ProcessMessage(&ProcessMessageDTO{Channel: "rocket-buf", Number: 3, Action: "increase_speed", Value: 100, Time: 3})
+	_ = service.ProcessMessage(&ProcessMessageDTO{Channel: "rocket-buf", Number: 2, Action: "launch", RocketType: "Falcon-9", Value: 1000, Param: "ARTEMIS", Time: 2})
+
+	// Act
+	err := service.ProcessMessage(&ProcessMessageDTO{Channel: "rocket-buf", Number: 1, Action: "launch", RocketType: "Falcon-9", Value: 1000, Param: "ARTEMIS", Time: 1})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error for message #1, got %v", err)
+	}
+	letters := service.GetDeadLetters()
+	if len(letters) != 1 || letters[0].Number != 2 || letters[0].Reason != ReasonAlreadyLaunched {
+		t.Errorf("Expected message #2 dead-lettered as already_launched, got %+v", letters)
+	}
+	if buffered := service.getBufferedMessageNumbers("rocket-buf"); len(buffered) != 1 || buffered[0] != 3 {
+		t.Errorf("Expected only message #3 to stay buffered, got %v", buffered)
+	}
+}
+
+// failingStore fails the next appends of a message, as a store that is briefly unavailable
+type failingStore struct {
+	domain.EventStore
+	channel  string
+	number   int
+	failures int
+}
+
+func (s *failingStore) AppendEvents(channel *domain.Channel, expectedVersion int, events []domain.DomainEvent) error {
+	if channel.Value() == s.channel && events[0].GetMessageNumber().Value() == s.number && s.failures > 0 {
+		s.failures--
+		return errors.New("store unavailable")
+	}
+	return s.EventStore.AppendEvents(channel, expectedVersion, events)
+}
+
+// TestProcessMessageRedeliveryAfterBufferedFailure verifies that a message redelivered after its
+// buffered copy failed for a transient reason replaces that copy.
+// Expected result: #2 stays buffered after failing; the redelivered #2 is applied together with
+// the buffered #3, and the buffer is empty.
+func TestProcessMessageRedeliveryAfterBufferedFailure(t *testing.T) {
+	// Arrange
+	memory, _ := infrastructure.NewKafkaEventStore(infrastructure.KafkaConfig{})
+	eventStore := &failingStore{EventStore: memory, channel: "rocket-flaky", number: 2, failures: 1}
+	service := NewRocketApplicationService(infrastructure.NewRocketRepository(eventStore), eventStore)
+	_ = service.ProcessMessage(&ProcessMessageDTO{Channel: "rocket-flaky", Number: 3, Action: "increase_speed", Value: 100, Time: 3})
+	_ = service.ProcessMessage(&ProcessMessageDTO{Channel: "rocket-flaky", Number: 2, Action: "increase_speed", Value: 100, Time: 2})
+	_ = service.ProcessMessage(&ProcessMessageDTO{Channel: "rocket-flaky", Number: 1, Action: "launch", RocketType: "Falcon-9", Value: 1000, Param: "ARTEMIS", Time: 1})
+	stuck := service.getBufferedMessageNumbers("rocket-flaky")
+
+	// Act
+	err := service.ProcessMessage(&ProcessMessageDTO{Channel: "rocket-flaky", Number: 2, Action: "increase_speed", Value: 100, Time: 2})
+
+	// Assert
+	if len(stuck) != 2 {
+		t.Fatalf("Expected #2 and #3 buffered after the failure, got %v", stuck)
+	}
+	if err != nil {
+		t.Fatalf("Expected no error for the redelivered #2, got %v", err)
+	}
+	rocket, _ := service.GetRocket("rocket-flaky")
+	if rocket.Speed != 1200 {
+		t.Errorf("Expected #2 and #3 applied at speed 1200, got %d", rocket.Speed)
+	}
+	if buffered := service.BufferedMessages(); buffered != 0 {
+		t.Errorf("Expected an empty buffer, got %d messages (%v)", buffered, service.getBufferedMessageNumbers("rocket-flaky"))
+	}
+}
+
+// TestProcessMessageEnvelopeRejectionReleasesBuffer verifies that a message rejected for
+// exceeding the rocket type's envelope does not stall its channel.
+// Expected result: message #2 is dead-lettered as envelope_exceeded, the buffered message #3
+// is applied, speed 26000.
+func TestProcessMessageEnvelopeRejectionReleasesBuffer(t *testing.T) {
+	// Arrange
+	eventStore, _ := infrastructure.NewKafkaEventStore(infrastructure.KafkaConfig{})
+	repository := infrastructure.NewRocketRepository(eventStore)
+	catalog, _ := domain.NewRocketCatalog(domain.EnvelopeReject, domain.RocketTypeSpec{Name: "Falcon-9", MaxSpeed: 30000})
+	service := NewRocketApplicationService(repository, eventStore, WithRocketCatalog(catalog))
+	_ = service.ProcessMessage(&ProcessMessageDTO{Channel: "rocket-env", Number: 1, Action: "launch", RocketType: "Falcon-9", Value: 25000, Param: "ARTEMIS", Time: 1})
+	_ = service.ProcessMessage(&ProcessMessageDTO{Channel: "rocket-env", Number: 3, Action: "increase_speed", Value: 1000, Time: 3})
+
+	// Act
+	err := service.ProcessMessage(&ProcessMessageDTO{Channel: "rocket-env", Number: 2, Action: "increase_speed", Value: 9000, Time: 2})
+
+	// Assert
+	if !errors.Is(err, domain.ErrEnvelopeExceeded) {
+		t.Fatalf("Expected ErrEnvelopeExceeded, got %v", err)
+	}
+	letters := service.GetDeadLetters()
+	if len(letters) != 1 || letters[0].Number != 2 || letters[0].Reason != ReasonEnvelopeExceeded {
+		t.Errorf("Expected message #2 dead-lettered as envelope_exceeded, got %+v", letters)
+	}
+	rocket, _ := service.GetRocket("rocket-env")
+	if rocket.Speed != 26000 {
+		t.Errorf("Expected speed 26000, got %d", rocket.Speed)
+	}
+	events, _ := service.ListEvents("rocket-env")
+	if len(events) != 3 || events[1].Type != "rocket_envelope_exceeded" {
+		t.Errorf("Expected launch, envelope exceeded and speed increase events, got %+v", events)
+	}
+}
+
+// TestProcessMessageMissionRegistry verifies that missions are resolved with the registry.
+// Expected result: a registered mission keeps its name next to the category; in strict mode
+// an unregistered one is dead-lettered as unknown_mission.
+func TestProcessMessageMissionRegistry(t *testing.T) {
+	// Arrange
+	eventStore, _ := infrastructure.NewKafkaEventStore(infrastructure.KafkaConfig{})
+	repository := infrastructure.NewRocketRepository(eventStore)
+	missions := domain.NewMissionRegistry(true)
+	_ = missions.Register("Mars Mission", domain.MissionExploration)
+	service := NewRocketApplicationService(repository, eventStore, WithMissionRegistry(missions))
+
+	// Act
+	launchErr := service.ProcessMessage(&ProcessMessageDTO{Channel: "rocket-mars", Number: 1, Action: "launch", RocketType: "Falcon-9", Value: 1000, Param: "Mars Mission", Time: 1})
+	changeErr := service.ProcessMessage(&ProcessMessageDTO{Channel: "rocket-mars", Number: 2, Action: "change_mission", Param: "Moon Tourism", Time: 2})
+
+	// Assert
+	if launchErr != nil {
+		t.Fatalf("Expected no error launching, got %v", launchErr)
+	}
+	rocket, _ := service.GetRocket("rocket-mars")
+	if rocket.Mission != "exploration" || rocket.MissionName != "Mars Mission" {
+		t.Errorf("Expected exploration (Mars Mission), got %s (%s)", rocket.Mission, rocket.MissionName)
+	}
+	if !errors.Is(changeErr, domain.ErrUnknownMission) {
+		t.Errorf("Expected ErrUnknownMission, got %v", changeErr)
+	}
+	if letters := service.GetDeadLetters(); len(letters) != 1 || letters[0].Reason != ReasonUnknownMission {
+		t.Errorf("Expected one unknown_mission dead letter, got %+v", letters)
+	}
+}
+
+// TestProcessMessageTimeRegression verifies that a message going back in time is rejected
+// without stalling its channel, and that the producer's clock skew is exposed.
+// Expected result: message #2 dead-lettered as time_regression, #3 applied, the rocket shows
+// the latest message time, the receive time of #3 and a skew of -500 ms.
+func TestProcessMessageTimeRegression(t *testing.T) {
+	// Arrange
+	eventStore, _ := infrastructure.NewKafkaEventStore(infrastructure.KafkaConfig{})
+	repository := infrastructure.NewRocketRepository(eventStore)
+	service := NewRocketApplicationService(repository, eventStore, WithTimePolicy(domain.TimePolicy{Tolerance: time.Second, Reject: true}))
+	_ = service.ProcessMessage(&ProcessMessageDTO{Channel: "rocket-time", Number: 1, Action: "launch", RocketType: "Falcon-9", Value: 1000, Param: "ARTEMIS", Time: 10000, ReceivedAt: 10000})
+
+	// Act
+	regressErr := service.ProcessMessage(&ProcessMessageDTO{Channel: "rocket-time", Number: 2, Action: "increase_speed", Value: 100, Time: 5000, ReceivedAt: 10100})
+	nextErr := service.ProcessMessage(&ProcessMessageDTO{Channel: "rocket-time", Number: 3, Action: "increase_speed", Value: 50, Time: 11000, ReceivedAt: 11500})
+
+	// Assert
+	if !errors.Is(regressErr, domain.ErrTimeRegression) {
+		t.Fatalf("Expected ErrTimeRegression, got %v", regressErr)
+	}
+	if nextErr != nil {
+		t.Fatalf("Expected message #3 to be applied, got %v", nextErr)
+	}
+	if letters := service.GetDeadLetters(); len(letters) != 1 || letters[0].Reason != ReasonTimeRegression {
+		t.Errorf("Expected one time_regression dead letter, got %+v", letters)
+	}
+	rocket, _ := service.GetRocket("rocket-time")
+	if rocket.Speed != 1050 || rocket.LastMessageTime != 11000 || rocket.LastReceivedAt != 11500 {
+		t.Errorf("Expected speed 1050, last message time 11000 received at 11500, got %+v", rocket)
+	}
+	if rocket.ClockSkew == nil || *rocket.ClockSkew != -500 {
+		t.Errorf("Expected a clock skew of -500 ms, got %v", rocket.ClockSkew)
+	}
+}
+
+// TestEraseChannel verifies that an erased channel disappears and its history is redacted.
+// Expected result: the rocket is no longer listed, GET fails with ErrChannelErased, the events
+// are redacted up to the tombstone (metadata included) and new messages are rejected.
+func TestEraseChannel(t *testing.T) {
+	// Arrange
+	service := setupTestService()
+	_ = service.ProcessMessage(&ProcessMessageDTO{Channel: "rocket-a", Number: 1, Action: "launch", RocketType: "Falcon-9", Value: 1000, Param: "exploration", Time: 1})
+	_ = service.ProcessMessage(&ProcessMessageDTO{Channel: "rocket-b", Number: 1, Action: "launch", RocketType: "Falcon-9", Value: 1000, Param: "exploration", Time: 1})
+
+	// Act
+	erasure, err := service.EraseChannel("rocket-a", "contract terminated")
+	listed, _ := service.ListRockets()
+	_, errGet := service.GetRocket("rocket-a")
+	events, _ := service.ListEvents("rocket-a")
+	errMessage := service.ProcessMessage(&ProcessMessageDTO{Channel: "rocket-a", Number: 2, Action: "increase_speed", Value: 10, Time: 2})
+	_, errUnknown := service.EraseChannel("rocket-c", "")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if erasure.KeyDestroyed {
+		t.Errorf("Expected no key to destroy without encryption")
+	}
+	if len(listed) != 1 || listed[0].Channel != "rocket-b" {
+		t.Errorf("Expected only rocket-b to be listed, got %d rockets", len(listed))
+	}
+	if !errors.Is(errGet, domain.ErrChannelErased) {
+		t.Errorf("Expected ErrChannelErased, got %v", errGet)
+	}
+	if len(events) != 2 || !events[0].Redacted || events[0].Type != "rocket_launched" || events[1].Type != "channel_erased" {
+		t.Errorf("Expected a redacted launch and the tombstone, got %+v", events)
+	} else if events[0].Timestamp != 0 || events[0].ReceivedAt != 0 || len(events[0].Metadata) != 0 {
+		t.Errorf("Expected the redacted launch without timestamp or metadata, got %+v", events[0])
+	}
+	if RejectionReason(errMessage) != ReasonChannelErased {
+		t.Errorf("Expected channel_erased, got %v", errMessage)
+	}
+	if !errors.Is(errUnknown, ErrRocketNotFound) {
+		t.Errorf("Expected ErrRocketNotFound, got %v", errUnknown)
+	}
+}
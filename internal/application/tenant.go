@@ -0,0 +1,272 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"rockets/internal/domain"
+)
+
+// ErrTooManyTenants is returned when opening a tenant would exceed TenantLimits.MaxTenants
+var ErrTooManyTenants = errors.New("too many tenants")
+
+// TenantBackend is the storage partition of one tenant
+type TenantBackend struct {
+	EventStore domain.EventStore
+	Repository domain.RocketRepository
+	Fleets     domain.FleetRepository
+	Serializer domain.EventSerializer // used by exports and imports
+	Keys       domain.KeyShredder     // optional: data keys of encrypted channels
+	Close      func() error           // optional: releases the event store
+}
+
+// TenantBackendFactory opens the storage partition of a tenant
+type TenantBackendFactory func(tenantID string) (*TenantBackend, error)
+
+// Tenant groups everything that is isolated per tenant: its store partition, the service
+// (and with it the reorder buffers) and the worker pool that feeds it
+type Tenant struct {
+	ID      string
+	Service *RocketApplicationService
+	Archive *ArchiveService
+	Fleets  *FleetService
+	Pool    *WorkerPool
+	backend *TenantBackend
+	stop    context.CancelFunc // stops the worker pool
+}
+
+// TenantLimits bound what unknown clients can make the registry open. Zero values mean no limit.
+type TenantLimits struct {
+	MaxTenants  int           // tenants open at once, the default tenant included
+	IdleTimeout time.Duration // tenants unused for this long are closed (never the default tenant)
+}
+
+// TenantRegistry opens tenants on first use and keeps them until they are idle for
+// TenantLimits.IdleTimeout, or until Close
+type TenantRegistry struct {
+	ctx         context.Context // stops the worker pools
+	factory     TenantBackendFactory
+	workerCount int
+	limits      TenantLimits
+	options     []ServiceOption
+	janitor     sync.WaitGroup // closes idle tenants
+
+	mu    sync.Mutex
+	slots map[string]*tenantSlot
+}
+
+// tenantSlot holds a tenant while its partition is being opened, so opening one tenant
+// does not block requests to the others. users and lastUsed are guarded by the registry's lock.
+type tenantSlot struct {
+	ready    chan struct{} // closed once tenant or err is set
+	tenant   *Tenant
+	err      error
+	users    int       // requests holding the tenant
+	lastUsed time.Time // when the last of them released it
+}
+
+// NewTenantRegistry creates a registry whose tenants get workerCount workers each and a
+// service configured with opts. Cancelling ctx stops their worker pools and the closing of
+// idle tenants.
+func NewTenantRegistry(ctx context.Context, factory TenantBackendFactory, workerCount int, limits TenantLimits, opts ...ServiceOption) *TenantRegistry {
+	if factory == nil {
+		panic("tenant backend factory cannot be nil")
+	}
+	r := &TenantRegistry{
+		ctx:         ctx,
+		factory:     factory,
+		workerCount: workerCount,
+		limits:      limits,
+		options:     opts,
+		slots:       make(map[string]*tenantSlot),
+	}
+	if limits.IdleTimeout > 0 {
+		r.janitor.Add(1)
+		go r.closeIdleLoop()
+	}
+	return r
+}
+
+// Get returns the tenant with the given ID, opening its partition if needed. The tenant may be
+// closed once idle: callers that keep using it should Acquire it instead.
+func (r *TenantRegistry) Get(id string) (*Tenant, error) {
+	tenant, release, err := r.Acquire(id)
+	if err != nil {
+		return nil, err
+	}
+	release()
+	return tenant, nil
+}
+
+// Acquire returns the tenant with the given ID, opening its partition if needed. The tenant
+// is not closed for being idle until release is called.
+func (r *TenantRegistry) Acquire(id string) (*Tenant, func(), error) {
+	if err := domain.ValidateTenantID(id); err != nil {
+		return nil, nil, err
+	}
+
+	r.mu.Lock()
+	slot, ok := r.slots[id]
+	if !ok {
+		if r.limits.MaxTenants > 0 && len(r.slots) >= r.limits.MaxTenants {
+			r.mu.Unlock()
+			return nil, nil, fmt.Errorf("%w: %d open", ErrTooManyTenants, r.limits.MaxTenants)
+		}
+		slot = &tenantSlot{ready: make(chan struct{})}
+		r.slots[id] = slot
+	}
+	slot.users++
+	r.mu.Unlock()
+
+	if !ok {
+		slot.tenant, slot.err = r.open(id)
+		if slot.err != nil {
+			// Forget the failure so the next request tries again
+			r.mu.Lock()
+			delete(r.slots, id)
+			r.mu.Unlock()
+		}
+		close(slot.ready)
+	}
+	<-slot.ready
+
+	release := func() {
+		r.mu.Lock()
+		slot.users--
+		slot.lastUsed = time.Now()
+		r.mu.Unlock()
+	}
+	if slot.err != nil {
+		release()
+		return nil, nil, slot.err
+	}
+	return slot.tenant, release, nil
+}
+
+// open creates the partition, service and worker pool of a tenant
+func (r *TenantRegistry) open(id string) (*Tenant, error) {
+	backend, err := r.factory(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open tenant %s: %w", id, err)
+	}
+	// Each worker owns one shard of the reorder buffer
+	options := append(r.options[:len(r.options):len(r.options)], WithBufferShards(r.workerCount), withTenant(id))
+	if backend.Keys != nil {
+		options = append(options, WithKeyShredder(backend.Keys))
+	}
+	service := NewRocketApplicationService(backend.Repository, backend.EventStore, options...)
+	tenant := &Tenant{
+		ID:      id,
+		Service: service,
+		Archive: NewArchiveService(backend.EventStore, backend.Serializer, backend.Repository),
+		Fleets:  NewFleetService(backend.Fleets, service),
+		Pool:    NewWorkerPool(service, r.workerCount),
+		backend: backend,
+	}
+	ctx, stop := context.WithCancel(r.ctx)
+	tenant.stop = stop
+	tenant.Pool.Start(ctx)
+
+	slog.Info("Tenant opened", "tenant", id)
+	return tenant, nil
+}
+
+// Tenants returns the tenants opened so far, sorted by ID
+func (r *TenantRegistry) Tenants() []*Tenant {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenants := make([]*Tenant, 0, len(r.slots))
+	for _, slot := range r.slots {
+		select {
+		case <-slot.ready:
+			if slot.tenant != nil {
+				tenants = append(tenants, slot.tenant)
+			}
+		default: // still opening
+		}
+	}
+	sort.Slice(tenants, func(i, j int) bool { return tenants[i].ID < tenants[j].ID })
+	return tenants
+}
+
+// CloseIdle closes the tenants nobody used since before now minus the idle timeout and that
+// have nothing left to do: no request holding them, no message queued or being processed and
+// nothing in their reorder buffers. The default tenant stays open. It returns how many it closed.
+func (r *TenantRegistry) CloseIdle(now time.Time) int {
+	if r.limits.IdleTimeout <= 0 {
+		return 0
+	}
+
+	var idle []*Tenant
+	r.mu.Lock()
+	for id, slot := range r.slots {
+		if id == domain.DefaultTenantID || slot.users > 0 || now.Sub(slot.lastUsed) < r.limits.IdleTimeout {
+			continue
+		}
+		// users == 0 means the slot is ready: every Acquire holds it until it is
+		tenant := slot.tenant
+		if tenant == nil || !tenant.Pool.Idle() || tenant.Service.BufferedMessages() > 0 {
+			continue
+		}
+		// Nobody can acquire the tenant any more, so it stays idle while it is closed
+		delete(r.slots, id)
+		idle = append(idle, tenant)
+	}
+	r.mu.Unlock()
+
+	for _, tenant := range idle {
+		if err := r.closeTenant(tenant); err != nil {
+			slog.Error("Failed to close idle tenant", "tenant", tenant.ID, "err", err)
+			continue
+		}
+		slog.Info("Idle tenant closed", "tenant", tenant.ID)
+	}
+	return len(idle)
+}
+
+// closeIdleLoop calls CloseIdle until the registry context is cancelled
+func (r *TenantRegistry) closeIdleLoop() {
+	defer r.janitor.Done()
+	interval := r.limits.IdleTimeout / 4
+	if interval > time.Minute {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.ctx.Done():
+			return
+		case now := <-ticker.C:
+			r.CloseIdle(now)
+		}
+	}
+}
+
+// closeTenant stops the worker pool of a tenant and closes its event store
+func (r *TenantRegistry) closeTenant(tenant *Tenant) error {
+	tenant.stop()
+	tenant.Pool.Wait()
+	if tenant.backend.Close == nil {
+		return nil
+	}
+	return tenant.backend.Close()
+}
+
+// Close waits for the worker pools (the registry context must be cancelled first) and
+// closes every tenant's event store
+func (r *TenantRegistry) Close() error {
+	r.janitor.Wait()
+	var errs []error
+	for _, tenant := range r.Tenants() {
+		if err := r.closeTenant(tenant); err != nil {
+			errs = append(errs, fmt.Errorf("tenant %s: %w", tenant.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
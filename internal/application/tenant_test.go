@@ -0,0 +1,195 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"rockets/internal/domain"
+	"rockets/internal/infrastructure"
+)
+
+// memoryTenants creates a registry whose tenants keep their events in memory
+func memoryTenants(t *testing.T, limits TenantLimits) *TenantRegistry {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	registry := NewTenantRegistry(ctx, func(string) (*TenantBackend, error) {
+		eventStore, err := infrastructure.NewKafkaEventStore(infrastructure.KafkaConfig{})
+		if err != nil {
+			return nil, err
+		}
+		return &TenantBackend{
+			EventStore: eventStore,
+			Repository: infrastructure.NewRocketRepository(eventStore),
+			Serializer: infrastructure.DefaultEventCodec(),
+		}, nil
+	}, 1, limits)
+	t.Cleanup(func() {
+		cancel()
+		_ = registry.Close()
+	})
+	return registry
+}
+
+// TestTenantRegistryIsolatesChannels verifies that the same channel in two tenants is two
+// different rockets, with separate reorder buffers.
+// Expected result: team-a sees its launch at 1000; team-b's early message #2 stays buffered
+// and is not applied to team-a's rocket.
+func TestTenantRegistryIsolatesChannels(t *testing.T) {
+	// Arrange
+	registry := memoryTenants(t, TenantLimits{})
+	teamA, _ := registry.Get("team-a")
+	teamB, _ := registry.Get("team-b")
+
+	// Act
+	errA := teamA.Service.ProcessMessage(&ProcessMessageDTO{Channel: "rocket-alpha", Number: 1, Action: "launch", RocketType: "Falcon-9", Value: 1000, Param: "ARTEMIS", Time: 1})
+	errB := teamB.Service.ProcessMessage(&ProcessMessageDTO{Channel: "rocket-alpha", Number: 2, Action: "increase_speed", Value: 500, Time: 2})
+
+	// Assert
+	if errA != nil || errB != nil {
+		t.Fatalf("Expected no errors, got %v and %v", errA, errB)
+	}
+	rocket, err := teamA.Service.GetRocket("rocket-alpha")
+	if err != nil {
+		t.Fatalf("Expected team-a's rocket, got %v", err)
+	}
+	if rocket.Speed != 1000 {
+		t.Errorf("Expected speed 1000, got %d", rocket.Speed)
+	}
+	if rockets, _ := teamB.Service.ListRockets(); len(rockets) != 0 {
+		t.Errorf("Expected team-b to have no rockets, got %d", len(rockets))
+	}
+	if buffered := teamB.Service.GetBufferStatus(); len(buffered) != 1 {
+		t.Errorf("Expected 1 channel buffered for team-b, got %d", len(buffered))
+	}
+	if buffered := teamA.Service.GetBufferStatus(); len(buffered) != 0 {
+		t.Errorf("Expected no buffered channels for team-a, got %d", len(buffered))
+	}
+}
+
+// TestTenantRegistryReusesTenants verifies that a tenant is opened once and listed.
+// Expected result: the same *Tenant twice, and both tenants listed by ID.
+func TestTenantRegistryReusesTenants(t *testing.T) {
+	// Arrange
+	registry := memoryTenants(t, TenantLimits{})
+
+	// Act
+	first, _ := registry.Get("team-b")
+	second, _ := registry.Get("team-b")
+	_, _ = registry.Get(domain.DefaultTenantID)
+	tenants := registry.Tenants()
+
+	// Assert
+	if first != second {
+		t.Errorf("Expected the same tenant on every Get")
+	}
+	if len(tenants) != 2 || tenants[0].ID != domain.DefaultTenantID || tenants[1].ID != "team-b" {
+		t.Errorf("Expected tenants [default team-b], got %d tenants", len(tenants))
+	}
+}
+
+// TestTenantRegistryRejectsInvalidID verifies that IDs unusable as partition names are refused.
+// Expected result: ErrInvalidTenant for a path-like and an uppercase ID.
+func TestTenantRegistryRejectsInvalidID(t *testing.T) {
+	// Arrange
+	registry := memoryTenants(t, TenantLimits{})
+
+	for _, id := range []string{"../etc", "Team-A", ""} {
+		// Act
+		_, err := registry.Get(id)
+
+		// Assert
+		if !errors.Is(err, domain.ErrInvalidTenant) {
+			t.Errorf("Expected ErrInvalidTenant for %q, got %v", id, err)
+		}
+	}
+}
+
+// TestTenantRegistryRetriesFailedOpen verifies that a tenant whose store failed to open is
+// opened again on the next request.
+// Expected result: first Get fails, second succeeds.
+func TestTenantRegistryRetriesFailedOpen(t *testing.T) {
+	// Arrange
+	attempts := 0
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	registry := NewTenantRegistry(ctx, func(string) (*TenantBackend, error) {
+		attempts++
+		if attempts == 1 {
+			return nil, errors.New("store unavailable")
+		}
+		eventStore, _ := infrastructure.NewKafkaEventStore(infrastructure.KafkaConfig{})
+		return &TenantBackend{EventStore: eventStore, Repository: infrastructure.NewRocketRepository(eventStore)}, nil
+	}, 1, TenantLimits{})
+
+	// Act
+	_, firstErr := registry.Get("team-a")
+	tenant, secondErr := registry.Get("team-a")
+
+	// Assert
+	if firstErr == nil {
+		t.Errorf("Expected the first open to fail")
+	}
+	if secondErr != nil || tenant == nil {
+		t.Errorf("Expected the second open to succeed, got %v", secondErr)
+	}
+}
+
+// TestTenantRegistryCapsTenants verifies that no tenant is opened beyond MaxTenants, and that
+// the tenants already open keep being served.
+// Expected result: the third tenant is refused with ErrTooManyTenants; team-a is still served.
+func TestTenantRegistryCapsTenants(t *testing.T) {
+	// Arrange
+	registry := memoryTenants(t, TenantLimits{MaxTenants: 2})
+	_, _ = registry.Get(domain.DefaultTenantID)
+	_, _ = registry.Get("team-a")
+
+	// Act
+	_, refused := registry.Get("team-b")
+	_, served := registry.Get("team-a")
+
+	// Assert
+	if !errors.Is(refused, ErrTooManyTenants) {
+		t.Errorf("Expected ErrTooManyTenants, got %v", refused)
+	}
+	if served != nil {
+		t.Errorf("Expected team-a to be served, got %v", served)
+	}
+	if tenants := registry.Tenants(); len(tenants) != 2 {
+		t.Errorf("Expected 2 open tenants, got %d", len(tenants))
+	}
+}
+
+// TestTenantRegistryClosesIdleTenants verifies that idle tenants are closed, but not the default
+// tenant, a tenant still held by a request or one with buffered messages, and that a closed
+// tenant frees its slot.
+// Expected result: only team-a is closed; team-c can then be opened under a cap of 4.
+func TestTenantRegistryClosesIdleTenants(t *testing.T) {
+	// Arrange
+	registry := memoryTenants(t, TenantLimits{MaxTenants: 4, IdleTimeout: time.Hour})
+	_, _ = registry.Get(domain.DefaultTenantID)
+	_, _ = registry.Get("team-a")
+	teamB, _ := registry.Get("team-b")
+	_ = teamB.Service.ProcessMessage(rocketMessage("rocket-1", 1))
+	_ = teamB.Service.ProcessMessage(rocketMessage("rocket-1", 3))
+	_, release, _ := registry.Acquire("team-h")
+	defer release()
+
+	// Act
+	early := registry.CloseIdle(time.Now())
+	closed := registry.CloseIdle(time.Now().Add(2 * time.Hour))
+	_, err := registry.Get("team-c")
+
+	// Assert
+	if early != 0 || closed != 1 {
+		t.Errorf("Expected nothing closed early and then one tenant, got %d and %d", early, closed)
+	}
+	var ids []string
+	for _, tenant := range registry.Tenants() {
+		ids = append(ids, tenant.ID)
+	}
+	if err != nil || len(ids) != 4 || ids[1] != "team-b" || ids[2] != "team-c" {
+		t.Errorf("Expected default, team-b, team-c and team-h open, got %v (%v)", ids, err)
+	}
+}
@@ -5,18 +5,28 @@ import (
 	"fmt"
 	"log/slog"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
+// workerQueueSize is how many jobs each worker can have waiting
+const workerQueueSize = 100
+
 // WorkerPool process messages concurrently with a fixed number of workers.
+// Jobs are routed to workers by a hash of their channel, so the messages of a channel are
+// handled by one worker in the order they were enqueued, while channels of different workers
+// are processed in parallel.
 type WorkerPool struct {
 	service     *RocketApplicationService
-	jobs        chan *ProcessMessageDTO
+	jobs        []chan *ProcessMessageDTO // one queue per worker
 	wg          sync.WaitGroup
 	workerCount int
 	ctx         context.Context // Context to manage shutdown
+	inFlight    atomic.Int64    // messages enqueued and not processed yet
 }
 
-// NewWorkerPool creates a pool with a fixed number of workers.
+// NewWorkerPool creates a pool with a fixed number of workers. Create the service
+// WithBufferShards(workerCount) so that the workers do not share reorder buffer shards.
 func NewWorkerPool(service *RocketApplicationService, workerCount int) *WorkerPool {
 	if service == nil {
 		panic("service cannot be nil")
@@ -24,9 +34,13 @@ func NewWorkerPool(service *RocketApplicationService, workerCount int) *WorkerPo
 	if workerCount <= 0 {
 		workerCount = 1
 	}
+	jobs := make([]chan *ProcessMessageDTO, workerCount)
+	for i := range jobs {
+		jobs[i] = make(chan *ProcessMessageDTO, workerQueueSize)
+	}
 	return &WorkerPool{
 		service:     service,
-		jobs:        make(chan *ProcessMessageDTO, 100),
+		jobs:        jobs,
 		workerCount: workerCount,
 	}
 }
@@ -36,17 +50,31 @@ func (p *WorkerPool) Start(ctx context.Context) {
 	p.ctx = ctx
 	slog.Debug("Workers started", "count", p.workerCount)
 
-	// Simulate redelivery loop (watchdog stub)
-	go func() {
-		slog.Debug("Message redelivery loop started")
-		<-ctx.Done()
-	}()
+	// Gap watchdog: applies the gap policies to buffers that wait too long for a message
+	if interval := p.service.gapCheckInterval(); interval > 0 {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			slog.Debug("Gap watchdog started", "interval", interval)
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					slog.Debug("Gap watchdog shutting down")
+					return
+				case now := <-ticker.C:
+					p.service.ResolveGaps(now)
+				}
+			}
+		}()
+	}
 
 	// Start workers
 	for i := 1; i <= p.workerCount; i++ {
 		// Launch worker goroutine
 		p.wg.Add(1)
-		go func(id int) {
+		go func(id int, jobs <-chan *ProcessMessageDTO) {
 			defer p.wg.Done()
 			slog.Debug("Worker started and waiting for jobs", "worker_id", id)
 			for {
@@ -54,7 +82,7 @@ func (p *WorkerPool) Start(ctx context.Context) {
 				case <-ctx.Done(): // Shutdown signal
 					slog.Debug("Worker shutting down", "worker_id", id)
 					return
-				case job, ok := <-p.jobs: // Receive job
+				case job, ok := <-jobs: // Receive job
 					if !ok {
 						slog.Debug("Job channel closed", "worker_id", id)
 						return
@@ -65,11 +93,14 @@ func (p *WorkerPool) Start(ctx context.Context) {
 						"number", job.Number,
 						"action", job.Action)
 
-					if err := p.service.ProcessMessage(job); err != nil {
+					err := p.service.ProcessMessage(job)
+					p.inFlight.Add(-1)
+					if err != nil {
 						slog.Error("Error processing message",
 							"worker_id", id,
 							"channel", job.Channel,
 							"number", job.Number,
+							"reason", RejectionReason(err),
 							"err", err)
 					} else {
 						slog.Info("Message processed successfully",
@@ -79,11 +110,12 @@ func (p *WorkerPool) Start(ctx context.Context) {
 					}
 				}
 			}
-		}(i)
+		}(i, p.jobs[i-1])
 	}
 }
 
-// Enqueue adds a message to the queue.
+// Enqueue adds a message to the queue of the worker that owns its channel. It returns
+// ErrBufferFull or ErrGapTooLarge for a message the reorder buffer would refuse.
 func (p *WorkerPool) Enqueue(dto *ProcessMessageDTO) error {
 	if dto == nil {
 		return fmt.Errorf("message DTO cannot be nil")
@@ -95,14 +127,26 @@ func (p *WorkerPool) Enqueue(dto *ProcessMessageDTO) error {
 	default:
 	}
 
+	// Refused messages are reported to the producer instead of being dropped later
+	if err := p.service.Admit(dto); err != nil {
+		return err
+	}
+
+	p.inFlight.Add(1)
 	select {
-	case p.jobs <- dto:
+	case p.jobs[shardIndex(dto.Channel, p.workerCount)] <- dto:
 		return nil
 	case <-p.ctx.Done():
+		p.inFlight.Add(-1)
 		return fmt.Errorf("worker pool stopped")
 	}
 }
 
+// Idle reports whether no message is queued or being processed
+func (p *WorkerPool) Idle() bool {
+	return p.inFlight.Load() == 0
+}
+
 // Wait waits for all workers to finish.
 // This should be called after cancelling the context to ensure graceful shutdown.
 func (p *WorkerPool) Wait() {
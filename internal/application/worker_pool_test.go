@@ -0,0 +1,172 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"rockets/internal/domain"
+	"rockets/internal/infrastructure"
+)
+
+// slowStore delays every append like a store that waits for a disk or a broker, and reports
+// the channel of every committed batch
+type slowStore struct {
+	domain.EventStore
+	delay    time.Duration
+	gate     func(channel string) // optional, called before each append
+	appended chan string
+}
+
+func (s *slowStore) AppendEvents(channel *domain.Channel, expectedVersion int, events []domain.DomainEvent) error {
+	if s.gate != nil {
+		s.gate(channel.Value())
+	}
+	time.Sleep(s.delay)
+	if err := s.EventStore.AppendEvents(channel, expectedVersion, events); err != nil {
+		return err
+	}
+	s.appended <- channel.Value()
+	return nil
+}
+
+// setupPool starts a pool of workers over a service whose buffer has one shard per worker
+func setupPool(t testing.TB, store *slowStore, workers int, opts ...ServiceOption) (*WorkerPool, *RocketApplicationService) {
+	t.Helper()
+	inner, err := infrastructure.NewKafkaEventStore(infrastructure.KafkaConfig{})
+	if err != nil {
+		t.Fatalf("Expected no error creating store, got %v", err)
+	}
+	store.EventStore = inner
+	opts = append(opts, WithBufferShards(workers))
+	service := NewRocketApplicationService(infrastructure.NewRocketRepository(store), store, opts...)
+	ctx, cancel := context.WithCancel(context.Background())
+	pool := NewWorkerPool(service, workers)
+	pool.Start(ctx)
+	t.Cleanup(func() {
+		cancel()
+		pool.Wait()
+	})
+	return pool, service
+}
+
+// rocketMessage builds message number n of a channel: a launch first, speed increases after
+func rocketMessage(channel string, n int) *ProcessMessageDTO {
+	if n == 1 {
+		return &ProcessMessageDTO{Channel: channel, Number: 1, Action: "launch", RocketType: "Falcon-9", Value: 10000, Param: "exploration", Time: 1000}
+	}
+	return &ProcessMessageDTO{Channel: channel, Number: n, Action: "increase_speed", Value: 100, Time: int64(1000 + n)}
+}
+
+// TestWorkerPoolProcessesChannelsInParallel verifies that a slow channel does not hold up the
+// channels of other workers, and that each channel stays in order.
+// Expected result: rocket-b is saved while rocket-a is blocked; rocket-a then applies all its
+// messages in order without buffering or rejecting any.
+func TestWorkerPoolProcessesChannelsInParallel(t *testing.T) {
+	// Arrange
+	channelA, channelB := "rocket-a", ""
+	for i := 0; channelB == ""; i++ {
+		if candidate := fmt.Sprintf("rocket-%d", i); shardIndex(candidate, 2) != shardIndex(channelA, 2) {
+			channelB = candidate
+		}
+	}
+	release := make(chan struct{})
+	store := &slowStore{appended: make(chan string, 16), gate: func(channel string) {
+		if channel == channelA {
+			<-release
+		}
+	}}
+	pool, service := setupPool(t, store, 2)
+
+	// Act
+	for n := 1; n <= 5; n++ {
+		_ = pool.Enqueue(rocketMessage(channelA, n))
+	}
+	_ = pool.Enqueue(rocketMessage(channelB, 1))
+
+	// Assert
+	select {
+	case channel := <-store.appended:
+		if channel != channelB {
+			t.Fatalf("Expected %s to be saved first, got %s", channelB, channel)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Expected %s to be saved while %s is blocked", channelB, channelA)
+	}
+	close(release)
+	for n := 1; n <= 5; n++ {
+		select {
+		case <-store.appended:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Expected 5 saves of %s, got %d", channelA, n-1)
+		}
+	}
+	rocket, err := service.GetRocket(channelA)
+	if err != nil || rocket.Speed != 10400 {
+		t.Errorf("Expected %s at speed 10400, got %+v (err %v)", channelA, rocket, err)
+	}
+	if buffered := service.GetBufferStatus(); len(buffered) != 0 {
+		t.Errorf("Expected nothing buffered, got %+v", buffered)
+	}
+	if rejected := service.GetDeadLetters(); len(rejected) != 0 {
+		t.Errorf("Expected no rejected messages, got %+v", rejected)
+	}
+}
+
+// TestWorkerPoolResolvesGaps verifies that the pool's watchdog applies the gap policy without
+// any further message arriving.
+// Expected result: the gap before message 3 is skipped after its 20ms timeout, then message 3
+// is saved.
+func TestWorkerPoolResolvesGaps(t *testing.T) {
+	// Arrange
+	store := &slowStore{appended: make(chan string, 16)}
+	pool, service := setupPool(t, store, 2, WithGapPolicies(GapPolicies{Default: GapPolicy{Timeout: 20 * time.Millisecond, Action: GapSkip}}))
+
+	// Act
+	_ = pool.Enqueue(rocketMessage("rocket-1", 1))
+	_ = pool.Enqueue(rocketMessage("rocket-1", 3))
+
+	// Assert
+	for saves := 0; saves < 3; saves++ {
+		select {
+		case <-store.appended:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Expected the launch, the skip and message 3 to be saved, got %d saves", saves)
+		}
+	}
+	events, _ := service.ListEvents("rocket-1")
+	if len(events) != 3 || events[1].Type != "messages_skipped" || events[2].MessageNumber != 3 {
+		t.Errorf("Expected message 2 skipped before message 3, got %+v", events)
+	}
+}
+
+// BenchmarkWorkerPool measures the throughput of the pool for growing WORKER_COUNT, over a
+// store that takes 200µs per append. Messages are spread over 64 channels.
+func BenchmarkWorkerPool(b *testing.B) {
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	b.Cleanup(func() { slog.SetDefault(defaultLogger) })
+
+	const channels = 64
+	for _, workers := range []int{1, 2, 4, 8, 16} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			store := &slowStore{delay: 200 * time.Microsecond, appended: make(chan string, 1024)}
+			pool, _ := setupPool(b, store, workers)
+
+			b.ResetTimer()
+			go func() {
+				for i := 0; i < b.N; i++ {
+					_ = pool.Enqueue(rocketMessage(fmt.Sprintf("rocket-%d", i%channels), i/channels+1))
+				}
+			}()
+			for i := 0; i < b.N; i++ {
+				<-store.appended
+			}
+			b.StopTimer()
+			b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "msgs/s")
+		})
+	}
+}
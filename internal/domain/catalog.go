@@ -0,0 +1,151 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EnvelopePolicy decides what happens to a command that exceeds the envelope of its rocket type
+type EnvelopePolicy string
+
+const (
+	// EnvelopeReject refuses the command; only a RocketEnvelopeExceeded event is recorded
+	EnvelopeReject EnvelopePolicy = "reject"
+	// EnvelopeWarn applies the command and records a RocketEnvelopeExceeded event next to it
+	EnvelopeWarn EnvelopePolicy = "warn"
+)
+
+// Envelope rules a command can break
+const (
+	EnvelopeRuleMaxSpeed = "max_speed"
+	EnvelopeRuleStages   = "stages"
+	EnvelopeRuleMission  = "mission"
+)
+
+// RocketTypeSpec is the flight envelope of a rocket type
+type RocketTypeSpec struct {
+	Name            string    // as reported in RocketLaunched
+	MaxSpeed        int       // km/h, 0 means no limit
+	Stages          int       // stages at launch, 0 means unknown
+	AllowedMissions []Mission // empty means any mission
+}
+
+// allowsMission reports whether the type may fly mission
+func (s *RocketTypeSpec) allowsMission(mission Mission) bool {
+	if len(s.AllowedMissions) == 0 {
+		return true
+	}
+	for _, allowed := range s.AllowedMissions {
+		if allowed == mission {
+			return true
+		}
+	}
+	return false
+}
+
+// RocketCatalog holds the known rocket types. Rockets of a type that is not in the catalog
+// are not checked.
+type RocketCatalog struct {
+	policy EnvelopePolicy
+	types  map[string]*RocketTypeSpec // by lower-case name
+}
+
+// NewRocketCatalog creates a catalog of specs that handles violations with policy
+// (EnvelopeReject when empty)
+func NewRocketCatalog(policy EnvelopePolicy, specs ...RocketTypeSpec) (*RocketCatalog, error) {
+	switch policy {
+	case "":
+		policy = EnvelopeReject
+	case EnvelopeReject, EnvelopeWarn:
+	default:
+		return nil, fmt.Errorf("unknown envelope policy %q (want %q or %q)", policy, EnvelopeReject, EnvelopeWarn)
+	}
+
+	c := &RocketCatalog{policy: policy, types: make(map[string]*RocketTypeSpec, len(specs))}
+	for _, spec := range specs {
+		key := strings.ToLower(strings.TrimSpace(spec.Name))
+		if key == "" {
+			return nil, fmt.Errorf("rocket type name cannot be empty")
+		}
+		if _, ok := c.types[key]; ok {
+			return nil, fmt.Errorf("rocket type %q is defined twice", spec.Name)
+		}
+		if spec.MaxSpeed < 0 || spec.Stages < 0 {
+			return nil, fmt.Errorf("rocket type %q: max speed and stages cannot be negative", spec.Name)
+		}
+		spec.AllowedMissions = append([]Mission{}, spec.AllowedMissions...)
+		c.types[key] = &spec
+	}
+	return c, nil
+}
+
+// Policy returns how violations are handled
+func (c *RocketCatalog) Policy() EnvelopePolicy {
+	return c.policy
+}
+
+// Lookup returns the spec of a rocket type (case-insensitive)
+func (c *RocketCatalog) Lookup(rocketType string) (*RocketTypeSpec, bool) {
+	if c == nil {
+		return nil, false
+	}
+	spec, ok := c.types[strings.ToLower(strings.TrimSpace(rocketType))]
+	return spec, ok
+}
+
+// Len returns the number of rocket types in the catalog
+func (c *RocketCatalog) Len() int {
+	if c == nil {
+		return 0
+	}
+	return len(c.types)
+}
+
+// EnvelopeViolation describes how a command exceeds the envelope of its rocket type
+type EnvelopeViolation struct {
+	Rule   string
+	Detail string
+}
+
+// checkSpeed reports a speed above the type's max speed (the check* methods accept a nil spec:
+// rockets of types outside the catalog are not checked)
+func (s *RocketTypeSpec) checkSpeed(speed int) *EnvelopeViolation {
+	if s == nil {
+		return nil
+	}
+	if s.MaxSpeed > 0 && speed > s.MaxSpeed {
+		return &EnvelopeViolation{
+			Rule:   EnvelopeRuleMaxSpeed,
+			Detail: fmt.Sprintf("speed %d above max speed %d of %s", speed, s.MaxSpeed, s.Name),
+		}
+	}
+	return nil
+}
+
+// checkMission reports a mission the type may not fly
+func (s *RocketTypeSpec) checkMission(mission Mission) *EnvelopeViolation {
+	if s == nil {
+		return nil
+	}
+	if !s.allowsMission(mission) {
+		return &EnvelopeViolation{
+			Rule:   EnvelopeRuleMission,
+			Detail: fmt.Sprintf("mission %s not allowed for %s", mission, s.Name),
+		}
+	}
+	return nil
+}
+
+// checkStages reports more remaining stages than the type has
+func (s *RocketTypeSpec) checkStages(remaining int) *EnvelopeViolation {
+	if s == nil {
+		return nil
+	}
+	if s.Stages > 0 && remaining >= s.Stages {
+		return &EnvelopeViolation{
+			Rule:   EnvelopeRuleStages,
+			Detail: fmt.Sprintf("%d remaining stages but %s has %d", remaining, s.Name, s.Stages),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Reasons a rocket rejects a command, matched with errors.Is
+var (
+	ErrAlreadyLaunched   = errors.New("rocket already launched")
+	ErrRocketExploded    = errors.New("rocket exploded")
+	ErrNotLaunched       = errors.New("rocket not launched")
+	ErrInvalidTransition = errors.New("invalid transition")
+	ErrOutOfOrder        = errors.New("message number out of order")
+	ErrInvalidPayload    = errors.New("invalid payload")
+	ErrUnknownAction     = errors.New("unknown action")
+	ErrUnknownMission    = errors.New("unknown mission")
+	ErrStageCount        = errors.New("stage count can only go down")
+	ErrEnvelopeExceeded  = errors.New("rocket type envelope exceeded")
+	ErrTimeRegression    = errors.New("message time went back")
+	ErrChannelErased     = errors.New("channel erased")
+)
+
+// CommandError reports a command rejected by a rocket.
+// It unwraps to the reason, so errors.Is(err, ErrRocketExploded) and the like work.
+type CommandError struct {
+	Channel       string
+	Command       RocketCommand
+	MessageNumber int
+	Status        RocketStatus // status of the rocket when the command was rejected
+	Err           error
+}
+
+func (e *CommandError) Error() string {
+	return fmt.Sprintf("%s on channel %s (message %d, status %s): %v", e.Command, e.Channel, e.MessageNumber, e.Status, e.Err)
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
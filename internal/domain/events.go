@@ -6,6 +6,7 @@ type DomainEvent interface {
 	GetChannel() *Channel
 	GetMessageNumber() *MessageNumber
 	GetTimestamp() int64
+	GetMetadata() EventMetadata
 }
 
 // RocketLaunched event when a rocket is launched
@@ -15,13 +16,17 @@ type RocketLaunched struct {
 	Type          string
 	Speed         *Speed
 	Mission       Mission
+	RawMission    string // mission as reported by the producer, empty for older events
+	Stages        int    // stages of the type in the catalog, 0 when unknown
 	Timestamp     int64
+	Metadata      EventMetadata
 }
 
 func (e *RocketLaunched) GetEventType() string             { return "rocket_launched" }
 func (e *RocketLaunched) GetChannel() *Channel             { return e.Channel }
 func (e *RocketLaunched) GetMessageNumber() *MessageNumber { return e.MessageNumber }
 func (e *RocketLaunched) GetTimestamp() int64              { return e.Timestamp }
+func (e *RocketLaunched) GetMetadata() EventMetadata       { return e.Metadata.Copy() }
 
 // RocketSpeedIncreased event when speed increases
 type RocketSpeedIncreased struct {
@@ -31,12 +36,14 @@ type RocketSpeedIncreased struct {
 	NewSpeed      *Speed
 	Delta         int
 	Timestamp     int64
+	Metadata      EventMetadata
 }
 
 func (e *RocketSpeedIncreased) GetEventType() string             { return "rocket_speed_increased" }
 func (e *RocketSpeedIncreased) GetChannel() *Channel             { return e.Channel }
 func (e *RocketSpeedIncreased) GetMessageNumber() *MessageNumber { return e.MessageNumber }
 func (e *RocketSpeedIncreased) GetTimestamp() int64              { return e.Timestamp }
+func (e *RocketSpeedIncreased) GetMetadata() EventMetadata       { return e.Metadata.Copy() }
 
 // RocketSpeedDecreased event when speed decreases
 type RocketSpeedDecreased struct {
@@ -46,12 +53,14 @@ type RocketSpeedDecreased struct {
 	NewSpeed      *Speed
 	Delta         int
 	Timestamp     int64
+	Metadata      EventMetadata
 }
 
 func (e *RocketSpeedDecreased) GetEventType() string             { return "rocket_speed_decreased" }
 func (e *RocketSpeedDecreased) GetChannel() *Channel             { return e.Channel }
 func (e *RocketSpeedDecreased) GetMessageNumber() *MessageNumber { return e.MessageNumber }
 func (e *RocketSpeedDecreased) GetTimestamp() int64              { return e.Timestamp }
+func (e *RocketSpeedDecreased) GetMetadata() EventMetadata       { return e.Metadata.Copy() }
 
 // RocketExploded event when rocket explodes
 type RocketExploded struct {
@@ -59,12 +68,14 @@ type RocketExploded struct {
 	MessageNumber *MessageNumber
 	Reason        string
 	Timestamp     int64
+	Metadata      EventMetadata
 }
 
 func (e *RocketExploded) GetEventType() string             { return "rocket_exploded" }
 func (e *RocketExploded) GetChannel() *Channel             { return e.Channel }
 func (e *RocketExploded) GetMessageNumber() *MessageNumber { return e.MessageNumber }
 func (e *RocketExploded) GetTimestamp() int64              { return e.Timestamp }
+func (e *RocketExploded) GetMetadata() EventMetadata       { return e.Metadata.Copy() }
 
 // RocketMissionChanged event when mission changes
 type RocketMissionChanged struct {
@@ -72,10 +83,165 @@ type RocketMissionChanged struct {
 	MessageNumber *MessageNumber
 	OldMission    Mission
 	NewMission    Mission
+	RawMission    string // new mission as reported by the producer, empty for older events
 	Timestamp     int64
+	Metadata      EventMetadata
 }
 
 func (e *RocketMissionChanged) GetEventType() string             { return "rocket_mission_changed" }
 func (e *RocketMissionChanged) GetChannel() *Channel             { return e.Channel }
 func (e *RocketMissionChanged) GetMessageNumber() *MessageNumber { return e.MessageNumber }
 func (e *RocketMissionChanged) GetTimestamp() int64              { return e.Timestamp }
+func (e *RocketMissionChanged) GetMetadata() EventMetadata       { return e.Metadata.Copy() }
+
+// RocketLanded event when a rocket lands (and can be launched again)
+type RocketLanded struct {
+	Channel       *Channel
+	MessageNumber *MessageNumber
+	Site          string
+	Timestamp     int64
+	Metadata      EventMetadata
+}
+
+func (e *RocketLanded) GetEventType() string             { return "rocket_landed" }
+func (e *RocketLanded) GetChannel() *Channel             { return e.Channel }
+func (e *RocketLanded) GetMessageNumber() *MessageNumber { return e.MessageNumber }
+func (e *RocketLanded) GetTimestamp() int64              { return e.Timestamp }
+func (e *RocketLanded) GetMetadata() EventMetadata       { return e.Metadata.Copy() }
+
+// RocketRefueled event when fuel is loaded into a rocket on the ground
+type RocketRefueled struct {
+	Channel       *Channel
+	MessageNumber *MessageNumber
+	Amount        int
+	Fuel          int // fuel on board after refueling
+	Timestamp     int64
+	Metadata      EventMetadata
+}
+
+func (e *RocketRefueled) GetEventType() string             { return "rocket_refueled" }
+func (e *RocketRefueled) GetChannel() *Channel             { return e.Channel }
+func (e *RocketRefueled) GetMessageNumber() *MessageNumber { return e.MessageNumber }
+func (e *RocketRefueled) GetTimestamp() int64              { return e.Timestamp }
+func (e *RocketRefueled) GetMetadata() EventMetadata       { return e.Metadata.Copy() }
+
+// RocketStageSeparated event when a stage of a multi-stage rocket separates
+type RocketStageSeparated struct {
+	Channel         *Channel
+	MessageNumber   *MessageNumber
+	RemainingStages int
+	Timestamp       int64
+	Metadata        EventMetadata
+}
+
+func (e *RocketStageSeparated) GetEventType() string             { return "rocket_stage_separated" }
+func (e *RocketStageSeparated) GetChannel() *Channel             { return e.Channel }
+func (e *RocketStageSeparated) GetMessageNumber() *MessageNumber { return e.MessageNumber }
+func (e *RocketStageSeparated) GetTimestamp() int64              { return e.Timestamp }
+func (e *RocketStageSeparated) GetMetadata() EventMetadata       { return e.Metadata.Copy() }
+
+// RocketEnvelopeExceeded event when a command exceeds the envelope of the rocket's type
+// (see RocketCatalog). A rejected command raises only this event, which takes up its message
+// number; an applied one raises it after the command's own event, as a warning.
+type RocketEnvelopeExceeded struct {
+	Channel       *Channel
+	MessageNumber *MessageNumber
+	Command       RocketCommand
+	RocketType    string
+	Rule          string
+	Detail        string
+	Rejected      bool
+	Timestamp     int64
+	Metadata      EventMetadata
+}
+
+func (e *RocketEnvelopeExceeded) GetEventType() string             { return "rocket_envelope_exceeded" }
+func (e *RocketEnvelopeExceeded) GetChannel() *Channel             { return e.Channel }
+func (e *RocketEnvelopeExceeded) GetMessageNumber() *MessageNumber { return e.MessageNumber }
+func (e *RocketEnvelopeExceeded) GetTimestamp() int64              { return e.Timestamp }
+func (e *RocketEnvelopeExceeded) GetMetadata() EventMetadata       { return e.Metadata.Copy() }
+
+// RocketTimeRegressed event when a message's time is before the latest message time of its
+// channel by more than the tolerance (see TimePolicy). Like RocketEnvelopeExceeded, it is
+// recorded on its own for a rejected message and after the command's event otherwise.
+type RocketTimeRegressed struct {
+	Channel       *Channel
+	MessageNumber *MessageNumber
+	Command       RocketCommand
+	PreviousTime  int64 // latest message time before this message (Unix milliseconds)
+	Rejected      bool
+	Timestamp     int64 // time of the message
+	Metadata      EventMetadata
+}
+
+func (e *RocketTimeRegressed) GetEventType() string             { return "rocket_time_regressed" }
+func (e *RocketTimeRegressed) GetChannel() *Channel             { return e.Channel }
+func (e *RocketTimeRegressed) GetMessageNumber() *MessageNumber { return e.MessageNumber }
+func (e *RocketTimeRegressed) GetTimestamp() int64              { return e.Timestamp }
+func (e *RocketTimeRegressed) GetMetadata() EventMetadata       { return e.Metadata.Copy() }
+
+// Regression returns how far the message time went back, in milliseconds
+func (e *RocketTimeRegressed) Regression() int64 {
+	return e.PreviousTime - e.Timestamp
+}
+
+// MessagesSkipped records that messages From..MessageNumber of a channel never arrived and
+// were given up on, so the messages after them can be applied
+type MessagesSkipped struct {
+	Channel       *Channel
+	MessageNumber *MessageNumber // last skipped message
+	From          int            // first skipped message
+	Reason        string
+	Timestamp     int64 // when the messages were skipped
+	Metadata      EventMetadata
+}
+
+func (e *MessagesSkipped) GetEventType() string             { return "messages_skipped" }
+func (e *MessagesSkipped) GetChannel() *Channel             { return e.Channel }
+func (e *MessagesSkipped) GetMessageNumber() *MessageNumber { return e.MessageNumber }
+func (e *MessagesSkipped) GetTimestamp() int64              { return e.Timestamp }
+func (e *MessagesSkipped) GetMetadata() EventMetadata       { return e.Metadata.Copy() }
+
+// ChannelErased is the tombstone of an erased channel: the events before it are redacted and
+// the rocket is forgotten. It takes the number of the last message of the channel, as the
+// erasure is no producer message, and carries no customer data.
+type ChannelErased struct {
+	Channel       *Channel
+	MessageNumber *MessageNumber
+	Reason        string
+	Timestamp     int64
+	Metadata      EventMetadata
+}
+
+func (e *ChannelErased) GetEventType() string             { return "channel_erased" }
+func (e *ChannelErased) GetChannel() *Channel             { return e.Channel }
+func (e *ChannelErased) GetMessageNumber() *MessageNumber { return e.MessageNumber }
+func (e *ChannelErased) GetTimestamp() int64              { return e.Timestamp }
+func (e *ChannelErased) GetMetadata() EventMetadata       { return e.Metadata.Copy() }
+
+// RedactedEvent stands in for an event of an erased channel: only its type and position are
+// left. Its timestamp and metadata (correlation, source, reception time...) are erased with
+// the payload.
+type RedactedEvent struct {
+	Type          string // type of the original event
+	Channel       *Channel
+	MessageNumber *MessageNumber
+}
+
+func (e *RedactedEvent) GetEventType() string             { return e.Type }
+func (e *RedactedEvent) GetChannel() *Channel             { return e.Channel }
+func (e *RedactedEvent) GetMessageNumber() *MessageNumber { return e.MessageNumber }
+func (e *RedactedEvent) GetTimestamp() int64              { return 0 }
+func (e *RedactedEvent) GetMetadata() EventMetadata       { return nil }
+
+// Redact returns the redacted form of an event
+func Redact(event DomainEvent) *RedactedEvent {
+	if redacted, ok := event.(*RedactedEvent); ok {
+		return redacted
+	}
+	return &RedactedEvent{
+		Type:          event.GetEventType(),
+		Channel:       event.GetChannel(),
+		MessageNumber: event.GetMessageNumber(),
+	}
+}
@@ -0,0 +1,249 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// FleetStreamPrefix starts the channel of every fleet stream. Fleet streams are kept apart
+// from rocket channels by the FleetRepository, so the prefix only labels their events.
+const FleetStreamPrefix = "fleet:"
+
+// Reasons a fleet rejects a command, matched with errors.Is
+var (
+	ErrInvalidFleet       = errors.New("invalid fleet")
+	ErrFleetNotFound      = errors.New("fleet not found")
+	ErrFleetExists        = errors.New("fleet already exists")
+	ErrAlreadyFleetMember = errors.New("rocket already in fleet")
+	ErrNotFleetMember     = errors.New("rocket not in fleet")
+)
+
+// fleetIDPattern keeps fleet IDs readable in URLs and stream names
+var fleetIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,63}$`)
+
+// FleetCreated event when a fleet is created
+type FleetCreated struct {
+	Channel       *Channel // stream of the fleet
+	MessageNumber *MessageNumber
+	FleetID       string
+	Name          string
+	Timestamp     int64
+	Metadata      EventMetadata
+}
+
+func (e *FleetCreated) GetEventType() string             { return "fleet_created" }
+func (e *FleetCreated) GetChannel() *Channel             { return e.Channel }
+func (e *FleetCreated) GetMessageNumber() *MessageNumber { return e.MessageNumber }
+func (e *FleetCreated) GetTimestamp() int64              { return e.Timestamp }
+func (e *FleetCreated) GetMetadata() EventMetadata       { return e.Metadata.Copy() }
+
+// FleetRocketAdded event when a rocket joins a fleet
+type FleetRocketAdded struct {
+	Channel       *Channel // stream of the fleet
+	MessageNumber *MessageNumber
+	Rocket        string // channel of the rocket
+	Timestamp     int64
+	Metadata      EventMetadata
+}
+
+func (e *FleetRocketAdded) GetEventType() string             { return "fleet_rocket_added" }
+func (e *FleetRocketAdded) GetChannel() *Channel             { return e.Channel }
+func (e *FleetRocketAdded) GetMessageNumber() *MessageNumber { return e.MessageNumber }
+func (e *FleetRocketAdded) GetTimestamp() int64              { return e.Timestamp }
+func (e *FleetRocketAdded) GetMetadata() EventMetadata       { return e.Metadata.Copy() }
+
+// FleetRocketRemoved event when a rocket leaves a fleet
+type FleetRocketRemoved struct {
+	Channel       *Channel // stream of the fleet
+	MessageNumber *MessageNumber
+	Rocket        string // channel of the rocket
+	Timestamp     int64
+	Metadata      EventMetadata
+}
+
+func (e *FleetRocketRemoved) GetEventType() string             { return "fleet_rocket_removed" }
+func (e *FleetRocketRemoved) GetChannel() *Channel             { return e.Channel }
+func (e *FleetRocketRemoved) GetMessageNumber() *MessageNumber { return e.MessageNumber }
+func (e *FleetRocketRemoved) GetTimestamp() int64              { return e.Timestamp }
+func (e *FleetRocketRemoved) GetMetadata() EventMetadata       { return e.Metadata.Copy() }
+
+// Fleet groups rockets that are operated together. It has its own stream, where events are
+// numbered in sequence; the rockets it names do not have to exist yet.
+type Fleet struct {
+	id                string
+	stream            *Channel
+	name              string
+	createdAt         int64
+	members           []string // rocket channels, in the order they joined
+	version           int      // number of events applied
+	uncommittedEvents []DomainEvent
+}
+
+// NewFleet creates an empty fleet aggregate
+func NewFleet(id string) (*Fleet, error) {
+	if !fleetIDPattern.MatchString(id) {
+		return nil, fmt.Errorf("%w: %q (use 1-64 letters, digits, '.', '-' or '_')", ErrInvalidFleet, id)
+	}
+	stream, err := NewChannel(FleetStreamPrefix + id)
+	if err != nil {
+		return nil, err
+	}
+	return &Fleet{id: id, stream: stream}, nil
+}
+
+// Create creates the fleet
+func (f *Fleet) Create(name string, timestamp int64) error {
+	if f.Exists() {
+		return fmt.Errorf("%w: %s", ErrFleetExists, f.id)
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = f.id
+	}
+	f.raise(&FleetCreated{
+		Channel:       f.stream,
+		MessageNumber: f.nextNumber(),
+		FleetID:       f.id,
+		Name:          name,
+		Timestamp:     timestamp,
+		Metadata:      newFleetEventMetadata(),
+	})
+	return nil
+}
+
+// AddRocket adds the rocket of a channel to the fleet
+func (f *Fleet) AddRocket(channel *Channel, timestamp int64) error {
+	if !f.Exists() {
+		return fmt.Errorf("%w: %s", ErrFleetNotFound, f.id)
+	}
+	if f.HasMember(channel.Value()) {
+		return fmt.Errorf("%w: %s is already in fleet %s", ErrAlreadyFleetMember, channel.Value(), f.id)
+	}
+	f.raise(&FleetRocketAdded{
+		Channel:       f.stream,
+		MessageNumber: f.nextNumber(),
+		Rocket:        channel.Value(),
+		Timestamp:     timestamp,
+		Metadata:      newFleetEventMetadata(),
+	})
+	return nil
+}
+
+// RemoveRocket removes the rocket of a channel from the fleet
+func (f *Fleet) RemoveRocket(channel *Channel, timestamp int64) error {
+	if !f.Exists() {
+		return fmt.Errorf("%w: %s", ErrFleetNotFound, f.id)
+	}
+	if !f.HasMember(channel.Value()) {
+		return fmt.Errorf("%w: %s is not in fleet %s", ErrNotFleetMember, channel.Value(), f.id)
+	}
+	f.raise(&FleetRocketRemoved{
+		Channel:       f.stream,
+		MessageNumber: f.nextNumber(),
+		Rocket:        channel.Value(),
+		Timestamp:     timestamp,
+		Metadata:      newFleetEventMetadata(),
+	})
+	return nil
+}
+
+// nextNumber numbers the next event of the stream
+func (f *Fleet) nextNumber() *MessageNumber {
+	return &MessageNumber{value: f.version + 1}
+}
+
+// newFleetEventMetadata builds the metadata of a new fleet event
+func newFleetEventMetadata() EventMetadata {
+	return EventMetadata{MetadataEventID: NewID()}
+}
+
+// raise applies a new event and keeps it until it is committed
+func (f *Fleet) raise(event DomainEvent) {
+	f.applyEvent(event)
+	f.uncommittedEvents = append(f.uncommittedEvents, event)
+}
+
+// LoadFromHistory reconstructs the state from the event history
+func (f *Fleet) LoadFromHistory(events []DomainEvent) {
+	for _, event := range events {
+		f.applyEvent(event)
+	}
+}
+
+// applyEvent applies an event to the internal state
+func (f *Fleet) applyEvent(event DomainEvent) {
+	switch e := event.(type) {
+	case *FleetCreated:
+		f.name = e.Name
+		f.createdAt = e.Timestamp
+
+	case *FleetRocketAdded:
+		f.members = append(f.members, e.Rocket)
+
+	case *FleetRocketRemoved:
+		for i, member := range f.members {
+			if member == e.Rocket {
+				f.members = append(f.members[:i:i], f.members[i+1:]...)
+				break
+			}
+		}
+	}
+	f.version++
+}
+
+// GetUncommittedEvents returns the events not yet persisted
+func (f *Fleet) GetUncommittedEvents() []DomainEvent {
+	return f.uncommittedEvents
+}
+
+// MarkEventsAsCommitted marks events as committed
+func (f *Fleet) MarkEventsAsCommitted() {
+	f.uncommittedEvents = nil
+}
+
+// GetID returns the fleet's ID
+func (f *Fleet) GetID() string {
+	return f.id
+}
+
+// GetStream returns the channel of the fleet's stream
+func (f *Fleet) GetStream() *Channel {
+	return f.stream
+}
+
+// GetName returns the fleet's name
+func (f *Fleet) GetName() string {
+	return f.name
+}
+
+// GetCreatedAt returns when the fleet was created (Unix milliseconds)
+func (f *Fleet) GetCreatedAt() int64 {
+	return f.createdAt
+}
+
+// GetMembers returns the channels of the fleet's rockets, in the order they joined
+func (f *Fleet) GetMembers() []string {
+	return append([]string(nil), f.members...)
+}
+
+// HasMember reports whether the rocket of channel is in the fleet
+func (f *Fleet) HasMember(channel string) bool {
+	for _, member := range f.members {
+		if member == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// Exists reports whether the fleet has been created
+func (f *Fleet) Exists() bool {
+	return f.version > 0
+}
+
+// GetVersion returns the number of events of the fleet, committed or not
+func (f *Fleet) GetVersion() int {
+	return f.version
+}
@@ -0,0 +1,83 @@
+package domain
+
+import "fmt"
+
+// RocketCommand names a command of the rocket aggregate
+type RocketCommand string
+
+const (
+	CommandLaunch        RocketCommand = "launch"
+	CommandIncreaseSpeed RocketCommand = "increase_speed"
+	CommandDecreaseSpeed RocketCommand = "decrease_speed"
+	CommandChangeMission RocketCommand = "change_mission"
+	CommandExplode       RocketCommand = "explode"
+	CommandLand          RocketCommand = "land"
+	CommandRefuel        RocketCommand = "refuel"
+	CommandSeparateStage RocketCommand = "separate_stage"
+	CommandErase         RocketCommand = "erase"
+	CommandSkipMessages  RocketCommand = "skip_messages"
+)
+
+// rocketTransitions is the lifecycle of a rocket: for every status, the commands it accepts
+// and the status each one leads to. A command missing from a status is rejected.
+// Every channel can be erased, after which it accepts nothing. Lost messages can be skipped
+// in any other status, which does not change it.
+var rocketTransitions = map[RocketStatus]map[RocketCommand]RocketStatus{
+	StatusNotLaunched: {
+		CommandLaunch:       StatusFlying,
+		CommandRefuel:       StatusNotLaunched,
+		CommandErase:        StatusErased,
+		CommandSkipMessages: StatusNotLaunched,
+	},
+	StatusFlying: {
+		CommandIncreaseSpeed: StatusFlying,
+		CommandDecreaseSpeed: StatusFlying,
+		CommandChangeMission: StatusFlying,
+		CommandSeparateStage: StatusFlying,
+		CommandLand:          StatusLanded,
+		CommandExplode:       StatusExploded,
+		CommandErase:         StatusErased,
+		CommandSkipMessages:  StatusFlying,
+	},
+	// Reusable rockets can be refueled and launched again
+	StatusLanded: {
+		CommandLaunch:        StatusFlying,
+		CommandRefuel:        StatusLanded,
+		CommandChangeMission: StatusLanded,
+		CommandExplode:       StatusExploded,
+		CommandErase:         StatusErased,
+		CommandSkipMessages:  StatusLanded,
+	},
+	StatusExploded: {
+		CommandErase:        StatusErased,
+		CommandSkipMessages: StatusExploded,
+	},
+	StatusErased: {},
+}
+
+// IsKnown reports whether the status is part of the lifecycle
+func (s RocketStatus) IsKnown() bool {
+	_, ok := rocketTransitions[s]
+	return ok
+}
+
+// Next returns the status reached by executing command in status s
+func (s RocketStatus) Next(command RocketCommand) (RocketStatus, error) {
+	if next, ok := rocketTransitions[s][command]; ok {
+		return next, nil
+	}
+	switch {
+	case s == StatusErased:
+		return s, fmt.Errorf("%w: cannot %s", ErrChannelErased, command)
+	case command == CommandLaunch:
+		return s, ErrAlreadyLaunched
+	case s == StatusExploded && command == CommandExplode:
+		return s, fmt.Errorf("%w: already exploded", ErrRocketExploded)
+	case s == StatusExploded:
+		return s, fmt.Errorf("%w: cannot change crashed rocket", ErrRocketExploded)
+	case s == StatusNotLaunched:
+		return s, fmt.Errorf("%w: cannot %s", ErrNotLaunched, command)
+	default:
+		return s, fmt.Errorf("%w: cannot %s a %s rocket", ErrInvalidTransition, command, s)
+	}
+}
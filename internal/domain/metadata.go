@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"crypto/rand"
+	"fmt"
+)
+
+// Well-known keys of EventMetadata
+const (
+	MetadataEventID       = "eventId"       // unique id of the event
+	MetadataCorrelationID = "correlationId" // id shared by everything caused by the same request
+	MetadataCausationID   = "causationId"   // id of the message that caused the event
+	MetadataSource        = "source"        // producer that sent the message
+	MetadataReceivedAt    = "receivedAt"    // when the message was received (RFC 3339)
+	MetadataAppliedAt     = "appliedAt"     // when the rocket applied it (RFC 3339)
+	MetadataClockSkew     = "clockSkewMs"   // message time minus receive time, in milliseconds
+	MetadataVersion       = "version"       // aggregate version the event took, 1 for the first of its stream
+)
+
+// EventMetadata describes where an event comes from. It is attached when the event is
+// raised and never changes afterwards: events hand out copies only.
+type EventMetadata map[string]string
+
+// Get returns the value of a key, or "" if it is not set
+func (m EventMetadata) Get(key string) string {
+	return m[key]
+}
+
+// Copy returns an independent copy of the metadata (nil stays nil)
+func (m EventMetadata) Copy() EventMetadata {
+	if m == nil {
+		return nil
+	}
+	c := make(EventMetadata, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// NewID returns a random RFC 4122 version 4 UUID
+func NewID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
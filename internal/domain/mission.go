@@ -0,0 +1,125 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MissionName is a mission as reported by the producer together with its category
+type MissionName struct {
+	raw      string
+	category Mission
+}
+
+// NewMissionName creates a mission name; raw may be empty when the producer's text is unknown
+func NewMissionName(raw string, category Mission) MissionName {
+	return MissionName{raw: raw, category: category}
+}
+
+// Raw returns the mission as reported by the producer
+func (m MissionName) Raw() string {
+	return m.raw
+}
+
+// Category returns the normalized mission
+func (m MissionName) Category() Mission {
+	return m.category
+}
+
+// IsCategory reports whether m is one of the mission categories (MissionUnknown excluded)
+func (m Mission) IsCategory() bool {
+	switch m {
+	case MissionExploration, MissionSatellite, MissionResupply:
+		return true
+	default:
+		return false
+	}
+}
+
+// RegisteredMission is an entry of the mission registry
+type RegisteredMission struct {
+	Name     string  `json:"name"`
+	Category Mission `json:"category"`
+	BuiltIn  bool    `json:"builtIn,omitempty"`
+}
+
+// MissionRegistry maps the missions producers report to their category. It starts with the
+// categories themselves and can be extended while the server runs. Names are matched
+// case-insensitively, ignoring repeated spaces.
+type MissionRegistry struct {
+	mu       sync.RWMutex
+	strict   bool
+	missions map[string]RegisteredMission // by key (see missionKey)
+}
+
+// NewMissionRegistry creates a registry of the built-in categories. A strict registry rejects
+// missions that are not registered; otherwise they fall into MissionUnknown.
+func NewMissionRegistry(strict bool) *MissionRegistry {
+	r := &MissionRegistry{strict: strict, missions: make(map[string]RegisteredMission)}
+	for _, category := range []Mission{MissionExploration, MissionSatellite, MissionResupply} {
+		r.missions[missionKey(string(category))] = RegisteredMission{Name: string(category), Category: category, BuiltIn: true}
+	}
+	return r
+}
+
+// missionKey normalizes a mission name for lookups
+func missionKey(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), " ")
+}
+
+// Strict reports whether unregistered missions are rejected
+func (r *MissionRegistry) Strict() bool {
+	return r.strict
+}
+
+// Register adds (or re-categorizes) a mission. Built-in categories cannot be changed.
+func (r *MissionRegistry) Register(name string, category Mission) error {
+	key := missionKey(name)
+	if key == "" {
+		return fmt.Errorf("%w: mission name cannot be empty", ErrInvalidPayload)
+	}
+	category = Mission(strings.ToLower(string(category)))
+	if !category.IsCategory() {
+		return fmt.Errorf("%w: unknown mission category %q", ErrInvalidPayload, category)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if existing, ok := r.missions[key]; ok && existing.BuiltIn {
+		return fmt.Errorf("%w: %q is a built-in mission category", ErrInvalidPayload, existing.Name)
+	}
+	r.missions[key] = RegisteredMission{Name: strings.TrimSpace(name), Category: category}
+	return nil
+}
+
+// Resolve returns the mission name of raw. An unregistered mission is MissionUnknown, or an
+// error wrapping ErrUnknownMission in strict mode.
+func (r *MissionRegistry) Resolve(raw string) (MissionName, error) {
+	r.mu.RLock()
+	registered, ok := r.missions[missionKey(raw)]
+	r.mu.RUnlock()
+
+	if !ok {
+		if r.strict {
+			return MissionName{}, fmt.Errorf("%w: %q is not registered", ErrUnknownMission, raw)
+		}
+		return NewMissionName(raw, MissionUnknown), nil
+	}
+	return NewMissionName(raw, registered.Category), nil
+}
+
+// List returns the registered missions sorted by name
+func (r *MissionRegistry) List() []RegisteredMission {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	missions := make([]RegisteredMission, 0, len(r.missions))
+	for _, mission := range r.missions {
+		missions = append(missions, mission)
+	}
+	sort.Slice(missions, func(i, j int) bool {
+		return missionKey(missions[i].Name) < missionKey(missions[j].Name)
+	})
+	return missions
+}
@@ -1,14 +1,138 @@
 package domain
 
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
 // RocketRepository defines the contract for rocket persistence
 type RocketRepository interface {
 	GetByChannel(channel *Channel) (*Rocket, error)
+	// Peek returns a rocket like GetByChannel, but one that is not cached stays out of the cache
+	Peek(channel *Channel) (*Rocket, error)
 	Save(rocket *Rocket) error
 	GetAll() ([]*Rocket, error)
 }
 
-// EventStore defines the contract for event storage
+// FleetRepository defines the contract for fleet persistence
+type FleetRepository interface {
+	// GetByID returns the fleet with the given ID; a fleet that was never created does not Exist
+	GetByID(id string) (*Fleet, error)
+	// GetEvents returns the events of a fleet's stream
+	GetEvents(id string) ([]DomainEvent, error)
+	Save(fleet *Fleet) error
+	GetAll() ([]*Fleet, error)
+}
+
+// AnyVersion disables the optimistic concurrency check of AppendEvents
+const AnyVersion = -1
+
+// EventStore defines the contract for event storage.
+// The version of a channel is the number of events stored for it.
 type EventStore interface {
 	AppendEvent(event DomainEvent) error
+	// AppendEvents atomically appends events to a channel if its current version is
+	// expectedVersion (or AnyVersion); otherwise nothing is written and a *ConcurrencyError is returned
+	AppendEvents(channel *Channel, expectedVersion int, events []DomainEvent) error
 	GetEventsByChannel(channel *Channel) ([]DomainEvent, error)
+	// GetAllChannels returns the channels in the order their first event was stored
+	GetAllChannels() []string
+	// ReadAll returns up to limit events (all if limit <= 0) starting at fromPosition, in commit order
+	ReadAll(fromPosition int64, limit int) ([]RecordedEvent, error)
+}
+
+// RecordedEvent is an event together with its position in the global log of the store and
+// its version within its channel. Both start at 1 and grow by one with every stored event.
+type RecordedEvent struct {
+	Position int64
+	Version  int
+	Event    DomainEvent
+}
+
+// EventSubscriber is implemented by event stores that push committed events to subscribers.
+// A subscription first delivers the stored history and then every new event, in order and
+// exactly once. It blocks until ctx is done (returning ctx.Err()) or handler returns an error.
+type EventSubscriber interface {
+	// SubscribeAll delivers the global log starting at fromPosition
+	SubscribeAll(ctx context.Context, fromPosition int64, handler func(RecordedEvent) error) error
+	// SubscribeToChannel delivers the events of a channel after the first fromVersion ones
+	SubscribeToChannel(ctx context.Context, channel *Channel, fromVersion int, handler func(RecordedEvent) error) error
+}
+
+// ChainVerifier is implemented by event stores that chain every stored event to the hash
+// of the previous event of its channel
+type ChainVerifier interface {
+	// VerifyChains walks the stored log and reports the first broken link of every channel
+	VerifyChains() (*ChainReport, error)
+}
+
+// ErrHashChainUnsupported is returned when an event store keeps no hash chains to verify
+var ErrHashChainUnsupported = errors.New("event store keeps no hash chains")
+
+// ChainReport is the result of verifying the hash chains of a store
+type ChainReport struct {
+	Intact   bool         `json:"intact"`
+	Channels int          `json:"channels"`
+	Events   int          `json:"events"`
+	Breaks   []ChainBreak `json:"breaks"`
+}
+
+// ChainBreak describes the first event of a channel that does not match the hash chain.
+// A break with an empty channel is damage that could not be attributed to a channel.
+type ChainBreak struct {
+	Channel     string `json:"channel"`
+	EventNumber int    `json:"eventNumber"` // 1-based position of the event within its channel
+	Location    string `json:"location"`    // where the record is stored
+	Reason      string `json:"reason"`
+}
+
+// EventSerializer converts events to and from their stored representation
+type EventSerializer interface {
+	Serialize(event DomainEvent) ([]byte, error)
+	Deserialize(data []byte) (DomainEvent, error)
+}
+
+// SnapshotStore defines the contract for rocket snapshot storage
+type SnapshotStore interface {
+	SaveSnapshot(snapshot *RocketSnapshot) error
+	// LoadSnapshot returns the latest snapshot of a channel, or nil if there is none
+	LoadSnapshot(channel *Channel) (*RocketSnapshot, error)
+	// DeleteSnapshot removes the snapshot of a channel, if any
+	DeleteSnapshot(channel *Channel) error
+}
+
+// EventPublisher receives the events of every batch a repository committed, in commit order.
+// Publishing must not fail the write: the events are already stored.
+type EventPublisher interface {
+	Publish(events []DomainEvent)
+}
+
+// KeyShredder is implemented by stores of per-channel data keys. Destroying the key of a
+// channel makes its encrypted events unreadable for good.
+type KeyShredder interface {
+	DestroyKey(channel string) error
+}
+
+// ErrConcurrencyConflict is matched (errors.Is) by every *ConcurrencyError
+var ErrConcurrencyConflict = errors.New("concurrency conflict")
+
+// ErrOutcomeUnknown is returned when an event store gave up waiting for an append it already
+// handed on: the events may still be stored, so the channel must be read again before retrying
+var ErrOutcomeUnknown = errors.New("append outcome unknown")
+
+// ConcurrencyError reports that another writer appended to a channel first
+type ConcurrencyError struct {
+	Channel  string
+	Expected int
+	Actual   int
+}
+
+func (e *ConcurrencyError) Error() string {
+	return fmt.Sprintf("concurrency conflict on channel %s: expected version %d, actual %d", e.Channel, e.Expected, e.Actual)
+}
+
+// Is makes errors.Is(err, ErrConcurrencyConflict) true
+func (e *ConcurrencyError) Is(target error) bool {
+	return target == ErrConcurrencyConflict
 }
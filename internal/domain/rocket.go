@@ -3,6 +3,8 @@ package domain
 import (
 	"fmt"
 	"log/slog"
+	"strconv"
+	"time"
 )
 
 // Rocket is the aggregate root representing a rocket
@@ -12,8 +14,20 @@ type Rocket struct {
 	status            RocketStatus
 	speed             *Speed
 	mission           Mission
+	rawMission        string // mission as reported by the producer
+	fuel              int
+	stages            int // remaining stages, known after the first separation (stagesKnown)
+	stagesKnown       bool
 	lastMessageNumber *MessageNumber
+	version           int // events applied so far; unlike message numbers it has no gaps
 	uncommittedEvents []DomainEvent
+	commandMetadata   EventMetadata  // copied into every event raised until the next commit
+	catalog           *RocketCatalog // envelope of the rocket types, nil when commands are not checked
+	timePolicy        *TimePolicy    // nil when message times are not checked
+	warnings          []DomainEvent  // raised after the event of the current command
+	lastMessageTime   int64          // latest message time applied (Unix milliseconds)
+	lastReceivedAt    int64          // when the last applied message was received, 0 if unknown
+	clockSkew         int64          // time minus receive time of the last applied message, in ms
 }
 
 // NewRocket creates a new Rocket instance
@@ -21,7 +35,7 @@ func NewRocket(channel *Channel) *Rocket {
 	return &Rocket{
 		channel:           channel,
 		rocketType:        "unknown",
-		status:            StatusLaunched,
+		status:            StatusNotLaunched,
 		speed:             &Speed{value: 0},
 		mission:           MissionUnknown,
 		lastMessageNumber: &MessageNumber{value: 0},
@@ -31,12 +45,26 @@ func NewRocket(channel *Channel) *Rocket {
 
 // Launch launches the rocket
 func (r *Rocket) Launch(msgNum *MessageNumber, rocketType string, speed *Speed, mission Mission, timestamp int64) error {
-	if r.status != StatusLaunched {
-		return fmt.Errorf("rocket already launched")
+	return r.LaunchNamed(msgNum, rocketType, speed, NewMissionName("", mission), timestamp)
+}
+
+// LaunchNamed launches the rocket, keeping the mission as reported by the producer
+func (r *Rocket) LaunchNamed(msgNum *MessageNumber, rocketType string, speed *Speed, missionName MissionName, timestamp int64) error {
+	mission := missionName.Category()
+	if err := r.accept(CommandLaunch, msgNum, timestamp); err != nil {
+		return err
+	}
+	if speed == nil {
+		return r.reject(CommandLaunch, msgNum, fmt.Errorf("%w: launch speed is required", ErrInvalidPayload))
 	}
 
-	if msgNum.Value() <= r.lastMessageNumber.Value() {
-		return fmt.Errorf("message number out of order")
+	spec, _ := r.catalog.Lookup(rocketType)
+	violation := spec.checkSpeed(speed.Value())
+	if violation == nil {
+		violation = spec.checkMission(mission)
+	}
+	if err := r.checkEnvelope(CommandLaunch, msgNum, rocketType, violation, timestamp); err != nil {
+		return err
 	}
 
 	event := &RocketLaunched{
@@ -45,7 +73,15 @@ func (r *Rocket) Launch(msgNum *MessageNumber, rocketType string, speed *Speed,
 		Type:          rocketType,
 		Speed:         speed,
 		Mission:       mission,
+		RawMission:    missionName.Raw(),
 		Timestamp:     timestamp,
+		Metadata:      r.newEventMetadata(),
+	}
+	if r.stagesKnown {
+		// A relaunched booster keeps the stages it has left
+		event.Stages = r.stages
+	} else if spec != nil {
+		event.Stages = spec.Stages
 	}
 
 	slog.Info("Applying RocketLaunched",
@@ -55,22 +91,26 @@ func (r *Rocket) Launch(msgNum *MessageNumber, rocketType string, speed *Speed,
 		"speed", speed.Value(),
 		"mission", mission)
 
-	r.applyEvent(event)
-	r.uncommittedEvents = append(r.uncommittedEvents, event)
+	r.raise(event)
 	return nil
 }
 
 // IncreaseSpeed increases the rocket's speed
 func (r *Rocket) IncreaseSpeed(msgNum *MessageNumber, delta int, timestamp int64) error {
-	if r.status == StatusExploded {
-		return fmt.Errorf("cannot change crashed rocket")
+	if err := r.accept(CommandIncreaseSpeed, msgNum, timestamp); err != nil {
+		return err
 	}
-
-	if msgNum.Value() <= r.lastMessageNumber.Value() {
-		return fmt.Errorf("message number out of order")
+	if delta < 0 {
+		return r.reject(CommandIncreaseSpeed, msgNum, fmt.Errorf("%w: speed delta cannot be negative", ErrInvalidPayload))
 	}
 
-	newSpeed := r.speed.Increase(delta)
+	newSpeed, err := r.speed.Increase(delta)
+	if err != nil {
+		return r.reject(CommandIncreaseSpeed, msgNum, err)
+	}
+	if err := r.checkEnvelope(CommandIncreaseSpeed, msgNum, r.rocketType, r.spec().checkSpeed(newSpeed.Value()), timestamp); err != nil {
+		return err
+	}
 
 	event := &RocketSpeedIncreased{
 		Channel:       r.channel,
@@ -79,6 +119,7 @@ func (r *Rocket) IncreaseSpeed(msgNum *MessageNumber, delta int, timestamp int64
 		NewSpeed:      newSpeed,
 		Delta:         delta,
 		Timestamp:     timestamp,
+		Metadata:      r.newEventMetadata(),
 	}
 
 	slog.Info("Applying SpeedIncreased",
@@ -88,19 +129,17 @@ func (r *Rocket) IncreaseSpeed(msgNum *MessageNumber, delta int, timestamp int64
 		"after", newSpeed.Value(),
 		"increment", delta)
 
-	r.applyEvent(event)
-	r.uncommittedEvents = append(r.uncommittedEvents, event)
+	r.raise(event)
 	return nil
 }
 
 // DecreaseSpeed decreases the rocket's speed
 func (r *Rocket) DecreaseSpeed(msgNum *MessageNumber, delta int, timestamp int64) error {
-	if r.status == StatusExploded {
-		return fmt.Errorf("cannot change crashed rocket")
+	if err := r.accept(CommandDecreaseSpeed, msgNum, timestamp); err != nil {
+		return err
 	}
-
-	if msgNum.Value() <= r.lastMessageNumber.Value() {
-		return fmt.Errorf("message number out of order")
+	if delta < 0 {
+		return r.reject(CommandDecreaseSpeed, msgNum, fmt.Errorf("%w: speed delta cannot be negative", ErrInvalidPayload))
 	}
 
 	newSpeed := r.speed.Decrease(delta)
@@ -112,6 +151,7 @@ func (r *Rocket) DecreaseSpeed(msgNum *MessageNumber, delta int, timestamp int64
 		NewSpeed:      newSpeed,
 		Delta:         delta,
 		Timestamp:     timestamp,
+		Metadata:      r.newEventMetadata(),
 	}
 
 	slog.Info("Applying SpeedDecreased",
@@ -121,19 +161,14 @@ func (r *Rocket) DecreaseSpeed(msgNum *MessageNumber, delta int, timestamp int64
 		"after", newSpeed.Value(),
 		"decrement", delta)
 
-	r.applyEvent(event)
-	r.uncommittedEvents = append(r.uncommittedEvents, event)
+	r.raise(event)
 	return nil
 }
 
 // Explode explodes the rocket
 func (r *Rocket) Explode(msgNum *MessageNumber, reason string, timestamp int64) error {
-	if r.status == StatusExploded {
-		return fmt.Errorf("rocket already exploded")
-	}
-
-	if msgNum.Value() <= r.lastMessageNumber.Value() {
-		return fmt.Errorf("message number out of order")
+	if err := r.accept(CommandExplode, msgNum, timestamp); err != nil {
+		return err
 	}
 
 	event := &RocketExploded{
@@ -141,21 +176,26 @@ func (r *Rocket) Explode(msgNum *MessageNumber, reason string, timestamp int64)
 		MessageNumber: msgNum,
 		Reason:        reason,
 		Timestamp:     timestamp,
+		Metadata:      r.newEventMetadata(),
 	}
 
-	r.applyEvent(event)
-	r.uncommittedEvents = append(r.uncommittedEvents, event)
+	r.raise(event)
 	return nil
 }
 
 // ChangeMission changes the rocket's mission
 func (r *Rocket) ChangeMission(msgNum *MessageNumber, newMission Mission, timestamp int64) error {
-	if r.status == StatusExploded {
-		return fmt.Errorf("cannot change crashed rocket")
-	}
+	return r.ChangeMissionNamed(msgNum, NewMissionName("", newMission), timestamp)
+}
 
-	if msgNum.Value() <= r.lastMessageNumber.Value() {
-		return fmt.Errorf("message number out of order")
+// ChangeMissionNamed changes the rocket's mission, keeping it as reported by the producer
+func (r *Rocket) ChangeMissionNamed(msgNum *MessageNumber, missionName MissionName, timestamp int64) error {
+	newMission := missionName.Category()
+	if err := r.accept(CommandChangeMission, msgNum, timestamp); err != nil {
+		return err
+	}
+	if err := r.checkEnvelope(CommandChangeMission, msgNum, r.rocketType, r.spec().checkMission(newMission), timestamp); err != nil {
+		return err
 	}
 
 	event := &RocketMissionChanged{
@@ -163,11 +203,316 @@ func (r *Rocket) ChangeMission(msgNum *MessageNumber, newMission Mission, timest
 		MessageNumber: msgNum,
 		OldMission:    r.mission,
 		NewMission:    newMission,
+		RawMission:    missionName.Raw(),
+		Timestamp:     timestamp,
+		Metadata:      r.newEventMetadata(),
+	}
+
+	r.raise(event)
+	return nil
+}
+
+// Erase erases the rocket's channel: a ChannelErased tombstone ends its stream and the
+// rocket's state is forgotten
+func (r *Rocket) Erase(reason string, timestamp int64) error {
+	if _, err := r.status.Next(CommandErase); err != nil {
+		return r.reject(CommandErase, r.lastMessageNumber, err)
+	}
+
+	event := &ChannelErased{
+		Channel:       r.channel,
+		MessageNumber: r.lastMessageNumber,
+		Reason:        reason,
+		Timestamp:     timestamp,
+		Metadata:      r.newEventMetadata(),
+	}
+
+	r.raise(event)
+	return nil
+}
+
+// SkipMessages gives up on the messages from..to, which never arrived: a MessagesSkipped
+// event takes their numbers, so the next message applied is to+1. from must be the next
+// message of the channel.
+func (r *Rocket) SkipMessages(from, to *MessageNumber, reason string, timestamp int64) error {
+	if _, err := r.status.Next(CommandSkipMessages); err != nil {
+		return r.reject(CommandSkipMessages, from, err)
+	}
+	if expected := r.lastMessageNumber.Value() + 1; from.Value() != expected {
+		return r.reject(CommandSkipMessages, from, fmt.Errorf("%w: skip starts at %d, expected %d", ErrOutOfOrder, from.Value(), expected))
+	}
+	if to.Value() < from.Value() {
+		return r.reject(CommandSkipMessages, from, fmt.Errorf("%w: skip ends at %d, before %d", ErrInvalidPayload, to.Value(), from.Value()))
+	}
+
+	event := &MessagesSkipped{
+		Channel:       r.channel,
+		MessageNumber: to,
+		From:          from.Value(),
+		Reason:        reason,
 		Timestamp:     timestamp,
+		Metadata:      r.newEventMetadata(),
 	}
 
+	slog.Warn("Applying MessagesSkipped",
+		"channel", r.channel.Value(),
+		"from", from.Value(),
+		"to", to.Value(),
+		"reason", reason)
+
+	r.raise(event)
+	return nil
+}
+
+// accept checks that the rocket's lifecycle allows command, that msgNum comes after the last
+// applied message and that its time does not go back (see TimePolicy)
+func (r *Rocket) accept(command RocketCommand, msgNum *MessageNumber, timestamp int64) error {
+	if _, err := r.status.Next(command); err != nil {
+		return r.reject(command, msgNum, err)
+	}
+	if msgNum.Value() <= r.lastMessageNumber.Value() {
+		return r.reject(command, msgNum, fmt.Errorf("%w: %d is not after %d", ErrOutOfOrder, msgNum.Value(), r.lastMessageNumber.Value()))
+	}
+	return r.checkTime(command, msgNum, timestamp)
+}
+
+// reject builds the error of a rejected command; warnings of the command are dropped
+func (r *Rocket) reject(command RocketCommand, msgNum *MessageNumber, reason error) error {
+	r.warnings = nil
+	return &CommandError{
+		Channel:       r.channel.Value(),
+		Command:       command,
+		MessageNumber: msgNum.Value(),
+		Status:        r.status,
+		Err:           reason,
+	}
+}
+
+// raise applies and records the event of a command, followed by the command's warnings
+func (r *Rocket) raise(event DomainEvent) {
+	r.record(event)
+	for _, warning := range r.warnings {
+		r.record(warning)
+	}
+	r.warnings = nil
+}
+
+// record applies a new event and adds it to the uncommitted ones, stamped with the version it takes
+func (r *Rocket) record(event DomainEvent) {
+	if metadata := raisedMetadata(event); metadata != nil {
+		metadata[MetadataVersion] = strconv.Itoa(r.version + 1)
+	}
 	r.applyEvent(event)
 	r.uncommittedEvents = append(r.uncommittedEvents, event)
+}
+
+// raisedMetadata returns the metadata of an event the rocket raises, which is still its own
+// to complete (GetMetadata hands out copies)
+func raisedMetadata(event DomainEvent) EventMetadata {
+	switch e := event.(type) {
+	case *RocketLaunched:
+		return e.Metadata
+	case *RocketSpeedIncreased:
+		return e.Metadata
+	case *RocketSpeedDecreased:
+		return e.Metadata
+	case *RocketExploded:
+		return e.Metadata
+	case *RocketMissionChanged:
+		return e.Metadata
+	case *RocketLanded:
+		return e.Metadata
+	case *RocketRefueled:
+		return e.Metadata
+	case *RocketStageSeparated:
+		return e.Metadata
+	case *RocketEnvelopeExceeded:
+		return e.Metadata
+	case *RocketTimeRegressed:
+		return e.Metadata
+	case *MessagesSkipped:
+		return e.Metadata
+	case *ChannelErased:
+		return e.Metadata
+	}
+	return nil
+}
+
+// recordRejection rejects a command but still records why, in an event that takes up its
+// message number, followed by the warnings the command raised so far (a message time that
+// went back is worth knowing whatever became of the command): the caller must save the rocket
+func (r *Rocket) recordRejection(command RocketCommand, msgNum *MessageNumber, reason error, event DomainEvent) error {
+	warnings := r.warnings
+	err := r.reject(command, msgNum, reason)
+	r.record(event)
+	for _, warning := range warnings {
+		r.record(warning)
+	}
+	return err
+}
+
+// SetCatalog sets the rocket types whose envelope the next commands are checked against
+// (nil disables the checks)
+func (r *Rocket) SetCatalog(catalog *RocketCatalog) {
+	r.catalog = catalog
+}
+
+// spec returns the catalog entry of the rocket's type, nil when it is not in the catalog
+func (r *Rocket) spec() *RocketTypeSpec {
+	spec, _ := r.catalog.Lookup(r.rocketType)
+	return spec
+}
+
+// checkEnvelope handles a command that exceeds the envelope of rocketType (violation is nil
+// when it does not). With EnvelopeReject the command is refused and only the
+// RocketEnvelopeExceeded event is recorded; with EnvelopeWarn it follows the command's event.
+func (r *Rocket) checkEnvelope(command RocketCommand, msgNum *MessageNumber, rocketType string, violation *EnvelopeViolation, timestamp int64) error {
+	if violation == nil {
+		return nil
+	}
+	event := &RocketEnvelopeExceeded{
+		Channel:       r.channel,
+		MessageNumber: msgNum,
+		Command:       command,
+		RocketType:    rocketType,
+		Rule:          violation.Rule,
+		Detail:        violation.Detail,
+		Rejected:      r.catalog.Policy() == EnvelopeReject,
+		Timestamp:     timestamp,
+		Metadata:      r.newEventMetadata(),
+	}
+
+	slog.Warn("Rocket type envelope exceeded",
+		"channel", r.channel.Value(),
+		"message_number", msgNum.Value(),
+		"command", command,
+		"rocket_type", rocketType,
+		"rule", violation.Rule,
+		"rejected", event.Rejected)
+
+	if !event.Rejected {
+		r.warnings = append(r.warnings, event)
+		return nil
+	}
+	return r.recordRejection(command, msgNum, fmt.Errorf("%w: %s", ErrEnvelopeExceeded, violation.Detail), event)
+}
+
+// SetTimePolicy sets how the next commands handle message times that go back (nil disables
+// the checks)
+func (r *Rocket) SetTimePolicy(policy *TimePolicy) {
+	r.timePolicy = policy
+}
+
+// checkTime handles a message time before the latest one of the channel by more than the
+// tolerance of the time policy: the RocketTimeRegressed event follows the command's event,
+// or is recorded on its own when regressions are rejected
+func (r *Rocket) checkTime(command RocketCommand, msgNum *MessageNumber, timestamp int64) error {
+	if r.timePolicy == nil || r.lastMessageTime == 0 {
+		return nil
+	}
+	regression := r.lastMessageTime - timestamp
+	if regression <= r.timePolicy.Tolerance.Milliseconds() {
+		return nil
+	}
+	event := &RocketTimeRegressed{
+		Channel:       r.channel,
+		MessageNumber: msgNum,
+		Command:       command,
+		PreviousTime:  r.lastMessageTime,
+		Rejected:      r.timePolicy.Reject,
+		Timestamp:     timestamp,
+		Metadata:      r.newEventMetadata(),
+	}
+
+	slog.Warn("Message time went back",
+		"channel", r.channel.Value(),
+		"message_number", msgNum.Value(),
+		"command", command,
+		"regression_ms", regression,
+		"rejected", event.Rejected)
+
+	if !event.Rejected {
+		r.warnings = append(r.warnings, event)
+		return nil
+	}
+	return r.recordRejection(command, msgNum, fmt.Errorf("%w: %d ms before the latest message", ErrTimeRegression, regression), event)
+}
+
+// Land lands a flying rocket; it can then be refueled and launched again
+func (r *Rocket) Land(msgNum *MessageNumber, site string, timestamp int64) error {
+	if err := r.accept(CommandLand, msgNum, timestamp); err != nil {
+		return err
+	}
+
+	event := &RocketLanded{
+		Channel:       r.channel,
+		MessageNumber: msgNum,
+		Site:          site,
+		Timestamp:     timestamp,
+		Metadata:      r.newEventMetadata(),
+	}
+
+	slog.Info("Applying RocketLanded",
+		"channel", r.channel.Value(),
+		"message_number", msgNum.Value(),
+		"site", site)
+
+	r.raise(event)
+	return nil
+}
+
+// Refuel loads fuel into a rocket on the ground
+func (r *Rocket) Refuel(msgNum *MessageNumber, amount int, timestamp int64) error {
+	if err := r.accept(CommandRefuel, msgNum, timestamp); err != nil {
+		return err
+	}
+	if amount <= 0 {
+		return r.reject(CommandRefuel, msgNum, fmt.Errorf("%w: fuel amount must be positive", ErrInvalidPayload))
+	}
+
+	event := &RocketRefueled{
+		Channel:       r.channel,
+		MessageNumber: msgNum,
+		Amount:        amount,
+		Fuel:          r.fuel + amount,
+		Timestamp:     timestamp,
+		Metadata:      r.newEventMetadata(),
+	}
+
+	r.raise(event)
+	return nil
+}
+
+// SeparateStage separates a stage of a flying rocket. The number of remaining stages must
+// be lower than after the previous separation.
+func (r *Rocket) SeparateStage(msgNum *MessageNumber, remainingStages int, timestamp int64) error {
+	if err := r.accept(CommandSeparateStage, msgNum, timestamp); err != nil {
+		return err
+	}
+	if remainingStages < 0 {
+		return r.reject(CommandSeparateStage, msgNum, fmt.Errorf("%w: remaining stages cannot be negative", ErrInvalidPayload))
+	}
+	if r.stagesKnown && remainingStages >= r.stages {
+		return r.reject(CommandSeparateStage, msgNum, fmt.Errorf("%w: %d remaining stages after %d", ErrStageCount, remainingStages, r.stages))
+	}
+	var violation *EnvelopeViolation
+	if !r.stagesKnown {
+		// Launched without a known stage count: the first separation is checked against the type
+		violation = r.spec().checkStages(remainingStages)
+	}
+	if err := r.checkEnvelope(CommandSeparateStage, msgNum, r.rocketType, violation, timestamp); err != nil {
+		return err
+	}
+
+	event := &RocketStageSeparated{
+		Channel:         r.channel,
+		MessageNumber:   msgNum,
+		RemainingStages: remainingStages,
+		Timestamp:       timestamp,
+		Metadata:        r.newEventMetadata(),
+	}
+
+	r.raise(event)
 	return nil
 }
 
@@ -179,6 +524,23 @@ func (r *Rocket) GetUncommittedEvents() []DomainEvent {
 // MarkEventsAsCommitted marks events as committed
 func (r *Rocket) MarkEventsAsCommitted() {
 	r.uncommittedEvents = []DomainEvent{}
+	r.commandMetadata = nil
+}
+
+// SetCommandMetadata sets the metadata of the events raised by the next commands, until
+// they are committed. Every event gets its own copy with a new event ID.
+func (r *Rocket) SetCommandMetadata(metadata EventMetadata) {
+	r.commandMetadata = metadata.Copy()
+}
+
+// newEventMetadata builds the metadata of a new event
+func (r *Rocket) newEventMetadata() EventMetadata {
+	metadata := r.commandMetadata.Copy()
+	if metadata == nil {
+		metadata = EventMetadata{}
+	}
+	metadata[MetadataEventID] = NewID()
+	return metadata
 }
 
 // LoadFromHistory reconstructs the state from the event history
@@ -189,14 +551,21 @@ func (r *Rocket) LoadFromHistory(events []DomainEvent) error {
 	return nil
 }
 
-// applyEvent applies an event to the internal state
+// applyEvent applies an event to the internal state. Every event, rejections and tombstones
+// included, takes the next version.
 func (r *Rocket) applyEvent(event DomainEvent) {
+	r.version++
+
 	switch e := event.(type) {
 	case *RocketLaunched:
 		r.status = StatusFlying
 		r.rocketType = e.Type
 		r.speed = e.Speed
 		r.mission = e.Mission
+		r.rawMission = e.RawMission
+		if e.Stages > 0 {
+			r.stages, r.stagesKnown = e.Stages, true
+		}
 		r.lastMessageNumber = e.MessageNumber
 
 	case *RocketSpeedIncreased:
@@ -213,7 +582,64 @@ func (r *Rocket) applyEvent(event DomainEvent) {
 
 	case *RocketMissionChanged:
 		r.mission = e.NewMission
+		r.rawMission = e.RawMission
+		r.lastMessageNumber = e.MessageNumber
+
+	case *RocketLanded:
+		r.status = StatusLanded
+		r.speed = &Speed{value: 0}
+		r.lastMessageNumber = e.MessageNumber
+
+	case *RocketRefueled:
+		r.fuel = e.Fuel
+		r.lastMessageNumber = e.MessageNumber
+
+	case *RocketStageSeparated:
+		r.stages = e.RemainingStages
+		r.stagesKnown = true
+		r.lastMessageNumber = e.MessageNumber
+
+	case *RocketEnvelopeExceeded:
+		// A rejected command still takes up its message number
+		r.lastMessageNumber = e.MessageNumber
+		if e.Rejected {
+			return
+		}
+
+	case *RocketTimeRegressed:
 		r.lastMessageNumber = e.MessageNumber
+		if e.Rejected {
+			return
+		}
+
+	case *MessagesSkipped:
+		// Its timestamp is the time of the skip, not a message time
+		r.lastMessageNumber = e.MessageNumber
+		return
+
+	case *ChannelErased:
+		r.status = StatusErased
+		r.rocketType = "unknown"
+		r.speed = &Speed{value: 0}
+		r.mission, r.rawMission = MissionUnknown, ""
+		r.fuel = 0
+		r.stages, r.stagesKnown = 0, false
+		r.lastMessageTime, r.lastReceivedAt, r.clockSkew = 0, 0, 0
+		r.lastMessageNumber = e.MessageNumber
+		return
+	}
+
+	// The time of a rejected message is not trusted
+	r.trackTime(event)
+}
+
+// trackTime keeps the latest message time, and when the last message was received with the
+// skew of the producer's clock
+func (r *Rocket) trackTime(event DomainEvent) {
+	r.lastMessageTime = max(r.lastMessageTime, event.GetTimestamp())
+	if receivedAt, err := time.Parse(time.RFC3339Nano, event.GetMetadata().Get(MetadataReceivedAt)); err == nil {
+		r.lastReceivedAt = receivedAt.UnixMilli()
+		r.clockSkew = event.GetTimestamp() - r.lastReceivedAt
 	}
 }
 
@@ -237,11 +663,55 @@ func (r *Rocket) GetMission() Mission {
 	return r.mission
 }
 
+// GetRawMission returns the mission as reported by the producer (empty when it was not recorded)
+func (r *Rocket) GetRawMission() string {
+	return r.rawMission
+}
+
 // GetRocketType returns the rocket's type
 func (r *Rocket) GetRocketType() string {
 	return r.rocketType
 }
 
+// IsNew reports whether no event was ever applied to the rocket, i.e. its channel has no history
+func (r *Rocket) IsNew() bool {
+	return r.lastMessageNumber.Value() == 0
+}
+
+// GetFuel returns the fuel on board
+func (r *Rocket) GetFuel() int {
+	return r.fuel
+}
+
+// GetRemainingStages returns the stages left after the last separation; ok is false while
+// no stage has separated
+func (r *Rocket) GetRemainingStages() (stages int, ok bool) {
+	return r.stages, r.stagesKnown
+}
+
+// GetLastMessageTime returns the latest message time applied (Unix milliseconds, 0 if none)
+func (r *Rocket) GetLastMessageTime() int64 {
+	return r.lastMessageTime
+}
+
+// GetLastReceivedAt returns when the last applied message was received (Unix milliseconds,
+// 0 when unknown)
+func (r *Rocket) GetLastReceivedAt() int64 {
+	return r.lastReceivedAt
+}
+
+// GetClockSkew returns how far ahead of the server's clock (negative: behind) the producer's
+// clock was for the last applied message, in milliseconds; ok is false when unknown
+func (r *Rocket) GetClockSkew() (skew int64, ok bool) {
+	return r.clockSkew, r.lastReceivedAt != 0
+}
+
+// GetVersion returns the aggregate version: the number of events in the rocket's stream,
+// including the uncommitted ones
+func (r *Rocket) GetVersion() int {
+	return r.version
+}
+
 // GetLastMessageNumber returns the last applied messageNumber
 func (r *Rocket) GetLastMessageNumber() *MessageNumber {
 	return r.lastMessageNumber
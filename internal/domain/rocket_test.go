@@ -1,7 +1,12 @@
 package domain
 
 import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
 // TestRocketLaunch verifies that a rocket launches correctly with its initial properties.
@@ -212,3 +217,824 @@ func TestRocketLoadFromHistory(t *testing.T) {
 		t.Errorf("Expected last message 2, got %d", rocket.GetLastMessageNumber().Value())
 	}
 }
+
+// TestRocketSnapshotRestore verifies that a snapshot restores the full rocket state.
+// Expected result: the restored rocket matches the original and accepts the next message.
+func TestRocketSnapshotRestore(t *testing.T) {
+	// Arrange
+	channel, _ := NewChannel("rocket-1")
+	original := NewRocket(channel)
+	msgNum1, _ := NewMessageNumber(1)
+	speed, _ := NewSpeed(15000)
+	if err := original.Launch(msgNum1, "Falcon-9", speed, MissionSatellite, 1234567890); err != nil {
+		t.Fatalf("Expected no error launching, got %v", err)
+	}
+
+	// Act
+	restored := NewRocket(channel)
+	err := restored.RestoreFromSnapshot(original.Snapshot(1234567899))
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if restored.GetStatus() != StatusFlying || restored.GetSpeed().Value() != 15000 ||
+		restored.GetMission() != MissionSatellite || restored.GetRocketType() != "Falcon-9" {
+		t.Errorf("Restored rocket differs from original: %+v", restored.Snapshot(0))
+	}
+	msgNum2, _ := NewMessageNumber(2)
+	if err := restored.IncreaseSpeed(msgNum2, 1000, 1234567900); err != nil {
+		t.Errorf("Expected restored rocket to accept message 2, got %v", err)
+	}
+}
+
+// TestRocketEventMetadata verifies that raised events carry the command metadata.
+// Expected result: each event has the command metadata plus its own event ID; changing a copy does not change the event.
+func TestRocketEventMetadata(t *testing.T) {
+	// Arrange
+	channel, _ := NewChannel("rocket-1")
+	rocket := NewRocket(channel)
+	msgNum1, _ := NewMessageNumber(1)
+	msgNum2, _ := NewMessageNumber(2)
+	speed, _ := NewSpeed(15000)
+	rocket.SetCommandMetadata(EventMetadata{MetadataCorrelationID: "corr-1", MetadataSource: "test"})
+
+	// Act
+	_ = rocket.Launch(msgNum1, "Falcon-9", speed, MissionSatellite, 1234567890)
+	_ = rocket.IncreaseSpeed(msgNum2, 1000, 1234567891)
+	events := rocket.GetUncommittedEvents()
+
+	// Assert
+	first, second := events[0].GetMetadata(), events[1].GetMetadata()
+	if first.Get(MetadataCorrelationID) != "corr-1" || second.Get(MetadataSource) != "test" {
+		t.Errorf("Expected command metadata on every event, got %v and %v", first, second)
+	}
+	if first.Get(MetadataEventID) == "" || first.Get(MetadataEventID) == second.Get(MetadataEventID) {
+		t.Errorf("Expected distinct event IDs, got %q and %q", first.Get(MetadataEventID), second.Get(MetadataEventID))
+	}
+	first[MetadataSource] = "tampered"
+	if events[0].GetMetadata().Get(MetadataSource) != "test" {
+		t.Error("Expected event metadata to be immutable")
+	}
+	rocket.MarkEventsAsCommitted()
+	msgNum3, _ := NewMessageNumber(3)
+	_ = rocket.IncreaseSpeed(msgNum3, 1000, 1234567892)
+	if rocket.GetUncommittedEvents()[0].GetMetadata().Get(MetadataCorrelationID) != "" {
+		t.Error("Expected command metadata to be cleared on commit")
+	}
+}
+
+// TestRocketStartsNotLaunched verifies that a rocket without history has not been launched
+// and only accepts a launch.
+// Expected result: status not_launched, IsNew; speed, mission and explosion commands are rejected.
+func TestRocketStartsNotLaunched(t *testing.T) {
+	// Arrange
+	channel, _ := NewChannel("rocket-1")
+	rocket := NewRocket(channel)
+	msgNum, _ := NewMessageNumber(1)
+
+	// Act
+	errs := map[RocketCommand]error{
+		CommandIncreaseSpeed: rocket.IncreaseSpeed(msgNum, 100, 1234567890),
+		CommandDecreaseSpeed: rocket.DecreaseSpeed(msgNum, 100, 1234567890),
+		CommandChangeMission: rocket.ChangeMission(msgNum, MissionSatellite, 1234567890),
+		CommandExplode:       rocket.Explode(msgNum, "PRESSURE", 1234567890),
+	}
+
+	// Assert
+	if rocket.GetStatus() != StatusNotLaunched || !rocket.IsNew() {
+		t.Errorf("Expected a new not_launched rocket, got %v", rocket.GetStatus())
+	}
+	for command, err := range errs {
+		if err == nil {
+			t.Errorf("Expected %s to be rejected before launch", command)
+		}
+	}
+	if len(rocket.GetUncommittedEvents()) != 0 {
+		t.Errorf("Expected no events, got %d", len(rocket.GetUncommittedEvents()))
+	}
+}
+
+// TestRocketStatusTransitions verifies the lifecycle transition table.
+// Expected result: only the listed commands are accepted, leading to the listed status.
+func TestRocketStatusTransitions(t *testing.T) {
+	cases := []struct {
+		from     RocketStatus
+		command  RocketCommand
+		to       RocketStatus
+		accepted bool
+	}{
+		{StatusNotLaunched, CommandLaunch, StatusFlying, true},
+		{StatusNotLaunched, CommandIncreaseSpeed, StatusNotLaunched, false},
+		{StatusNotLaunched, CommandExplode, StatusNotLaunched, false},
+		{StatusFlying, CommandLaunch, StatusFlying, false},
+		{StatusFlying, CommandIncreaseSpeed, StatusFlying, true},
+		{StatusFlying, CommandChangeMission, StatusFlying, true},
+		{StatusFlying, CommandExplode, StatusExploded, true},
+		{StatusExploded, CommandExplode, StatusExploded, false},
+		{StatusExploded, CommandDecreaseSpeed, StatusExploded, false},
+	}
+
+	for _, c := range cases {
+		// Act
+		to, err := c.from.Next(c.command)
+
+		// Assert
+		if (err == nil) != c.accepted || to != c.to {
+			t.Errorf("Expected %s --%s--> %s (accepted=%v), got %s (err=%v)", c.from, c.command, c.to, c.accepted, to, err)
+		}
+	}
+}
+
+// TestRocketRefusesUnknownSnapshotStatus verifies that a snapshot with a status the rocket
+// lifecycle does not know is refused.
+// Expected result: "launched" and "orbiting" -> error.
+func TestRocketRefusesUnknownSnapshotStatus(t *testing.T) {
+	// Arrange
+	channel, _ := NewChannel("rocket-1")
+	snapshots := []*RocketSnapshot{
+		{Channel: "rocket-1", RocketType: "unknown", Status: "launched", Mission: "unknown", LastMessageNumber: 1},
+		{Channel: "rocket-1", RocketType: "unknown", Status: "orbiting", Mission: "unknown", LastMessageNumber: 1},
+	}
+
+	for _, snapshot := range snapshots {
+		// Act
+		err := NewRocket(channel).RestoreFromSnapshot(snapshot)
+
+		// Assert
+		if err == nil {
+			t.Errorf("Expected status %q to be refused", snapshot.Status)
+		}
+	}
+}
+
+// TestRocketCommandErrors verifies that rejected commands return a *CommandError that
+// matches the reason with errors.Is.
+// Expected result: already launched, out of order, invalid payload and exploded reasons.
+func TestRocketCommandErrors(t *testing.T) {
+	// Arrange
+	channel, _ := NewChannel("rocket-1")
+	rocket := NewRocket(channel)
+	msgNum1, _ := NewMessageNumber(1)
+	msgNum2, _ := NewMessageNumber(2)
+	msgNum3, _ := NewMessageNumber(3)
+	speed, _ := NewSpeed(15000)
+	if err := rocket.Launch(msgNum1, "Falcon-9", speed, MissionSatellite, 1234567890); err != nil {
+		t.Fatalf("Expected no error launching, got %v", err)
+	}
+
+	// Act
+	relaunch := rocket.Launch(msgNum2, "Falcon-9", speed, MissionSatellite, 1234567891)
+	stale := rocket.IncreaseSpeed(msgNum1, 100, 1234567891)
+	negative := rocket.DecreaseSpeed(msgNum2, -100, 1234567891)
+	_ = rocket.Explode(msgNum2, "PRESSURE", 1234567892)
+	crashed := rocket.ChangeMission(msgNum3, MissionResupply, 1234567893)
+
+	// Assert
+	cases := []struct {
+		err    error
+		reason error
+	}{
+		{relaunch, ErrAlreadyLaunched},
+		{stale, ErrOutOfOrder},
+		{negative, ErrInvalidPayload},
+		{crashed, ErrRocketExploded},
+	}
+	for _, c := range cases {
+		if !errors.Is(c.err, c.reason) {
+			t.Errorf("Expected %v, got %v", c.reason, c.err)
+		}
+		var commandErr *CommandError
+		if !errors.As(c.err, &commandErr) || commandErr.Channel != "rocket-1" {
+			t.Errorf("Expected a *CommandError for rocket-1, got %T", c.err)
+		}
+	}
+	var commandErr *CommandError
+	if errors.As(crashed, &commandErr) && (commandErr.Status != StatusExploded || commandErr.Command != CommandChangeMission) {
+		t.Errorf("Expected change_mission rejected in status exploded, got %s in %s", commandErr.Command, commandErr.Status)
+	}
+}
+
+// TestRocketLandRefuelRelaunch verifies the lifecycle of a reusable booster.
+// Expected result: landed with speed 0, refueled to 500, flying again after a second launch.
+func TestRocketLandRefuelRelaunch(t *testing.T) {
+	// Arrange
+	channel, _ := NewChannel("booster-1")
+	rocket := NewRocket(channel)
+	msgNums := make([]*MessageNumber, 6)
+	for i := range msgNums {
+		msgNums[i], _ = NewMessageNumber(i + 1)
+	}
+	speed, _ := NewSpeed(15000)
+	_ = rocket.Launch(msgNums[0], "Falcon-9", speed, MissionSatellite, 1)
+
+	// Act
+	landErr := rocket.Land(msgNums[1], "LZ-1", 2)
+	speedErr := rocket.IncreaseSpeed(msgNums[2], 100, 3)
+	refuelErr := rocket.Refuel(msgNums[2], 500, 3)
+	landed := rocket.GetStatus()
+	relaunchErr := rocket.Launch(msgNums[3], "Falcon-9", speed, MissionResupply, 4)
+
+	// Assert
+	if landErr != nil || refuelErr != nil || relaunchErr != nil {
+		t.Fatalf("Expected land, refuel and relaunch to succeed, got %v / %v / %v", landErr, refuelErr, relaunchErr)
+	}
+	if !errors.Is(speedErr, ErrInvalidTransition) {
+		t.Errorf("Expected speed changes to be refused on the ground, got %v", speedErr)
+	}
+	if landed != StatusLanded || rocket.GetStatus() != StatusFlying {
+		t.Errorf("Expected landed then flying, got %s then %s", landed, rocket.GetStatus())
+	}
+	if rocket.GetFuel() != 500 {
+		t.Errorf("Expected fuel 500, got %d", rocket.GetFuel())
+	}
+
+	replayed := NewRocket(channel)
+	_ = replayed.LoadFromHistory(rocket.GetUncommittedEvents())
+	if replayed.GetStatus() != StatusFlying || replayed.GetFuel() != 500 || replayed.GetMission() != MissionResupply {
+		t.Errorf("Replayed rocket differs: %+v", replayed.Snapshot(0))
+	}
+}
+
+// TestRocketLandingInvariants verifies that only flying rockets land.
+// Expected result: landing before launch and after an explosion is refused.
+func TestRocketLandingInvariants(t *testing.T) {
+	// Arrange
+	channel, _ := NewChannel("rocket-1")
+	rocket := NewRocket(channel)
+	msgNum1, _ := NewMessageNumber(1)
+	msgNum2, _ := NewMessageNumber(2)
+	msgNum3, _ := NewMessageNumber(3)
+	speed, _ := NewSpeed(15000)
+
+	// Act
+	beforeLaunch := rocket.Land(msgNum1, "LZ-1", 1)
+	_ = rocket.Launch(msgNum1, "Falcon-9", speed, MissionSatellite, 1)
+	_ = rocket.Explode(msgNum2, "PRESSURE", 2)
+	afterExplosion := rocket.Land(msgNum3, "LZ-1", 3)
+
+	// Assert
+	if !errors.Is(beforeLaunch, ErrNotLaunched) {
+		t.Errorf("Expected ErrNotLaunched, got %v", beforeLaunch)
+	}
+	if !errors.Is(afterExplosion, ErrRocketExploded) {
+		t.Errorf("Expected ErrRocketExploded, got %v", afterExplosion)
+	}
+}
+
+// TestRocketStageCountOnlyGoesDown verifies stage separation.
+// Expected result: 2 then 1 remaining stages accepted, 1 again refused, survives a snapshot.
+func TestRocketStageCountOnlyGoesDown(t *testing.T) {
+	// Arrange
+	channel, _ := NewChannel("rocket-1")
+	rocket := NewRocket(channel)
+	msgNums := make([]*MessageNumber, 4)
+	for i := range msgNums {
+		msgNums[i], _ = NewMessageNumber(i + 1)
+	}
+	speed, _ := NewSpeed(15000)
+	_ = rocket.Launch(msgNums[0], "Saturn-V", speed, MissionExploration, 1)
+
+	// Act
+	firstErr := rocket.SeparateStage(msgNums[1], 2, 2)
+	secondErr := rocket.SeparateStage(msgNums[2], 1, 3)
+	repeatErr := rocket.SeparateStage(msgNums[3], 1, 4)
+
+	// Assert
+	if firstErr != nil || secondErr != nil {
+		t.Fatalf("Expected separations to succeed, got %v / %v", firstErr, secondErr)
+	}
+	if !errors.Is(repeatErr, ErrStageCount) {
+		t.Errorf("Expected ErrStageCount, got %v", repeatErr)
+	}
+	restored := NewRocket(channel)
+	if err := restored.RestoreFromSnapshot(rocket.Snapshot(0)); err != nil {
+		t.Fatalf("Expected no error restoring, got %v", err)
+	}
+	if stages, ok := restored.GetRemainingStages(); !ok || stages != 1 {
+		t.Errorf("Expected 1 remaining stage after restore, got %d (known=%v)", stages, ok)
+	}
+}
+
+// testCatalog returns a catalog with a two-stage Falcon-9 limited to 30000 km/h and
+// satellite or resupply missions
+func testCatalog(t *testing.T, policy EnvelopePolicy) *RocketCatalog {
+	t.Helper()
+	catalog, err := NewRocketCatalog(policy, RocketTypeSpec{
+		Name:            "Falcon-9",
+		MaxSpeed:        30000,
+		Stages:          2,
+		AllowedMissions: []Mission{MissionSatellite, MissionResupply},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error building the catalog, got %v", err)
+	}
+	return catalog
+}
+
+// TestRocketEnvelopeRejects verifies that with the reject policy a command beyond the type's
+// envelope is refused, but still recorded.
+// Expected result: ErrEnvelopeExceeded, speed unchanged, a single rejected
+// RocketEnvelopeExceeded event that takes up the message number.
+func TestRocketEnvelopeRejects(t *testing.T) {
+	// Arrange
+	channel, _ := NewChannel("rocket-1")
+	rocket := NewRocket(channel)
+	rocket.SetCatalog(testCatalog(t, EnvelopeReject))
+	msgNum1, _ := NewMessageNumber(1)
+	msgNum2, _ := NewMessageNumber(2)
+	speed, _ := NewSpeed(25000)
+	if err := rocket.Launch(msgNum1, "falcon-9", speed, MissionSatellite, 1); err != nil {
+		t.Fatalf("Expected no error launching, got %v", err)
+	}
+	rocket.MarkEventsAsCommitted()
+
+	// Act
+	err := rocket.IncreaseSpeed(msgNum2, 10000, 2)
+
+	// Assert
+	if !errors.Is(err, ErrEnvelopeExceeded) {
+		t.Fatalf("Expected ErrEnvelopeExceeded, got %v", err)
+	}
+	if rocket.GetSpeed().Value() != 25000 {
+		t.Errorf("Expected speed 25000, got %d", rocket.GetSpeed().Value())
+	}
+	events := rocket.GetUncommittedEvents()
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 event, got %d", len(events))
+	}
+	exceeded, ok := events[0].(*RocketEnvelopeExceeded)
+	if !ok || !exceeded.Rejected || exceeded.Rule != EnvelopeRuleMaxSpeed {
+		t.Errorf("Expected a rejected max_speed RocketEnvelopeExceeded, got %+v", events[0])
+	}
+	if rocket.GetLastMessageNumber().Value() != 2 {
+		t.Errorf("Expected last message number 2, got %d", rocket.GetLastMessageNumber().Value())
+	}
+}
+
+// TestRocketEnvelopeWarns verifies that with the warn policy a command beyond the type's
+// envelope is applied and flagged.
+// Expected result: mission changed, followed by a RocketEnvelopeExceeded warning.
+func TestRocketEnvelopeWarns(t *testing.T) {
+	// Arrange
+	channel, _ := NewChannel("rocket-1")
+	rocket := NewRocket(channel)
+	rocket.SetCatalog(testCatalog(t, EnvelopeWarn))
+	msgNum1, _ := NewMessageNumber(1)
+	msgNum2, _ := NewMessageNumber(2)
+	speed, _ := NewSpeed(25000)
+	_ = rocket.Launch(msgNum1, "Falcon-9", speed, MissionSatellite, 1)
+	rocket.MarkEventsAsCommitted()
+
+	// Act
+	err := rocket.ChangeMission(msgNum2, MissionExploration, 2)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if rocket.GetMission() != MissionExploration {
+		t.Errorf("Expected mission exploration, got %s", rocket.GetMission())
+	}
+	events := rocket.GetUncommittedEvents()
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(events))
+	}
+	warning, ok := events[1].(*RocketEnvelopeExceeded)
+	if !ok || warning.Rejected || warning.Rule != EnvelopeRuleMission {
+		t.Errorf("Expected a mission RocketEnvelopeExceeded warning, got %+v", events[1])
+	}
+}
+
+// TestRocketLaunchTakesStagesFromCatalog verifies that a launch records the stage count of
+// the type, so separations are checked from the first one.
+// Expected result: 2 stages after launch; separating to 2 remaining fails with ErrStageCount.
+func TestRocketLaunchTakesStagesFromCatalog(t *testing.T) {
+	// Arrange
+	channel, _ := NewChannel("rocket-1")
+	rocket := NewRocket(channel)
+	rocket.SetCatalog(testCatalog(t, EnvelopeReject))
+	msgNum1, _ := NewMessageNumber(1)
+	msgNum2, _ := NewMessageNumber(2)
+	speed, _ := NewSpeed(1000)
+	_ = rocket.Launch(msgNum1, "Falcon-9", speed, MissionResupply, 1)
+
+	// Act
+	err := rocket.SeparateStage(msgNum2, 2, 2)
+
+	// Assert
+	if stages, ok := rocket.GetRemainingStages(); !ok || stages != 2 {
+		t.Errorf("Expected 2 known stages, got %d (known %t)", stages, ok)
+	}
+	if !errors.Is(err, ErrStageCount) {
+		t.Errorf("Expected ErrStageCount, got %v", err)
+	}
+}
+
+// TestRocketRelaunchKeepsSeparatedStages verifies that a relaunch does not restore the stages
+// of the catalog once some were separated.
+// Expected result: 1 remaining stage after the relaunch, also when replayed.
+func TestRocketRelaunchKeepsSeparatedStages(t *testing.T) {
+	// Arrange
+	channel, _ := NewChannel("booster-1")
+	rocket := NewRocket(channel)
+	rocket.SetCatalog(testCatalog(t, EnvelopeReject))
+	msgNums := make([]*MessageNumber, 4)
+	for i := range msgNums {
+		msgNums[i], _ = NewMessageNumber(i + 1)
+	}
+	speed, _ := NewSpeed(1000)
+	_ = rocket.Launch(msgNums[0], "Falcon-9", speed, MissionResupply, 1)
+	if err := rocket.SeparateStage(msgNums[1], 1, 2); err != nil {
+		t.Fatalf("Expected no error separating, got %v", err)
+	}
+	_ = rocket.Land(msgNums[2], "LZ-1", 3)
+
+	// Act
+	err := rocket.Launch(msgNums[3], "Falcon-9", speed, MissionResupply, 4)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error relaunching, got %v", err)
+	}
+	if stages, ok := rocket.GetRemainingStages(); !ok || stages != 1 {
+		t.Errorf("Expected 1 known stage, got %d (known %t)", stages, ok)
+	}
+	replayed := NewRocket(channel)
+	_ = replayed.LoadFromHistory(rocket.GetUncommittedEvents())
+	if stages, ok := replayed.GetRemainingStages(); !ok || stages != 1 {
+		t.Errorf("Expected 1 known stage after replay, got %d (known %t)", stages, ok)
+	}
+}
+
+// TestRocketSpeedOverflow verifies that increasing the speed beyond the int range is rejected.
+// Expected result: ErrInvalidPayload, speed unchanged.
+func TestRocketSpeedOverflow(t *testing.T) {
+	// Arrange
+	channel, _ := NewChannel("rocket-1")
+	rocket := NewRocket(channel)
+	msgNum1, _ := NewMessageNumber(1)
+	msgNum2, _ := NewMessageNumber(2)
+	speed, _ := NewSpeed(1000)
+	_ = rocket.Launch(msgNum1, "Falcon-9", speed, MissionResupply, 1)
+
+	// Act
+	err := rocket.IncreaseSpeed(msgNum2, math.MaxInt, 2)
+
+	// Assert
+	if !errors.Is(err, ErrInvalidPayload) {
+		t.Errorf("Expected ErrInvalidPayload, got %v", err)
+	}
+	if rocket.GetSpeed().Value() != 1000 {
+		t.Errorf("Expected speed 1000, got %d", rocket.GetSpeed().Value())
+	}
+}
+
+// TestMissionRegistryResolve verifies how producer missions map to categories.
+// Expected result: registered names resolve to their category keeping the raw text, unknown
+// ones fall into MissionUnknown (or fail in strict mode), built-ins cannot be re-categorized.
+func TestMissionRegistryResolve(t *testing.T) {
+	// Arrange
+	registry := NewMissionRegistry(false)
+	strict := NewMissionRegistry(true)
+	for _, r := range []*MissionRegistry{registry, strict} {
+		if err := r.Register("Mars Mission", MissionExploration); err != nil {
+			t.Fatalf("Expected no error registering, got %v", err)
+		}
+	}
+
+	// Act
+	mars, marsErr := registry.Resolve("MARS   mission")
+	unknown, unknownErr := registry.Resolve("Moon Tourism")
+	_, strictErr := strict.Resolve("Moon Tourism")
+	builtInErr := registry.Register("Satellite", MissionResupply)
+
+	// Assert
+	if marsErr != nil || mars.Category() != MissionExploration || mars.Raw() != "MARS   mission" {
+		t.Errorf("Expected exploration keeping the raw text, got %+v (%v)", mars, marsErr)
+	}
+	if unknownErr != nil || unknown.Category() != MissionUnknown || unknown.Raw() != "Moon Tourism" {
+		t.Errorf("Expected unknown keeping the raw text, got %+v (%v)", unknown, unknownErr)
+	}
+	if !errors.Is(strictErr, ErrUnknownMission) {
+		t.Errorf("Expected ErrUnknownMission in strict mode, got %v", strictErr)
+	}
+	if !errors.Is(builtInErr, ErrInvalidPayload) {
+		t.Errorf("Expected built-in categories to be fixed, got %v", builtInErr)
+	}
+}
+
+// TestRocketKeepsRawMission verifies that the producer's mission survives events and snapshots.
+// Expected result: raw mission "Mars Mission" after launch, replay and snapshot restore;
+// "ISS Cargo" after a mission change.
+func TestRocketKeepsRawMission(t *testing.T) {
+	// Arrange
+	channel, _ := NewChannel("rocket-1")
+	rocket := NewRocket(channel)
+	msgNum1, _ := NewMessageNumber(1)
+	msgNum2, _ := NewMessageNumber(2)
+	speed, _ := NewSpeed(1000)
+
+	// Act
+	_ = rocket.LaunchNamed(msgNum1, "Falcon-9", speed, NewMissionName("Mars Mission", MissionExploration), 1)
+	replayed := NewRocket(channel)
+	_ = replayed.LoadFromHistory(rocket.GetUncommittedEvents())
+	restored := NewRocket(channel)
+	_ = restored.RestoreFromSnapshot(rocket.Snapshot(1))
+	_ = rocket.ChangeMissionNamed(msgNum2, NewMissionName("ISS Cargo", MissionResupply), 2)
+
+	// Assert
+	for name, r := range map[string]*Rocket{"replayed": replayed, "restored": restored} {
+		if r.GetRawMission() != "Mars Mission" || r.GetMission() != MissionExploration {
+			t.Errorf("%s: expected Mars Mission (exploration), got %q (%s)", name, r.GetRawMission(), r.GetMission())
+		}
+	}
+	if rocket.GetRawMission() != "ISS Cargo" || rocket.GetMission() != MissionResupply {
+		t.Errorf("Expected ISS Cargo (resupply), got %q (%s)", rocket.GetRawMission(), rocket.GetMission())
+	}
+}
+
+// TestRocketTimeRegression verifies how message times that go back are handled.
+// Expected result: within the tolerance nothing is recorded; beyond it a warning follows the
+// command, or with Reject only a rejected RocketTimeRegressed event is recorded.
+func TestRocketTimeRegression(t *testing.T) {
+	tests := []struct {
+		name       string
+		policy     TimePolicy
+		time       int64
+		wantErr    bool
+		wantEvents []string
+		wantSpeed  int
+	}{
+		{"within tolerance", TimePolicy{Tolerance: time.Second}, 9500, false, []string{"rocket_speed_increased"}, 1100},
+		{"flagged", TimePolicy{Tolerance: time.Second}, 8000, false, []string{"rocket_speed_increased", "rocket_time_regressed"}, 1100},
+		{"rejected", TimePolicy{Tolerance: time.Second, Reject: true}, 8000, true, []string{"rocket_time_regressed"}, 1000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			channel, _ := NewChannel("rocket-1")
+			rocket := NewRocket(channel)
+			rocket.SetTimePolicy(&tt.policy)
+			msgNum1, _ := NewMessageNumber(1)
+			msgNum2, _ := NewMessageNumber(2)
+			speed, _ := NewSpeed(1000)
+			_ = rocket.Launch(msgNum1, "Falcon-9", speed, MissionSatellite, 10000)
+			rocket.MarkEventsAsCommitted()
+
+			// Act
+			err := rocket.IncreaseSpeed(msgNum2, 100, tt.time)
+
+			// Assert
+			if (err != nil) != tt.wantErr || tt.wantErr && !errors.Is(err, ErrTimeRegression) {
+				t.Fatalf("Expected error %t (ErrTimeRegression), got %v", tt.wantErr, err)
+			}
+			var types []string
+			for _, event := range rocket.GetUncommittedEvents() {
+				types = append(types, event.GetEventType())
+			}
+			if strings.Join(types, ",") != strings.Join(tt.wantEvents, ",") {
+				t.Errorf("Expected events %v, got %v", tt.wantEvents, types)
+			}
+			if rocket.GetSpeed().Value() != tt.wantSpeed {
+				t.Errorf("Expected speed %d, got %d", tt.wantSpeed, rocket.GetSpeed().Value())
+			}
+			if rocket.GetLastMessageTime() != 10000 {
+				t.Errorf("Expected the latest message time to stay 10000, got %d", rocket.GetLastMessageTime())
+			}
+			if rocket.GetLastMessageNumber().Value() != 2 {
+				t.Errorf("Expected message 2 to be recorded, got %d", rocket.GetLastMessageNumber().Value())
+			}
+		})
+	}
+}
+
+// TestRocketTimeRegressionWithEnvelopeRejection verifies that a message time flagged as going
+// back is still recorded when the command is then rejected for its envelope.
+// Expected result: ErrEnvelopeExceeded, with the rejected RocketEnvelopeExceeded event followed
+// by the RocketTimeRegressed warning.
+func TestRocketTimeRegressionWithEnvelopeRejection(t *testing.T) {
+	// Arrange
+	channel, _ := NewChannel("rocket-1")
+	rocket := NewRocket(channel)
+	rocket.SetCatalog(testCatalog(t, EnvelopeReject))
+	rocket.SetTimePolicy(&TimePolicy{Tolerance: time.Second})
+	msgNum1, _ := NewMessageNumber(1)
+	msgNum2, _ := NewMessageNumber(2)
+	speed, _ := NewSpeed(25000)
+	if err := rocket.Launch(msgNum1, "falcon-9", speed, MissionSatellite, 10000); err != nil {
+		t.Fatalf("Expected no error launching, got %v", err)
+	}
+	rocket.MarkEventsAsCommitted()
+
+	// Act
+	err := rocket.IncreaseSpeed(msgNum2, 10000, 8000)
+
+	// Assert
+	if !errors.Is(err, ErrEnvelopeExceeded) {
+		t.Fatalf("Expected ErrEnvelopeExceeded, got %v", err)
+	}
+	var types []string
+	for _, event := range rocket.GetUncommittedEvents() {
+		types = append(types, event.GetEventType())
+	}
+	if want := "rocket_envelope_exceeded,rocket_time_regressed"; strings.Join(types, ",") != want {
+		t.Errorf("Expected events %s, got %v", want, types)
+	}
+	if rocket.GetVersion() != 3 {
+		t.Errorf("Expected version 3, got %d", rocket.GetVersion())
+	}
+}
+
+// TestRocketTracksClockSkew verifies that the receive time of the last message and the skew
+// of the producer's clock are derived from the event metadata.
+// Expected result: received at 10000, producer 1500 ms ahead.
+func TestRocketTracksClockSkew(t *testing.T) {
+	// Arrange
+	channel, _ := NewChannel("rocket-1")
+	rocket := NewRocket(channel)
+	msgNum, _ := NewMessageNumber(1)
+	speed, _ := NewSpeed(1000)
+	rocket.SetCommandMetadata(EventMetadata{MetadataReceivedAt: time.UnixMilli(10000).UTC().Format(time.RFC3339Nano)})
+
+	// Act
+	_ = rocket.Launch(msgNum, "Falcon-9", speed, MissionSatellite, 11500)
+
+	// Assert
+	skew, ok := rocket.GetClockSkew()
+	if !ok || skew != 1500 {
+		t.Errorf("Expected a clock skew of 1500 ms, got %d (known %t)", skew, ok)
+	}
+	if rocket.GetLastReceivedAt() != 10000 {
+		t.Errorf("Expected received at 10000, got %d", rocket.GetLastReceivedAt())
+	}
+}
+
+// TestFleetMembership verifies the commands of a fleet and that its history rebuilds it.
+// Expected result: commands on a fleet that was not created and duplicate or missing members
+// are rejected; the events are numbered in sequence on the fleet's stream.
+func TestFleetMembership(t *testing.T) {
+	// Arrange
+	fleet, _ := NewFleet("alpha")
+	rocket1, _ := NewChannel("rocket-1")
+	rocket2, _ := NewChannel("rocket-2")
+
+	// Act
+	errNotCreated := fleet.AddRocket(rocket1, 1)
+	errCreate := fleet.Create("", 2)
+	errExists := fleet.Create("Alpha", 3)
+	_ = fleet.AddRocket(rocket1, 4)
+	_ = fleet.AddRocket(rocket2, 5)
+	errDuplicate := fleet.AddRocket(rocket1, 6)
+	_ = fleet.RemoveRocket(rocket1, 7)
+	errMissing := fleet.RemoveRocket(rocket1, 8)
+
+	// Assert
+	if !errors.Is(errNotCreated, ErrFleetNotFound) {
+		t.Errorf("Expected ErrFleetNotFound, got %v", errNotCreated)
+	}
+	if errCreate != nil || fleet.GetName() != "alpha" {
+		t.Errorf("Expected the fleet to be created with its ID as name, got %v %q", errCreate, fleet.GetName())
+	}
+	if !errors.Is(errExists, ErrFleetExists) {
+		t.Errorf("Expected ErrFleetExists, got %v", errExists)
+	}
+	if !errors.Is(errDuplicate, ErrAlreadyFleetMember) {
+		t.Errorf("Expected ErrAlreadyFleetMember, got %v", errDuplicate)
+	}
+	if !errors.Is(errMissing, ErrNotFleetMember) {
+		t.Errorf("Expected ErrNotFleetMember, got %v", errMissing)
+	}
+	if members := fleet.GetMembers(); len(members) != 1 || members[0] != "rocket-2" {
+		t.Errorf("Expected members [rocket-2], got %v", members)
+	}
+
+	events := fleet.GetUncommittedEvents()
+	if len(events) != 4 {
+		t.Fatalf("Expected 4 events, got %d", len(events))
+	}
+	for i, event := range events {
+		if event.GetChannel().Value() != "fleet:alpha" || event.GetMessageNumber().Value() != i+1 {
+			t.Errorf("Expected event %d on fleet:alpha, got %s #%d", i+1, event.GetChannel().Value(), event.GetMessageNumber().Value())
+		}
+	}
+	rebuilt, _ := NewFleet("alpha")
+	rebuilt.LoadFromHistory(events)
+	if rebuilt.GetVersion() != 4 || !rebuilt.HasMember("rocket-2") || rebuilt.HasMember("rocket-1") {
+		t.Errorf("Expected the history to rebuild the fleet, got version %d members %v", rebuilt.GetVersion(), rebuilt.GetMembers())
+	}
+	if _, err := NewFleet("bad/id"); !errors.Is(err, ErrInvalidFleet) {
+		t.Errorf("Expected ErrInvalidFleet, got %v", err)
+	}
+}
+
+// TestRocketErase verifies that an erased rocket forgets its state and accepts nothing more.
+// Expected result: a ChannelErased tombstone at the last message number; later commands and a
+// second erasure fail with ErrChannelErased.
+func TestRocketErase(t *testing.T) {
+	// Arrange
+	channel, _ := NewChannel("rocket-1")
+	rocket := NewRocket(channel)
+	msgNum1, _ := NewMessageNumber(1)
+	msgNum2, _ := NewMessageNumber(2)
+	speed, _ := NewSpeed(1000)
+	_ = rocket.LaunchNamed(msgNum1, "Falcon-9", speed, NewMissionName("Mars Mission", MissionExploration), 1000)
+
+	// Act
+	err := rocket.Erase("contract terminated", 2000)
+	errCommand := rocket.IncreaseSpeed(msgNum2, 100, 3000)
+	errAgain := rocket.Erase("again", 4000)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	events := rocket.GetUncommittedEvents()
+	tombstone, ok := events[len(events)-1].(*ChannelErased)
+	if !ok || tombstone.GetMessageNumber().Value() != 1 {
+		t.Fatalf("Expected a tombstone at message 1, got %T", events[len(events)-1])
+	}
+	if rocket.GetStatus() != StatusErased || rocket.GetRawMission() != "" || rocket.GetSpeed().Value() != 0 || rocket.GetRocketType() != "unknown" {
+		t.Errorf("Expected the state to be forgotten, got status %s mission %q speed %d type %s",
+			rocket.GetStatus(), rocket.GetRawMission(), rocket.GetSpeed().Value(), rocket.GetRocketType())
+	}
+	if !errors.Is(errCommand, ErrChannelErased) || !errors.Is(errAgain, ErrChannelErased) {
+		t.Errorf("Expected ErrChannelErased, got %v and %v", errCommand, errAgain)
+	}
+}
+
+// TestRocketVersion verifies that the aggregate version counts every event, whatever its
+// message number, and is stamped into the event metadata.
+// Expected result: consecutive versions 1..3 across a message number gap and a warning,
+// carried over by a snapshot.
+func TestRocketVersion(t *testing.T) {
+	// Arrange
+	channel, _ := NewChannel("rocket-1")
+	rocket := NewRocket(channel)
+	rocket.SetCatalog(testCatalog(t, EnvelopeWarn))
+	msgNum1, _ := NewMessageNumber(1)
+	msgNum5, _ := NewMessageNumber(5)
+	speed, _ := NewSpeed(25000)
+	_ = rocket.Launch(msgNum1, "Falcon-9", speed, MissionSatellite, 1)
+
+	// Act
+	err := rocket.ChangeMission(msgNum5, MissionExploration, 2)
+	restored := NewRocket(channel)
+	restoreErr := restored.RestoreFromSnapshot(rocket.Snapshot(3))
+
+	// Assert
+	if err != nil || restoreErr != nil {
+		t.Fatalf("Expected no errors, got %v and %v", err, restoreErr)
+	}
+	if rocket.GetVersion() != 3 || rocket.GetLastMessageNumber().Value() != 5 {
+		t.Errorf("Expected version 3 at message 5, got %d at %d", rocket.GetVersion(), rocket.GetLastMessageNumber().Value())
+	}
+	for i, event := range rocket.GetUncommittedEvents() {
+		if got := event.GetMetadata().Get(MetadataVersion); got != strconv.Itoa(i+1) {
+			t.Errorf("Expected event %d to carry version %d, got %q", i, i+1, got)
+		}
+	}
+	if restored.GetVersion() != 3 {
+		t.Errorf("Expected restored version 3, got %d", restored.GetVersion())
+	}
+}
+
+// TestRocketSkipMessages verifies that skipping a gap moves the rocket past the missing
+// messages without changing its state, and that only the gap right after the last message
+// can be skipped.
+// Expected result: message 5 is accepted after skipping 2-4; a skip not starting at the
+// expected message is rejected with ErrOutOfOrder.
+func TestRocketSkipMessages(t *testing.T) {
+	// Arrange
+	channel, _ := NewChannel("rocket-1")
+	rocket := NewRocket(channel)
+	msgNum1, _ := NewMessageNumber(1)
+	msgNum2, _ := NewMessageNumber(2)
+	msgNum3, _ := NewMessageNumber(3)
+	msgNum4, _ := NewMessageNumber(4)
+	msgNum5, _ := NewMessageNumber(5)
+	speed, _ := NewSpeed(1000)
+	_ = rocket.Launch(msgNum1, "Falcon-9", speed, MissionExploration, 1000)
+
+	// Act
+	errEarly := rocket.SkipMessages(msgNum3, msgNum4, "timeout", 2000)
+	err := rocket.SkipMessages(msgNum2, msgNum4, "timeout", 2000)
+	errNext := rocket.IncreaseSpeed(msgNum5, 100, 3000)
+
+	// Assert
+	if !errors.Is(errEarly, ErrOutOfOrder) {
+		t.Errorf("Expected ErrOutOfOrder, got %v", errEarly)
+	}
+	if err != nil || errNext != nil {
+		t.Fatalf("Expected no error, got %v and %v", err, errNext)
+	}
+	events := rocket.GetUncommittedEvents()
+	skipped, ok := events[1].(*MessagesSkipped)
+	if !ok || skipped.From != 2 || skipped.GetMessageNumber().Value() != 4 {
+		t.Fatalf("Expected messages 2-4 skipped, got %+v", events[1])
+	}
+	if rocket.GetLastMessageNumber().Value() != 5 || rocket.GetSpeed().Value() != 1100 || rocket.GetVersion() != 3 {
+		t.Errorf("Expected message 5 at speed 1100 and version 3, got %d, %d and %d",
+			rocket.GetLastMessageNumber().Value(), rocket.GetSpeed().Value(), rocket.GetVersion())
+	}
+}
@@ -0,0 +1,85 @@
+package domain
+
+import "fmt"
+
+// RocketSnapshot is the serialized state of a rocket at a given version
+type RocketSnapshot struct {
+	Channel           string `json:"channel"`
+	RocketType        string `json:"rocketType"`
+	Status            string `json:"status"`
+	Speed             int    `json:"speed"`
+	Mission           string `json:"mission"`
+	RawMission        string `json:"rawMission,omitempty"`
+	Fuel              int    `json:"fuel,omitempty"`
+	Stages            *int   `json:"stages,omitempty"` // remaining stages, nil while unknown
+	LastMessageNumber int    `json:"lastMessageNumber"`
+	Version           int    `json:"version"` // number of events the snapshot covers
+	LastMessageTime   int64  `json:"lastMessageTime,omitempty"`
+	LastReceivedAt    int64  `json:"lastReceivedAt,omitempty"`
+	ClockSkew         int64  `json:"clockSkewMs,omitempty"`
+	TakenAt           int64  `json:"takenAt"`
+}
+
+// Snapshot captures the current (committed) state of the rocket
+func (r *Rocket) Snapshot(takenAt int64) *RocketSnapshot {
+	snapshot := &RocketSnapshot{
+		Channel:           r.channel.Value(),
+		RocketType:        r.rocketType,
+		Status:            string(r.status),
+		Speed:             r.speed.Value(),
+		Mission:           string(r.mission),
+		RawMission:        r.rawMission,
+		Fuel:              r.fuel,
+		LastMessageNumber: r.lastMessageNumber.Value(),
+		Version:           r.version,
+		LastMessageTime:   r.lastMessageTime,
+		LastReceivedAt:    r.lastReceivedAt,
+		ClockSkew:         r.clockSkew,
+		TakenAt:           takenAt,
+	}
+	if r.stagesKnown {
+		stages := r.stages
+		snapshot.Stages = &stages
+	}
+	return snapshot
+}
+
+// RestoreFromSnapshot replaces the rocket state with the one captured in a snapshot.
+// The events after the first snapshot.Version ones must then be replayed with LoadFromHistory.
+func (r *Rocket) RestoreFromSnapshot(snapshot *RocketSnapshot) error {
+	if snapshot == nil {
+		return fmt.Errorf("snapshot cannot be nil")
+	}
+	if snapshot.Channel != r.channel.Value() {
+		return fmt.Errorf("snapshot of channel %s cannot restore rocket %s", snapshot.Channel, r.channel.Value())
+	}
+	speed, err := NewSpeed(snapshot.Speed)
+	if err != nil {
+		return fmt.Errorf("invalid snapshot speed: %w", err)
+	}
+	status := RocketStatus(snapshot.Status)
+	if !status.IsKnown() {
+		return fmt.Errorf("invalid snapshot status %q", snapshot.Status)
+	}
+	lastMessageNumber := &MessageNumber{value: 0}
+	if snapshot.LastMessageNumber > 0 {
+		lastMessageNumber, _ = NewMessageNumber(snapshot.LastMessageNumber)
+	}
+
+	r.rocketType = snapshot.RocketType
+	r.status = status
+	r.speed = speed
+	r.mission = Mission(snapshot.Mission)
+	r.rawMission = snapshot.RawMission
+	r.fuel = snapshot.Fuel
+	r.stages, r.stagesKnown = 0, snapshot.Stages != nil
+	if snapshot.Stages != nil {
+		r.stages = *snapshot.Stages
+	}
+	r.lastMessageNumber = lastMessageNumber
+	r.version = snapshot.Version
+	r.lastMessageTime = snapshot.LastMessageTime
+	r.lastReceivedAt = snapshot.LastReceivedAt
+	r.clockSkew = snapshot.ClockSkew
+	return nil
+}
@@ -0,0 +1,24 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// DefaultTenantID is the tenant of requests that do not name one
+const DefaultTenantID = "default"
+
+// ErrInvalidTenant is returned for tenant IDs that cannot name a store partition
+var ErrInvalidTenant = errors.New("invalid tenant")
+
+// tenantIDPattern keeps tenant IDs usable as directory and Kafka topic names
+var tenantIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// ValidateTenantID checks that id can identify a tenant
+func ValidateTenantID(id string) error {
+	if !tenantIDPattern.MatchString(id) {
+		return fmt.Errorf("%w: %q (use 1-64 lowercase letters, digits, '-' or '_')", ErrInvalidTenant, id)
+	}
+	return nil
+}
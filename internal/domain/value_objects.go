@@ -2,7 +2,9 @@ package domain
 
 import (
 	"fmt"
+	"math"
 	"strings"
+	"time"
 )
 
 // Channel represents a communication channel for a rocket
@@ -13,7 +15,7 @@ type Channel struct {
 // NewChannel creates a new channel
 func NewChannel(value string) (*Channel, error) {
 	if strings.TrimSpace(value) == "" {
-		return nil, fmt.Errorf("channel cannot be empty")
+		return nil, fmt.Errorf("%w: channel cannot be empty", ErrInvalidPayload)
 	}
 	return &Channel{value: value}, nil
 }
@@ -31,7 +33,7 @@ type MessageNumber struct {
 // NewMessageNumber creates a new message number
 func NewMessageNumber(value int) (*MessageNumber, error) {
 	if value <= 0 {
-		return nil, fmt.Errorf("message number must be positive")
+		return nil, fmt.Errorf("%w: message number must be positive", ErrInvalidPayload)
 	}
 	return &MessageNumber{value: value}, nil
 }
@@ -49,7 +51,7 @@ type Speed struct {
 // NewSpeed creates a new speed
 func NewSpeed(value int) (*Speed, error) {
 	if value < 0 {
-		return nil, fmt.Errorf("speed cannot be negative")
+		return nil, fmt.Errorf("%w: speed cannot be negative", ErrInvalidPayload)
 	}
 	return &Speed{value: value}, nil
 }
@@ -59,9 +61,12 @@ func (s *Speed) Value() int {
 	return s.value
 }
 
-// Increase increases the speed
-func (s *Speed) Increase(delta int) *Speed {
-	return &Speed{value: s.value + delta}
+// Increase increases the speed; it fails when the result does not fit in an int
+func (s *Speed) Increase(delta int) (*Speed, error) {
+	if delta > math.MaxInt-s.value {
+		return nil, fmt.Errorf("%w: speed %d + %d overflows", ErrInvalidPayload, s.value, delta)
+	}
+	return &Speed{value: s.value + delta}, nil
 }
 
 // Decrease decreases the speed
@@ -85,12 +90,10 @@ const (
 // NewMission creates a new mission
 func NewMission(value string) Mission {
 	m := Mission(strings.ToLower(value))
-	switch m {
-	case MissionExploration, MissionSatellite, MissionResupply:
+	if m.IsCategory() {
 		return m
-	default:
-		return MissionUnknown
 	}
+	return MissionUnknown
 }
 
 // MessageTime represents the time of a message
@@ -101,7 +104,7 @@ type MessageTime struct {
 // NewMessageTime creates a new message time
 func NewMessageTime(value int64) (*MessageTime, error) {
 	if value <= 0 {
-		return nil, fmt.Errorf("message time must be positive")
+		return nil, fmt.Errorf("%w: message time must be positive", ErrInvalidPayload)
 	}
 	return &MessageTime{value: value}, nil
 }
@@ -111,11 +114,20 @@ func (m *MessageTime) Value() int64 {
 	return m.value
 }
 
-// RocketStatus enumerates the possible states of a rocket
+// TimePolicy decides how a rocket handles a message whose time is before the latest message
+// time of its channel
+type TimePolicy struct {
+	Tolerance time.Duration // regressions up to this long are accepted silently
+	Reject    bool          // reject regressions beyond the tolerance instead of flagging them
+}
+
+// RocketStatus enumerates the possible states of a rocket (see rocketTransitions)
 type RocketStatus string
 
 const (
-	StatusLaunched RocketStatus = "launched"
-	StatusFlying   RocketStatus = "flying"
-	StatusExploded RocketStatus = "exploded"
+	StatusNotLaunched RocketStatus = "not_launched"
+	StatusFlying      RocketStatus = "flying"
+	StatusLanded      RocketStatus = "landed"
+	StatusExploded    RocketStatus = "exploded"
+	StatusErased      RocketStatus = "erased"
 )
@@ -0,0 +1,19 @@
+//go:build !unix
+
+package infrastructure
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// lockDir opens the lock file of dir. Without flock the directory is not actually locked:
+// keeping a second process away is up to the operator on these platforms.
+func lockDir(dir string) (*os.File, error) {
+	f, err := os.OpenFile(filepath.Join(dir, lockFileName), os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+	return f, nil
+}
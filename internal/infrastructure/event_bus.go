@@ -0,0 +1,221 @@
+package infrastructure
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+
+	"rockets/internal/domain"
+)
+
+// defaultBusQueueSize is how many events an async subscription holds before new ones are dropped
+const defaultBusQueueSize = 1024
+
+// DeliveryMode selects how a subscription receives its events
+type DeliveryMode int
+
+const (
+	// DeliverSync runs the handler on the committing goroutine, before Save returns.
+	// It must be quick and must not write through the repository that published the event.
+	DeliverSync DeliveryMode = iota
+	// DeliverAsync queues the events for a goroutine of the subscription, which handles them
+	// in commit order. Events that do not fit in the queue are dropped.
+	DeliverAsync
+)
+
+func (m DeliveryMode) String() string {
+	if m == DeliverAsync {
+		return "async"
+	}
+	return "sync"
+}
+
+// EventBusStats counts what the bus did with the events it was given
+type EventBusStats struct {
+	Delivered int64 // handled without error
+	Failed    int64 // the handler returned an error or panicked
+	Dropped   int64 // did not fit in the queue of an async subscription
+}
+
+// EventBus dispatches committed events to the handlers subscribed to their type
+// (domain.EventPublisher). A failing, panicking or slow handler never fails the write that
+// published the event: failures are logged and counted, and async queues drop what they cannot hold.
+type EventBus struct {
+	queueSize int
+	onFailure func(eventType string, err error)
+
+	mu            sync.RWMutex
+	subscriptions []*busSubscription
+	closed        bool
+	wg            sync.WaitGroup
+
+	delivered atomic.Int64
+	failed    atomic.Int64
+	dropped   atomic.Int64
+}
+
+// busSubscription is one handler and the events it accepts
+type busSubscription struct {
+	mode    DeliveryMode
+	accepts func(domain.DomainEvent) bool
+	handle  func(domain.DomainEvent) error
+	queue   chan domain.DomainEvent // async only
+}
+
+// EventBusOption configures optional features of the EventBus
+type EventBusOption func(*EventBus)
+
+// WithBusQueueSize sets how many events each async subscription can hold (default 1024)
+func WithBusQueueSize(size int) EventBusOption {
+	return func(b *EventBus) {
+		if size > 0 {
+			b.queueSize = size
+		}
+	}
+}
+
+// WithHandlerFailureHook registers a function called with the event type whenever a handler fails
+func WithHandlerFailureHook(hook func(eventType string, err error)) EventBusOption {
+	return func(b *EventBus) {
+		b.onFailure = hook
+	}
+}
+
+// NewEventBus creates an event bus without subscriptions
+func NewEventBus(opts ...EventBusOption) *EventBus {
+	b := &EventBus{queueSize: defaultBusQueueSize}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// Subscribe registers handler for the events of type E (e.g. *domain.RocketLaunched, or
+// domain.DomainEvent for every event). Subscriptions only see events published after them.
+func Subscribe[E domain.DomainEvent](bus *EventBus, mode DeliveryMode, handler func(E) error) {
+	sub := &busSubscription{
+		mode: mode,
+		accepts: func(event domain.DomainEvent) bool {
+			_, ok := event.(E)
+			return ok
+		},
+		handle: func(event domain.DomainEvent) error {
+			return handler(event.(E))
+		},
+	}
+
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	if bus.closed {
+		return
+	}
+	if mode == DeliverAsync {
+		sub.queue = make(chan domain.DomainEvent, bus.queueSize)
+		bus.wg.Add(1)
+		go bus.run(sub)
+	}
+	bus.subscriptions = append(bus.subscriptions, sub)
+}
+
+// Publish hands the events of a committed batch to their subscriptions, in order
+func (b *EventBus) Publish(events []domain.DomainEvent) {
+	b.mu.RLock()
+	subscriptions, closed := b.subscriptions, b.closed
+	b.mu.RUnlock()
+	if closed {
+		return
+	}
+
+	for _, event := range events {
+		for _, sub := range subscriptions {
+			if !sub.accepts(event) {
+				continue
+			}
+			if sub.mode == DeliverSync {
+				b.dispatch(sub, event)
+				continue
+			}
+			b.enqueue(sub, event)
+		}
+	}
+}
+
+// enqueue queues an event for an async subscription, dropping it when the queue is full
+func (b *EventBus) enqueue(sub *busSubscription, event domain.DomainEvent) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return
+	}
+	select {
+	case sub.queue <- event:
+	default:
+		b.dropped.Add(1)
+		slog.Warn("Event handler queue full, event dropped",
+			"type", event.GetEventType(),
+			"channel", event.GetChannel().Value(),
+			"message_number", event.GetMessageNumber().Value())
+	}
+}
+
+// run handles the queued events of an async subscription until the bus is closed
+func (b *EventBus) run(sub *busSubscription) {
+	defer b.wg.Done()
+	for event := range sub.queue {
+		b.dispatch(sub, event)
+	}
+}
+
+// dispatch runs a handler, turning a panic into an error
+func (b *EventBus) dispatch(sub *busSubscription, event domain.DomainEvent) {
+	err := func() (err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				err = fmt.Errorf("handler panicked: %v", p)
+			}
+		}()
+		return sub.handle(event)
+	}()
+	if err == nil {
+		b.delivered.Add(1)
+		return
+	}
+
+	b.failed.Add(1)
+	slog.Error("Event handler failed",
+		"type", event.GetEventType(),
+		"channel", event.GetChannel().Value(),
+		"message_number", event.GetMessageNumber().Value(),
+		"mode", sub.mode,
+		"err", err)
+	if b.onFailure != nil {
+		b.onFailure(event.GetEventType(), err)
+	}
+}
+
+// Stats returns the delivery counters of the bus
+func (b *EventBus) Stats() EventBusStats {
+	return EventBusStats{
+		Delivered: b.delivered.Load(),
+		Failed:    b.failed.Load(),
+		Dropped:   b.dropped.Load(),
+	}
+}
+
+// Close stops accepting events and waits until the async subscriptions handled the queued ones
+func (b *EventBus) Close() {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return
+	}
+	b.closed = true
+	for _, sub := range b.subscriptions {
+		if sub.queue != nil {
+			close(sub.queue)
+		}
+	}
+	b.mu.Unlock()
+	b.wg.Wait()
+}
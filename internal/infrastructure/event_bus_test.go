@@ -0,0 +1,111 @@
+package infrastructure
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"rockets/internal/domain"
+)
+
+// TestEventBusDeliversCommittedEvents verifies that the repository publishes a batch only once it is stored.
+// Expected result: typed handlers see the launch and the speed change they subscribed to; a save
+// lost to a concurrent writer publishes nothing.
+func TestEventBusDeliversCommittedEvents(t *testing.T) {
+	// Arrange
+	store := newMemoryStore(t)
+	bus := NewEventBus()
+	var launched []*domain.RocketLaunched
+	var all []domain.DomainEvent
+	Subscribe(bus, DeliverSync, func(e *domain.RocketLaunched) error {
+		launched = append(launched, e)
+		return nil
+	})
+	Subscribe(bus, DeliverSync, func(e domain.DomainEvent) error {
+		all = append(all, e)
+		return nil
+	})
+	repository := NewRocketRepository(store, WithEventPublisher(bus))
+	channel, _ := domain.NewChannel("rocket-bus")
+	rocket, _ := repository.GetByChannel(channel)
+	msgNum, _ := domain.NewMessageNumber(1)
+	speed, _ := domain.NewSpeed(10000)
+	_ = rocket.Launch(msgNum, "Falcon-9", speed, domain.MissionExploration, 1000)
+	stale := domain.NewRocket(channel) // loaded before the launch was stored
+	_ = stale.Launch(msgNum, "Falcon-9", speed, domain.MissionExploration, 1000)
+
+	// Act
+	saveErr := repository.Save(rocket)
+	conflictErr := repository.Save(stale)
+	msgNum, _ = domain.NewMessageNumber(2)
+	rocket, _ = repository.GetByChannel(channel)
+	_ = rocket.IncreaseSpeed(msgNum, 100, 2000)
+	_ = repository.Save(rocket)
+
+	// Assert
+	if saveErr != nil || !errors.Is(conflictErr, domain.ErrConcurrencyConflict) {
+		t.Fatalf("Expected the first save to succeed and the stale one to conflict, got %v and %v", saveErr, conflictErr)
+	}
+	if len(launched) != 1 {
+		t.Errorf("Expected 1 launch delivered, got %d", len(launched))
+	}
+	if len(all) != 2 || all[1].GetEventType() != "rocket_speed_increased" {
+		t.Errorf("Expected the launch and the speed increase, got %v", all)
+	}
+	if stats := bus.Stats(); stats.Delivered != 3 {
+		t.Errorf("Expected 3 deliveries, got %+v", stats)
+	}
+}
+
+// TestEventBusIsolatesHandlerFailures verifies that failing handlers do not affect the write
+// or the other subscriptions.
+// Expected result: the save succeeds, the error and the panic are counted and reported, and
+// the async handler receives every event in commit order once the bus is closed.
+func TestEventBusIsolatesHandlerFailures(t *testing.T) {
+	// Arrange
+	store := newMemoryStore(t)
+	var mu sync.Mutex
+	var failures []string
+	bus := NewEventBus(WithHandlerFailureHook(func(eventType string, err error) {
+		mu.Lock()
+		failures = append(failures, eventType)
+		mu.Unlock()
+	}))
+	Subscribe(bus, DeliverSync, func(*domain.RocketLaunched) error {
+		return errors.New("projection unavailable")
+	})
+	Subscribe(bus, DeliverAsync, func(*domain.RocketSpeedIncreased) error {
+		panic("bad handler")
+	})
+	var numbers []int
+	Subscribe(bus, DeliverAsync, func(e domain.DomainEvent) error {
+		numbers = append(numbers, e.GetMessageNumber().Value())
+		return nil
+	})
+	repository := NewRocketRepository(store, WithEventPublisher(bus))
+	channel, _ := domain.NewChannel("rocket-failing")
+	rocket, _ := repository.GetByChannel(channel)
+	msgNum, _ := domain.NewMessageNumber(1)
+	speed, _ := domain.NewSpeed(10000)
+	_ = rocket.Launch(msgNum, "Falcon-9", speed, domain.MissionExploration, 1000)
+	msgNum, _ = domain.NewMessageNumber(2)
+	_ = rocket.IncreaseSpeed(msgNum, 100, 2000)
+
+	// Act
+	err := repository.Save(rocket)
+	bus.Close()
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected the save to succeed, got %v", err)
+	}
+	if len(numbers) != 2 || numbers[0] != 1 || numbers[1] != 2 {
+		t.Errorf("Expected messages 1 and 2 in order, got %v", numbers)
+	}
+	if stats := bus.Stats(); stats.Failed != 2 || stats.Delivered != 2 {
+		t.Errorf("Expected 2 failures and 2 deliveries, got %+v", stats)
+	}
+	if len(failures) != 2 {
+		t.Errorf("Expected the failure hook to be called twice, got %v", failures)
+	}
+}
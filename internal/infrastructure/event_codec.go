@@ -0,0 +1,361 @@
+package infrastructure
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"rockets/internal/domain"
+)
+
+// EventEnvelope is the versioned wire format of a domain event
+type EventEnvelope struct {
+	Type          string            `json:"type"`
+	SchemaVersion int               `json:"schemaVersion"`
+	Payload       json.RawMessage   `json:"payload"`
+	Metadata      map[string]string `json:"metadata,omitempty"`
+	// An encrypted event keeps only its position (channel and message number) in Payload; the
+	// full payload and the metadata are sealed with the data key of its channel. A redacted
+	// event has lost everything but its position.
+	Sealed   []byte `json:"sealed,omitempty"`
+	Redacted bool   `json:"redacted,omitempty"`
+}
+
+// EventEncoder turns an event into its payload (any JSON-serializable value)
+type EventEncoder func(event domain.DomainEvent) (interface{}, error)
+
+// EventDecoder rebuilds an event from a payload in the current schema version and the
+// metadata stored next to it
+type EventDecoder func(payload json.RawMessage, metadata domain.EventMetadata) (domain.DomainEvent, error)
+
+// Upcaster rewrites a payload from one schema version to the next
+type Upcaster func(payload json.RawMessage) (json.RawMessage, error)
+
+// codecEntry is the registration of one event type
+type codecEntry struct {
+	version   int
+	encode    EventEncoder
+	decode    EventDecoder
+	upcasters map[int]Upcaster // from version -> from version + 1
+}
+
+// EventCodec converts domain events to and from versioned envelopes.
+// Each event type is registered with its current schema version; payloads written with an
+// older version are brought up to date by the chain of upcasters registered for the type.
+type EventCodec struct {
+	mu    sync.RWMutex
+	types map[string]*codecEntry
+	keys  KeyStore // encrypts payloads when set
+}
+
+// NewEventCodec creates a codec with no event types registered
+func NewEventCodec() *EventCodec {
+	return &EventCodec{
+		types: make(map[string]*codecEntry),
+	}
+}
+
+// DefaultEventCodec creates a codec with every rocket event registered
+func DefaultEventCodec() *EventCodec {
+	c := NewEventCodec()
+	registerRocketEvents(c)
+	registerFleetEvents(c)
+	return c
+}
+
+// EncryptedEventCodec creates a default codec that encrypts the payload and metadata of every
+// event with the data key of its channel. Channel tombstones stay in clear, and events whose
+// key was destroyed decode as redacted events.
+func EncryptedEventCodec(keys KeyStore) *EventCodec {
+	c := DefaultEventCodec()
+	c.keys = keys
+	return c
+}
+
+// unsealedEventType is written in clear even by an encrypting codec: it carries no customer
+// data and must stay readable once the key of its channel is gone
+const unsealedEventType = "channel_erased"
+
+// Register adds (or replaces) the encoder and decoder of an event type at a schema version
+func (c *EventCodec) Register(eventType string, version int, encode EventEncoder, decode EventDecoder) error {
+	if eventType == "" {
+		return fmt.Errorf("event type cannot be empty")
+	}
+	if version <= 0 {
+		return fmt.Errorf("schema version must be positive")
+	}
+	if encode == nil || decode == nil {
+		return fmt.Errorf("encoder and decoder are required")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.types[eventType]
+	if !ok {
+		entry = &codecEntry{upcasters: make(map[int]Upcaster)}
+		c.types[eventType] = entry
+	}
+	entry.version = version
+	entry.encode = encode
+	entry.decode = decode
+	return nil
+}
+
+// RegisterUpcaster adds the function that rewrites payloads of eventType from fromVersion to fromVersion+1
+func (c *EventCodec) RegisterUpcaster(eventType string, fromVersion int, upcaster Upcaster) error {
+	if fromVersion <= 0 {
+		return fmt.Errorf("schema version must be positive")
+	}
+	if upcaster == nil {
+		return fmt.Errorf("upcaster cannot be nil")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.types[eventType]
+	if !ok {
+		return fmt.Errorf("event type %s is not registered", eventType)
+	}
+	entry.upcasters[fromVersion] = upcaster
+	return nil
+}
+
+// Encode wraps an event in an envelope at the current schema version of its type
+func (c *EventCodec) Encode(event domain.DomainEvent, metadata map[string]string) (*EventEnvelope, error) {
+	if event == nil {
+		return nil, fmt.Errorf("event cannot be nil")
+	}
+	if redacted, ok := event.(*domain.RedactedEvent); ok {
+		position, err := json.Marshal(newEventPosition(redacted))
+		if err != nil {
+			return nil, err
+		}
+		return &EventEnvelope{Type: redacted.Type, SchemaVersion: 1, Payload: position, Redacted: true}, nil
+	}
+	c.mu.RLock()
+	entry, ok := c.types[event.GetEventType()]
+	c.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unsupported event type: %s", event.GetEventType())
+	}
+
+	payload, err := entry.encode(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", event.GetEventType(), err)
+	}
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", event.GetEventType(), err)
+	}
+
+	envelope := &EventEnvelope{
+		Type:          event.GetEventType(),
+		SchemaVersion: entry.version,
+		Payload:       raw,
+		Metadata:      copyMetadata(metadata),
+	}
+	if c.keys != nil && envelope.Type != unsealedEventType {
+		if err := c.seal(envelope, newEventPosition(event)); err != nil {
+			return nil, fmt.Errorf("failed to encrypt %s: %w", envelope.Type, err)
+		}
+	}
+	return envelope, nil
+}
+
+// eventPosition is what an encrypted or redacted envelope keeps in clear: enough to index the
+// event and find the key of its channel
+type eventPosition struct {
+	Channel       string `json:"channel"`
+	MessageNumber int    `json:"messageNumber"`
+}
+
+func newEventPosition(event domain.DomainEvent) eventPosition {
+	return eventPosition{Channel: event.GetChannel().Value(), MessageNumber: event.GetMessageNumber().Value()}
+}
+
+// sealedContent is the plaintext of a sealed envelope
+type sealedContent struct {
+	Payload  json.RawMessage   `json:"payload"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// seal moves the payload and the metadata of an envelope into Sealed, leaving its position in clear
+func (c *EventCodec) seal(envelope *EventEnvelope, position eventPosition) error {
+	key, err := c.keys.DataKey(position.Channel)
+	if err != nil {
+		return err
+	}
+	content, err := json.Marshal(sealedContent{Payload: envelope.Payload, Metadata: envelope.Metadata})
+	if err != nil {
+		return err
+	}
+	sealed, err := sealPayload(key, content, sealingData(envelope.Type, position))
+	if err != nil {
+		return err
+	}
+	inClear, err := json.Marshal(position)
+	if err != nil {
+		return err
+	}
+	envelope.Payload, envelope.Metadata, envelope.Sealed = inClear, nil, sealed
+	return nil
+}
+
+// unseal returns the payload and metadata of a sealed envelope, or nil if the key of its
+// channel was destroyed
+func (c *EventCodec) unseal(envelope *EventEnvelope, position eventPosition) (*sealedContent, error) {
+	if c.keys == nil {
+		return nil, fmt.Errorf("%s payload is encrypted and no key store is configured", envelope.Type)
+	}
+	key, err := c.keys.LookupKey(position.Channel)
+	if errors.Is(err, ErrKeyDestroyed) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := openPayload(key, envelope.Sealed, sealingData(envelope.Type, position))
+	if err != nil {
+		return nil, err
+	}
+	var content sealedContent
+	if err := json.Unmarshal(plaintext, &content); err != nil {
+		return nil, fmt.Errorf("invalid sealed %s: %w", envelope.Type, err)
+	}
+	return &content, nil
+}
+
+// sealingData is the additional data a sealed payload is bound to: the event's type and position
+func sealingData(eventType string, position eventPosition) []byte {
+	return []byte(fmt.Sprintf("%s\x00%s\x00%d", eventType, position.Channel, position.MessageNumber))
+}
+
+// redactedEvent rebuilds the redacted form of an event from the position of its envelope
+func redactedEvent(envelope *EventEnvelope, position eventPosition) (domain.DomainEvent, error) {
+	channel, msgNum, err := eventHeader{Channel: position.Channel, MessageNumber: position.MessageNumber}.values()
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s position: %w", envelope.Type, err)
+	}
+	return &domain.RedactedEvent{Type: envelope.Type, Channel: channel, MessageNumber: msgNum}, nil
+}
+
+// Decode rebuilds the event of an envelope, upcasting its payload first if needed
+func (c *EventCodec) Decode(envelope *EventEnvelope) (domain.DomainEvent, error) {
+	if envelope == nil {
+		return nil, fmt.Errorf("envelope cannot be nil")
+	}
+	payload, metadata := envelope.Payload, envelope.Metadata
+	if envelope.Redacted || len(envelope.Sealed) > 0 {
+		var position eventPosition
+		if err := json.Unmarshal(envelope.Payload, &position); err != nil {
+			return nil, fmt.Errorf("invalid %s position: %w", envelope.Type, err)
+		}
+		if envelope.Redacted {
+			return redactedEvent(envelope, position)
+		}
+		unsealed, err := c.unseal(envelope, position)
+		if err != nil {
+			return nil, err
+		}
+		if unsealed == nil {
+			return redactedEvent(envelope, position)
+		}
+		payload, metadata = unsealed.Payload, unsealed.Metadata
+	}
+
+	c.mu.RLock()
+	entry, ok := c.types[envelope.Type]
+	c.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown event type: %s", envelope.Type)
+	}
+	if envelope.SchemaVersion <= 0 || envelope.SchemaVersion > entry.version {
+		return nil, fmt.Errorf("unsupported schema version %d for %s (current %d)", envelope.SchemaVersion, envelope.Type, entry.version)
+	}
+
+	for v := envelope.SchemaVersion; v < entry.version; v++ {
+		c.mu.RLock()
+		upcaster, ok := entry.upcasters[v]
+		c.mu.RUnlock()
+		if !ok {
+			return nil, fmt.Errorf("no upcaster for %s from schema version %d", envelope.Type, v)
+		}
+		upcasted, err := upcaster(payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to upcast %s from schema version %d: %w", envelope.Type, v, err)
+		}
+		payload = upcasted
+	}
+
+	event, err := entry.decode(payload, domain.EventMetadata(copyMetadata(metadata)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s payload: %w", envelope.Type, err)
+	}
+	return event, nil
+}
+
+// Marshal encodes an event straight to envelope JSON
+func (c *EventCodec) Marshal(event domain.DomainEvent, metadata map[string]string) ([]byte, error) {
+	envelope, err := c.Encode(event, metadata)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(envelope)
+}
+
+// Unmarshal decodes envelope JSON, returning the event and its envelope
+func (c *EventCodec) Unmarshal(data []byte) (domain.DomainEvent, *EventEnvelope, error) {
+	var envelope EventEnvelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, nil, fmt.Errorf("invalid envelope: %w", err)
+	}
+	event, err := c.Decode(&envelope)
+	if err != nil {
+		return nil, nil, err
+	}
+	return event, &envelope, nil
+}
+
+// Serialize encodes an event and its metadata to envelope JSON (domain.EventSerializer)
+func (c *EventCodec) Serialize(event domain.DomainEvent) ([]byte, error) {
+	return c.Marshal(event, event.GetMetadata())
+}
+
+// Deserialize decodes envelope JSON to an event (domain.EventSerializer)
+func (c *EventCodec) Deserialize(data []byte) (domain.DomainEvent, error) {
+	event, _, err := c.Unmarshal(data)
+	return event, err
+}
+
+// typedEncoder adapts a payload builder for one concrete event type to an EventEncoder
+func typedEncoder[E domain.DomainEvent, P any](build func(E) P) EventEncoder {
+	return func(event domain.DomainEvent) (interface{}, error) {
+		e, ok := event.(E)
+		if !ok {
+			return nil, fmt.Errorf("unexpected event %T", event)
+		}
+		return build(e), nil
+	}
+}
+
+// typedDecoder adapts a payload parser to an EventDecoder
+func typedDecoder[P any](parse func(P, domain.EventMetadata) (domain.DomainEvent, error)) EventDecoder {
+	return func(payload json.RawMessage, metadata domain.EventMetadata) (domain.DomainEvent, error) {
+		var p P
+		if err := json.Unmarshal(payload, &p); err != nil {
+			return nil, err
+		}
+		return parse(p, metadata)
+	}
+}
+
+// copyMetadata returns a copy of a metadata map (nil stays nil)
+func copyMetadata(metadata map[string]string) map[string]string {
+	if len(metadata) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		out[k] = v
+	}
+	return out
+}
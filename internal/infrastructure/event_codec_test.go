@@ -0,0 +1,141 @@
+package infrastructure
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"rockets/internal/domain"
+)
+
+// TestEventCodecRoundTrip verifies that every rocket event survives encoding and decoding.
+// Expected result: same type, channel, message number and fields; metadata kept in the envelope
+// (except for a redacted event).
+func TestEventCodecRoundTrip(t *testing.T) {
+	// Arrange
+	codec := DefaultEventCodec()
+	channel, _ := domain.NewChannel("rocket-codec")
+	msgNum, _ := domain.NewMessageNumber(7)
+	oldSpeed, _ := domain.NewSpeed(100)
+	newSpeed, _ := domain.NewSpeed(250)
+	events := []domain.DomainEvent{
+		&domain.RocketLaunched{Channel: channel, MessageNumber: msgNum, Type: "Falcon-9", Speed: oldSpeed, Mission: domain.MissionResupply, RawMission: "ISS Cargo", Stages: 2, Timestamp: 11},
+		&domain.RocketSpeedIncreased{Channel: channel, MessageNumber: msgNum, OldSpeed: oldSpeed, NewSpeed: newSpeed, Delta: 150, Timestamp: 12},
+		&domain.RocketSpeedDecreased{Channel: channel, MessageNumber: msgNum, OldSpeed: newSpeed, NewSpeed: oldSpeed, Delta: 150, Timestamp: 13},
+		&domain.RocketExploded{Channel: channel, MessageNumber: msgNum, Reason: "fuel leak", Timestamp: 14},
+		&domain.RocketMissionChanged{Channel: channel, MessageNumber: msgNum, OldMission: domain.MissionResupply, NewMission: domain.MissionSatellite, RawMission: "Starlink", Timestamp: 15},
+		&domain.RocketLanded{Channel: channel, MessageNumber: msgNum, Site: "LZ-1", Timestamp: 16},
+		&domain.RocketRefueled{Channel: channel, MessageNumber: msgNum, Amount: 300, Fuel: 500, Timestamp: 17},
+		&domain.RocketStageSeparated{Channel: channel, MessageNumber: msgNum, RemainingStages: 1, Timestamp: 18},
+		&domain.RocketEnvelopeExceeded{Channel: channel, MessageNumber: msgNum, Command: domain.CommandIncreaseSpeed, RocketType: "Falcon-9", Rule: domain.EnvelopeRuleMaxSpeed, Detail: "speed 40000 above max speed 30000 of Falcon-9", Rejected: true, Timestamp: 19},
+		&domain.RocketTimeRegressed{Channel: channel, MessageNumber: msgNum, Command: domain.CommandLand, PreviousTime: 25, Timestamp: 20},
+		&domain.MessagesSkipped{Channel: channel, MessageNumber: msgNum, From: 3, Reason: "gap timeout", Timestamp: 23},
+		&domain.ChannelErased{Channel: channel, MessageNumber: msgNum, Reason: "contract terminated", Timestamp: 24},
+		&domain.RedactedEvent{Type: "rocket_launched", Channel: channel, MessageNumber: msgNum},
+		&domain.FleetCreated{Channel: channel, MessageNumber: msgNum, FleetID: "alpha", Name: "Alpha", Timestamp: 21},
+		&domain.FleetRocketAdded{Channel: channel, MessageNumber: msgNum, Rocket: "rocket-1", Timestamp: 22},
+		&domain.FleetRocketRemoved{Channel: channel, MessageNumber: msgNum, Rocket: "rocket-1", Timestamp: 23},
+	}
+
+	for _, event := range events {
+		// Act
+		data, err := codec.Marshal(event, map[string]string{"source": "test"})
+		if err != nil {
+			t.Fatalf("Expected no error encoding %s, got %v", event.GetEventType(), err)
+		}
+		decoded, envelope, err := codec.Unmarshal(data)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("Expected no error decoding %s, got %v", event.GetEventType(), err)
+		}
+		// A redacted event has lost its metadata along with its payload
+		_, redacted := event.(*domain.RedactedEvent)
+		if envelope.SchemaVersion != 1 || (envelope.Metadata["source"] != "test") != redacted {
+			t.Errorf("Unexpected envelope for %s: %+v", event.GetEventType(), envelope)
+		}
+		again, _ := codec.Marshal(decoded, map[string]string{"source": "test"})
+		if string(again) != string(data) {
+			t.Errorf("Round trip of %s changed the event:\n%s\n%s", event.GetEventType(), data, again)
+		}
+	}
+}
+
+// TestEventCodecUpcastsOldSchema verifies that an event written under an old schema still loads.
+// Schema v2 of rocket_launched renames "speed" to "launchSpeed"; an upcaster rewrites v1 payloads.
+// Expected result: the v1 envelope decodes through the upcaster; new writes use v2.
+func TestEventCodecUpcastsOldSchema(t *testing.T) {
+	// Arrange
+	type launchedV2 struct {
+		eventHeader
+		Type        string `json:"type"`
+		LaunchSpeed int    `json:"launchSpeed"`
+		Mission     string `json:"mission"`
+	}
+	codec := DefaultEventCodec()
+	channel, _ := domain.NewChannel("rocket-old")
+	msgNum, _ := domain.NewMessageNumber(1)
+	speed, _ := domain.NewSpeed(15000)
+	v1, _ := codec.Marshal(&domain.RocketLaunched{Channel: channel, MessageNumber: msgNum, Type: "Falcon-9", Speed: speed, Mission: domain.MissionExploration, Timestamp: 1}, nil)
+
+	_ = codec.Register("rocket_launched", 2,
+		typedEncoder(func(e *domain.RocketLaunched) launchedV2 {
+			return launchedV2{eventHeader: newEventHeader(e), Type: e.Type, LaunchSpeed: e.Speed.Value(), Mission: string(e.Mission)}
+		}),
+		typedDecoder(func(p launchedV2, metadata domain.EventMetadata) (domain.DomainEvent, error) {
+			channel, msgNum, _ := p.values()
+			speed, _ := domain.NewSpeed(p.LaunchSpeed)
+			return &domain.RocketLaunched{Channel: channel, MessageNumber: msgNum, Type: p.Type, Speed: speed, Mission: domain.Mission(p.Mission), Timestamp: p.Timestamp}, nil
+		}))
+	_ = codec.RegisterUpcaster("rocket_launched", 1, func(payload json.RawMessage) (json.RawMessage, error) {
+		fields := map[string]json.RawMessage{}
+		if err := json.Unmarshal(payload, &fields); err != nil {
+			return nil, err
+		}
+		fields["launchSpeed"] = fields["speed"]
+		delete(fields, "speed")
+		return json.Marshal(fields)
+	})
+
+	// Act
+	decoded, _, err := codec.Unmarshal(v1)
+	v2, _ := codec.Encode(decoded, nil)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error decoding v1 envelope, got %v", err)
+	}
+	if decoded.(*domain.RocketLaunched).Speed.Value() != 15000 {
+		t.Errorf("Expected speed 15000 after upcast, got %d", decoded.(*domain.RocketLaunched).Speed.Value())
+	}
+	if v2.SchemaVersion != 2 || !strings.Contains(string(v2.Payload), "launchSpeed") {
+		t.Errorf("Expected new writes at schema v2 with launchSpeed, got %d %s", v2.SchemaVersion, v2.Payload)
+	}
+}
+
+// TestEventCodecRejectsUnknownVersions verifies the errors for versions the codec cannot handle.
+// Expected result: a newer schema and a missing upcaster both fail to decode.
+func TestEventCodecRejectsUnknownVersions(t *testing.T) {
+	// Arrange
+	codec := DefaultEventCodec()
+	payload := json.RawMessage(`{"channel":"rocket-x","messageNumber":1,"timestamp":1,"reason":"x"}`)
+	_ = codec.Register("rocket_exploded", 3, typedEncoder(func(e *domain.RocketExploded) explodedPayload {
+		return explodedPayload{eventHeader: newEventHeader(e), Reason: e.Reason}
+	}), codec.types["rocket_exploded"].decode)
+
+	// Act
+	_, errNewer := codec.Decode(&EventEnvelope{Type: "rocket_exploded", SchemaVersion: 4, Payload: payload})
+	_, errGap := codec.Decode(&EventEnvelope{Type: "rocket_exploded", SchemaVersion: 1, Payload: payload})
+	_, errType := codec.Decode(&EventEnvelope{Type: "rocket_teleported", SchemaVersion: 1, Payload: payload})
+
+	// Assert
+	if errNewer == nil {
+		t.Error("Expected error for a schema newer than the codec, got nil")
+	}
+	if errGap == nil {
+		t.Error("Expected error for a missing upcaster, got nil")
+	}
+	if errType == nil {
+		t.Error("Expected error for an unknown event type, got nil")
+	}
+}
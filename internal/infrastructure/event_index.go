@@ -0,0 +1,193 @@
+package infrastructure
+
+import (
+	"fmt"
+	"sync"
+
+	"rockets/internal/domain"
+)
+
+// eventIndex keeps the events of every channel in memory, ordered by arrival.
+// It is the read side shared by the event store implementations.
+// Every event also gets the next position of the global log, so the position of an
+// event is the order in which it was added to the index.
+type eventIndex struct {
+	mu        sync.RWMutex
+	events    map[string][]domain.DomainEvent
+	positions map[string][]int64 // global position of every event of a channel
+	hashes    map[string]string  // chain hash of the last event of a channel
+	log       []domain.RecordedEvent
+	order     []string      // channels by first event
+	changed   chan struct{} // closed (and replaced) whenever events are added
+}
+
+// newEventIndex creates an empty index
+func newEventIndex() *eventIndex {
+	return &eventIndex{
+		events:    make(map[string][]domain.DomainEvent),
+		positions: make(map[string][]int64),
+		hashes:    make(map[string]string),
+		changed:   make(chan struct{}),
+	}
+}
+
+// append adds an event at the end of its channel; hash is its chain hash
+func (i *eventIndex) append(event domain.DomainEvent, hash string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.addLocked(event.GetChannel().Value(), []domain.DomainEvent{event}, hash)
+}
+
+// appendBatch adds events to a channel if its version matches expectedVersion;
+// lastHash is the chain hash of the last event of the batch
+func (i *eventIndex) appendBatch(channel string, expectedVersion int, events []domain.DomainEvent, lastHash string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if err := i.checkVersionLocked(channel, expectedVersion); err != nil {
+		return err
+	}
+	i.addLocked(channel, events, lastHash)
+	return nil
+}
+
+// head returns the version of a channel and the chain hash of its last event
+func (i *eventIndex) head(channel string) (int, string) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return len(i.events[channel]), i.hashes[channel]
+}
+
+func (i *eventIndex) addLocked(channel string, events []domain.DomainEvent, lastHash string) {
+	if len(events) == 0 {
+		return
+	}
+	if _, ok := i.events[channel]; !ok {
+		i.order = append(i.order, channel)
+	}
+	i.events[channel] = append(i.events[channel], events...)
+	for _, event := range events {
+		position := int64(len(i.log)) + 1
+		i.positions[channel] = append(i.positions[channel], position)
+		i.log = append(i.log, domain.RecordedEvent{Position: position, Version: len(i.positions[channel]), Event: event})
+	}
+	i.hashes[channel] = lastHash
+	for _, event := range events {
+		if _, ok := event.(*domain.ChannelErased); ok {
+			i.redactLocked(channel)
+		}
+	}
+	close(i.changed)
+	i.changed = make(chan struct{})
+}
+
+// redactLocked replaces the events of an erased channel held in memory by their redacted
+// form, so they are no longer readable even where they are still stored in clear
+func (i *eventIndex) redactLocked(channel string) {
+	for n, event := range i.events[channel] {
+		switch event.(type) {
+		case *domain.ChannelErased, *domain.RedactedEvent:
+			continue
+		}
+		redacted := domain.Redact(event)
+		i.events[channel][n] = redacted
+		i.log[i.positions[channel][n]-1].Event = redacted
+	}
+}
+
+// checkVersion returns a *domain.ConcurrencyError if the channel is not at expectedVersion
+func (i *eventIndex) checkVersion(channel string, expectedVersion int) error {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.checkVersionLocked(channel, expectedVersion)
+}
+
+func (i *eventIndex) checkVersionLocked(channel string, expectedVersion int) error {
+	actual := len(i.events[channel])
+	if expectedVersion != domain.AnyVersion && expectedVersion != actual {
+		return &domain.ConcurrencyError{Channel: channel, Expected: expectedVersion, Actual: actual}
+	}
+	return nil
+}
+
+// byChannel returns a copy of the events of a channel
+func (i *eventIndex) byChannel(channel string) []domain.DomainEvent {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	items := i.events[channel]
+	// Return a copy to avoid exposing internal slice
+	copySlice := make([]domain.DomainEvent, len(items))
+	copy(copySlice, items)
+	return copySlice
+}
+
+// channels returns every channel that has events
+func (i *eventIndex) channels() []string {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	channels := make([]string, len(i.order))
+	copy(channels, i.order)
+	return channels
+}
+
+// readAll returns up to limit events (all if limit <= 0) starting at fromPosition
+func (i *eventIndex) readAll(fromPosition int64, limit int) []domain.RecordedEvent {
+	page, _ := i.readAllOrWait(fromPosition, limit)
+	return page
+}
+
+// readAllOrWait is readAll that also returns a channel closed once more events are added.
+// Both come from the same read lock, so no event can slip in between them.
+func (i *eventIndex) readAllOrWait(fromPosition int64, limit int) ([]domain.RecordedEvent, <-chan struct{}) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	if fromPosition < 1 {
+		fromPosition = 1
+	}
+	start := fromPosition - 1
+	if start >= int64(len(i.log)) {
+		return []domain.RecordedEvent{}, i.changed
+	}
+	end := int64(len(i.log))
+	if limit > 0 && start+int64(limit) < end {
+		end = start + int64(limit)
+	}
+	page := make([]domain.RecordedEvent, end-start)
+	copy(page, i.log[start:end])
+	return page, i.changed
+}
+
+// readChannelOrWait returns up to limit events of a channel after its first fromVersion
+// events, and a channel closed once more events are added to the index
+func (i *eventIndex) readChannelOrWait(channel string, fromVersion int, limit int) ([]domain.RecordedEvent, <-chan struct{}) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	positions := i.positions[channel]
+	if fromVersion < 0 {
+		fromVersion = 0
+	}
+	if fromVersion >= len(positions) {
+		return []domain.RecordedEvent{}, i.changed
+	}
+	positions = positions[fromVersion:]
+	if limit > 0 && limit < len(positions) {
+		positions = positions[:limit]
+	}
+	page := make([]domain.RecordedEvent, len(positions))
+	for n, position := range positions {
+		page[n] = i.log[position-1]
+	}
+	return page, i.changed
+}
+
+// validateBatch checks that every event of a batch belongs to channel
+func validateBatch(channel *domain.Channel, events []domain.DomainEvent) error {
+	if channel == nil {
+		return fmt.Errorf("channel cannot be nil")
+	}
+	for _, event := range events {
+		if event.GetChannel().Value() != channel.Value() {
+			return fmt.Errorf("event of channel %s cannot be appended to channel %s", event.GetChannel().Value(), channel.Value())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package infrastructure
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"rockets/internal/domain"
+)
+
+// eventRecord is an event envelope as written to durable storage, with its log sequence number
+type eventRecord struct {
+	Seq uint64 `json:"seq,omitempty"`
+	EventEnvelope
+	Hash string `json:"hash,omitempty"` // chain hash, see chainHash
+}
+
+// newEventRecord encodes a domain event into a record chained to prevHash, the hash of the
+// previous event of its channel
+func newEventRecord(codec *EventCodec, seq uint64, event domain.DomainEvent, prevHash string) (*eventRecord, error) {
+	envelope, err := codec.Encode(event, event.GetMetadata())
+	if err != nil {
+		return nil, err
+	}
+	hash, err := chainHash(prevHash, envelope)
+	if err != nil {
+		return nil, err
+	}
+	return &eventRecord{Seq: seq, EventEnvelope: *envelope, Hash: hash}, nil
+}
+
+// newEventRecords encodes a batch of events of one channel, chaining each record to the
+// previous one. It returns the records and the hash of the last one.
+func newEventRecords(codec *EventCodec, firstSeq uint64, events []domain.DomainEvent, prevHash string) ([]*eventRecord, string, error) {
+	records := make([]*eventRecord, 0, len(events))
+	for i, event := range events {
+		seq := uint64(0)
+		if firstSeq > 0 {
+			seq = firstSeq + uint64(i)
+		}
+		record, err := newEventRecord(codec, seq, event, prevHash)
+		if err != nil {
+			return nil, "", err
+		}
+		records = append(records, record)
+		prevHash = record.Hash
+	}
+	return records, prevHash, nil
+}
+
+// channel returns the channel of the record without decoding the event
+func (r *eventRecord) channel() (string, error) {
+	var header eventHeader
+	if err := json.Unmarshal(r.Payload, &header); err != nil {
+		return "", err
+	}
+	return header.Channel, nil
+}
+
+// toDomainEvent decodes the event stored in the record
+func (r *eventRecord) toDomainEvent(codec *EventCodec) (domain.DomainEvent, error) {
+	event, err := codec.Decode(&r.EventEnvelope)
+	if err != nil {
+		return nil, fmt.Errorf("invalid record %d: %w", r.Seq, err)
+	}
+	return event, nil
+}
@@ -0,0 +1,567 @@
+package infrastructure
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"rockets/internal/domain"
+)
+
+// SyncPolicy decides when appended records are flushed to stable storage
+type SyncPolicy string
+
+const (
+	SyncAlways   SyncPolicy = "always"   // fsync after every append
+	SyncInterval SyncPolicy = "interval" // fsync in background every SyncInterval
+	SyncNever    SyncPolicy = "never"    // leave flushing to the operating system
+)
+
+const (
+	segmentExtension      = ".seg"
+	segmentMagic          = "RKTSEG01"
+	segmentHeaderSize     = int64(len(segmentMagic))
+	recordHeaderSize      = 8 // length (uint32) + checksum (uint32)
+	maxRecordSize         = 16 << 20
+	defaultSegmentBytes   = 64 << 20
+	defaultSyncInterval   = time.Second
+	segmentNameDigits     = 20
+	segmentFilePermission = 0o644
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// errTornRecord signals a record that was cut off or does not match its checksum
+var errTornRecord = errors.New("torn or corrupt record")
+
+// FileEventStoreConfig configures a FileEventStore
+type FileEventStoreConfig struct {
+	Dir             string        // directory holding the segment files
+	MaxSegmentBytes int64         // size at which a new segment is started
+	SyncPolicy      SyncPolicy    // when to fsync (default: always)
+	SyncInterval    time.Duration // fsync period for SyncInterval
+	Codec           *EventCodec   // event serialization (default: DefaultEventCodec)
+}
+
+// FileEventStore implements the event store as a segmented, checksummed, append-only log on disk.
+// Every batch of records is framed as | length | crc32c | payload | so a batch cut off by a crash
+// is detected and discarded as a whole when the store is reopened.
+type FileEventStore struct {
+	cfg   FileEventStoreConfig
+	index *eventIndex // channel index rebuilt on startup
+
+	mu         sync.Mutex // serializes writes to the active segment
+	active     *os.File
+	activeSize int64
+	nextSeq    uint64
+	dirty      bool  // unsynced writes pending (SyncInterval/SyncNever)
+	failed     error // set when a failed write could not be rolled back; no append is accepted
+	closed     bool
+	lock       *os.File // holds the lock on the directory until Close
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// lockFileName is the file a FileEventStore locks in its directory, so that a second store
+// (e.g. rocketsctl import next to a running server) cannot append to the same segments
+const lockFileName = "LOCK"
+
+// ErrStoreLocked is returned when the directory of a file event store is already open
+var ErrStoreLocked = errors.New("event store directory is in use by another process")
+
+// NewFileEventStore opens (or creates) a file event store and rebuilds its index from disk
+func NewFileEventStore(cfg FileEventStoreConfig) (*FileEventStore, error) {
+	if strings.TrimSpace(cfg.Dir) == "" {
+		return nil, fmt.Errorf("event store directory cannot be empty")
+	}
+	if cfg.MaxSegmentBytes <= segmentHeaderSize {
+		cfg.MaxSegmentBytes = defaultSegmentBytes
+	}
+	switch cfg.SyncPolicy {
+	case "":
+		cfg.SyncPolicy = SyncAlways
+	case SyncAlways, SyncInterval, SyncNever:
+	default:
+		return nil, fmt.Errorf("unknown sync policy: %s", cfg.SyncPolicy)
+	}
+	if cfg.SyncInterval <= 0 {
+		cfg.SyncInterval = defaultSyncInterval
+	}
+	if cfg.Codec == nil {
+		cfg.Codec = DefaultEventCodec()
+	}
+
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create event store directory: %w", err)
+	}
+
+	lock, err := lockDir(cfg.Dir)
+	if err != nil {
+		return nil, err
+	}
+	s := &FileEventStore{
+		cfg:     cfg,
+		index:   newEventIndex(),
+		nextSeq: 1,
+		lock:    lock,
+	}
+	if err := s.recover(); err != nil {
+		_ = lock.Close()
+		return nil, err
+	}
+
+	if cfg.SyncPolicy == SyncInterval {
+		s.stop = make(chan struct{})
+		s.done = make(chan struct{})
+		go s.syncLoop()
+	}
+
+	slog.Info("File event store opened",
+		"dir", cfg.Dir,
+		"records", s.nextSeq-1,
+		"channels", len(s.index.channels()),
+		"sync_policy", cfg.SyncPolicy)
+
+	return s, nil
+}
+
+// AppendEvent appends an event to the active segment
+func (s *FileEventStore) AppendEvent(event domain.DomainEvent) error {
+	return s.AppendEvents(event.GetChannel(), domain.AnyVersion, []domain.DomainEvent{event})
+}
+
+// AppendEvents appends a batch of events of one channel as a single record, so that after a
+// crash either the whole batch or none of it is found in the log
+func (s *FileEventStore) AppendEvents(channel *domain.Channel, expectedVersion int, events []domain.DomainEvent) error {
+	if err := validateBatch(channel, events); err != nil {
+		return err
+	}
+	if len(events) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return fmt.Errorf("event store is closed")
+	}
+	if s.failed != nil {
+		return fmt.Errorf("event store failed, reopen it to recover: %w", s.failed)
+	}
+	// Writes are serialized by s.mu, so the version cannot change before the index is updated
+	if err := s.index.checkVersion(channel.Value(), expectedVersion); err != nil {
+		return err
+	}
+
+	_, prevHash := s.index.head(channel.Value())
+	records, lastHash, err := newEventRecords(s.cfg.Codec, s.nextSeq, events, prevHash)
+	if err != nil {
+		return err
+	}
+	frame, err := encodeFrame(records)
+	if err != nil {
+		return err
+	}
+
+	if s.activeSize+int64(len(frame)) > s.cfg.MaxSegmentBytes && s.activeSize > segmentHeaderSize {
+		if err := s.roll(); err != nil {
+			return err
+		}
+	}
+
+	if err := s.write(frame); err != nil {
+		return err
+	}
+
+	s.nextSeq += uint64(len(records))
+	if err := s.index.appendBatch(channel.Value(), domain.AnyVersion, events, lastHash); err != nil {
+		return err
+	}
+
+	slog.Debug("Events stored", "channel", channel.Value(), "count", len(events), "first_seq", records[0].Seq)
+	return nil
+}
+
+// GetEventsByChannel gets all events for a channel
+func (s *FileEventStore) GetEventsByChannel(channel *domain.Channel) ([]domain.DomainEvent, error) {
+	return s.index.byChannel(channel.Value()), nil
+}
+
+// GetAllChannels gets all channels that have events
+func (s *FileEventStore) GetAllChannels() []string {
+	return s.index.channels()
+}
+
+// ReadAll reads the global log. The position of an event is its record sequence number,
+// so positions survive restarts.
+func (s *FileEventStore) ReadAll(fromPosition int64, limit int) ([]domain.RecordedEvent, error) {
+	return s.index.readAll(fromPosition, limit), nil
+}
+
+// SubscribeAll delivers the global log from fromPosition, then live events
+func (s *FileEventStore) SubscribeAll(ctx context.Context, fromPosition int64, handler func(domain.RecordedEvent) error) error {
+	return s.index.subscribeAll(ctx, fromPosition, handler)
+}
+
+// SubscribeToChannel delivers the events of a channel after the first fromVersion, then live events
+func (s *FileEventStore) SubscribeToChannel(ctx context.Context, channel *domain.Channel, fromVersion int, handler func(domain.RecordedEvent) error) error {
+	if channel == nil {
+		return fmt.Errorf("channel cannot be nil")
+	}
+	return s.index.subscribeChannel(ctx, channel.Value(), fromVersion, handler)
+}
+
+// Sync flushes pending writes to stable storage
+func (s *FileEventStore) Sync() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.syncLocked()
+}
+
+// Close flushes and closes the active segment
+func (s *FileEventStore) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.mu.Unlock()
+
+	if s.stop != nil {
+		close(s.stop)
+		<-s.done
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.lock.Close()
+	if err := s.syncLocked(); err != nil {
+		_ = s.active.Close()
+		return err
+	}
+	return s.active.Close()
+}
+
+// write appends a frame to the active segment. A frame that failed to be written or synced is
+// truncated away, so that the next append reuses its sequence numbers without leaving a record
+// recovery would reject behind.
+func (s *FileEventStore) write(frame []byte) error {
+	n, err := s.active.Write(frame)
+	if err != nil {
+		if n > 0 {
+			// Do not leave half a record behind for the next append
+			s.rollback(err)
+		}
+		return fmt.Errorf("failed to write record: %w", err)
+	}
+
+	if s.cfg.SyncPolicy == SyncAlways {
+		if err := s.active.Sync(); err != nil {
+			s.rollback(err)
+			return fmt.Errorf("failed to sync segment: %w", err)
+		}
+		s.activeSize += int64(n)
+		return nil
+	}
+	s.activeSize += int64(n)
+	s.dirty = true
+	return nil
+}
+
+// rollback truncates the active segment back to its last complete frame after a failed write.
+// If even that fails the store stops accepting appends: reopening it discards the torn tail.
+func (s *FileEventStore) rollback(cause error) {
+	if err := s.active.Truncate(s.activeSize); err != nil {
+		s.failed = fmt.Errorf("%v (rollback failed: %w)", cause, err)
+		slog.Error("Event store failed", "err", s.failed)
+	}
+}
+
+// syncLocked fsyncs the active segment if there are pending writes
+func (s *FileEventStore) syncLocked() error {
+	if !s.dirty {
+		return nil
+	}
+	if err := s.active.Sync(); err != nil {
+		return fmt.Errorf("failed to sync segment: %w", err)
+	}
+	s.dirty = false
+	return nil
+}
+
+// syncLoop flushes pending writes periodically (SyncInterval policy)
+func (s *FileEventStore) syncLoop() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.cfg.SyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			if err := s.Sync(); err != nil {
+				slog.Error("Periodic event store sync failed", "err", err)
+			}
+		}
+	}
+}
+
+// roll seals the active segment and starts a new one at the next sequence number. The sealed
+// segment stays active until the new one exists, so a failed roll can be tried again.
+func (s *FileEventStore) roll() error {
+	if err := s.active.Sync(); err != nil {
+		return fmt.Errorf("failed to sync segment before roll: %w", err)
+	}
+	s.dirty = false
+	sealed := s.active
+	if err := s.createSegment(s.nextSeq); err != nil {
+		return err
+	}
+	if err := sealed.Close(); err != nil {
+		// Already synced: nothing is lost
+		slog.Warn("Failed to close sealed segment", "path", sealed.Name(), "err", err)
+	}
+	return nil
+}
+
+// createSegment creates a new active segment whose first record is baseSeq
+func (s *FileEventStore) createSegment(baseSeq uint64) error {
+	path := filepath.Join(s.cfg.Dir, segmentName(baseSeq))
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY|os.O_APPEND, segmentFilePermission)
+	if err != nil {
+		return fmt.Errorf("failed to create segment: %w", err)
+	}
+	// A segment without its header would block the next attempt (O_EXCL) and fail recovery
+	if _, err := f.Write([]byte(segmentMagic)); err != nil {
+		_ = f.Close()
+		_ = os.Remove(path)
+		return fmt.Errorf("failed to write segment header: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		_ = os.Remove(path)
+		return fmt.Errorf("failed to sync segment: %w", err)
+	}
+	syncDir(s.cfg.Dir)
+
+	s.active = f
+	s.activeSize = segmentHeaderSize
+	slog.Debug("Segment created", "path", path, "base_seq", baseSeq)
+	return nil
+}
+
+// recover replays every segment into the index and reopens the last one for appends.
+// A torn tail in the last segment is truncated; damage in any earlier segment is fatal.
+func (s *FileEventStore) recover() error {
+	bases, err := listSegments(s.cfg.Dir)
+	if err != nil {
+		return err
+	}
+	if len(bases) == 0 {
+		return s.createSegment(s.nextSeq)
+	}
+
+	for i, base := range bases {
+		last := i == len(bases)-1
+		path := filepath.Join(s.cfg.Dir, segmentName(base))
+
+		if base != s.nextSeq {
+			return fmt.Errorf("segment %s starts at record %d, expected %d", path, base, s.nextSeq)
+		}
+
+		validSize, err := s.replaySegment(path)
+		if err != nil && !(last && errors.Is(err, errTornRecord)) {
+			return fmt.Errorf("segment %s: %w", path, err)
+		}
+
+		if !last {
+			continue
+		}
+
+		if err != nil {
+			slog.Warn("Truncating torn tail of event log", "path", path, "valid_bytes", validSize, "err", err)
+			if err := repairTail(path, validSize); err != nil {
+				return err
+			}
+			if validSize < segmentHeaderSize {
+				validSize = segmentHeaderSize
+			}
+		}
+
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, segmentFilePermission)
+		if err != nil {
+			return fmt.Errorf("failed to open segment: %w", err)
+		}
+		s.active = f
+		s.activeSize = validSize
+	}
+
+	return nil
+}
+
+// replaySegment loads the records of a segment into the index.
+// It returns the size of the valid prefix of the file.
+func (s *FileEventStore) replaySegment(path string) (int64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read segment: %w", err)
+	}
+	if int64(len(data)) < segmentHeaderSize || string(data[:segmentHeaderSize]) != segmentMagic {
+		return 0, fmt.Errorf("%w: invalid segment header", errTornRecord)
+	}
+
+	offset := segmentHeaderSize
+	for offset < int64(len(data)) {
+		payload, size, err := decodeFrame(data[offset:])
+		if err != nil {
+			return offset, err
+		}
+
+		records, err := decodeRecords(payload)
+		if err != nil {
+			return offset, fmt.Errorf("invalid record at offset %d: %w", offset, err)
+		}
+		events := make([]domain.DomainEvent, 0, len(records))
+		for i, record := range records {
+			if record.Seq != s.nextSeq+uint64(i) {
+				return offset, fmt.Errorf("record at offset %d has sequence %d, expected %d", offset, record.Seq, s.nextSeq+uint64(i))
+			}
+			event, err := record.toDomainEvent(s.cfg.Codec)
+			if err != nil {
+				return offset, err
+			}
+			events = append(events, event)
+		}
+
+		for i, event := range events {
+			s.index.append(event, records[i].Hash)
+		}
+		s.nextSeq += uint64(len(records))
+		offset += size
+	}
+
+	return offset, nil
+}
+
+// encodeFrame serializes a batch of records and frames it with its length and checksum
+func encodeFrame(records []*eventRecord) ([]byte, error) {
+	payload, err := json.Marshal(records)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode record: %w", err)
+	}
+	if len(payload) > maxRecordSize {
+		return nil, fmt.Errorf("record too large: %d bytes", len(payload))
+	}
+	frame := make([]byte, recordHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(frame[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(frame[4:8], crc32.Checksum(payload, crcTable))
+	copy(frame[recordHeaderSize:], payload)
+	return frame, nil
+}
+
+// decodeFrame reads one framed record, returning its payload and total size
+func decodeFrame(data []byte) ([]byte, int64, error) {
+	if len(data) < recordHeaderSize {
+		return nil, 0, fmt.Errorf("%w: short header", errTornRecord)
+	}
+	length := binary.BigEndian.Uint32(data[0:4])
+	checksum := binary.BigEndian.Uint32(data[4:8])
+	if length > maxRecordSize {
+		return nil, 0, fmt.Errorf("%w: invalid length %d", errTornRecord, length)
+	}
+	end := recordHeaderSize + int64(length)
+	if int64(len(data)) < end {
+		return nil, 0, fmt.Errorf("%w: short payload", errTornRecord)
+	}
+	payload := data[recordHeaderSize:end]
+	if crc32.Checksum(payload, crcTable) != checksum {
+		return nil, 0, fmt.Errorf("%w: checksum mismatch", errTornRecord)
+	}
+	return payload, end, nil
+}
+
+// decodeRecords decodes the payload of a frame, the batch of records of one append
+func decodeRecords(payload []byte) ([]*eventRecord, error) {
+	var records []*eventRecord
+	if err := json.Unmarshal(payload, &records); err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("empty record batch")
+	}
+	return records, nil
+}
+
+// repairTail cuts a segment back to its valid prefix, rewriting the header if it was lost
+func repairTail(path string, validSize int64) error {
+	f, err := os.OpenFile(path, os.O_RDWR, segmentFilePermission)
+	if err != nil {
+		return fmt.Errorf("failed to open segment for repair: %w", err)
+	}
+	defer f.Close()
+
+	if validSize < segmentHeaderSize {
+		if err := f.Truncate(0); err != nil {
+			return fmt.Errorf("failed to truncate segment: %w", err)
+		}
+		if _, err := f.WriteAt([]byte(segmentMagic), 0); err != nil {
+			return fmt.Errorf("failed to rewrite segment header: %w", err)
+		}
+	} else if err := f.Truncate(validSize); err != nil {
+		return fmt.Errorf("failed to truncate segment: %w", err)
+	}
+	return f.Sync()
+}
+
+// listSegments returns the base sequence numbers of the segments in dir, in order
+func listSegments(dir string) ([]uint64, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list segments: %w", err)
+	}
+	var bases []uint64
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, segmentExtension) {
+			continue
+		}
+		base, err := strconv.ParseUint(strings.TrimSuffix(name, segmentExtension), 10, 64)
+		if err != nil {
+			continue // Not one of ours
+		}
+		bases = append(bases, base)
+	}
+	sort.Slice(bases, func(i, j int) bool { return bases[i] < bases[j] })
+	return bases, nil
+}
+
+// segmentName returns the file name of the segment starting at baseSeq
+func segmentName(baseSeq uint64) string {
+	return fmt.Sprintf("%0*d%s", segmentNameDigits, baseSeq, segmentExtension)
+}
+
+// syncDir fsyncs a directory so newly created files survive a crash
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	defer d.Close()
+	if err := d.Sync(); err != nil {
+		slog.Debug("Directory sync failed", "dir", dir, "err", err)
+	}
+}
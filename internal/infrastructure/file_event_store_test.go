@@ -0,0 +1,490 @@
+package infrastructure
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"rockets/internal/domain"
+)
+
+// appendLaunchAndSpeedUps appends a launch followed by n speed increases to the store.
+func appendLaunchAndSpeedUps(t *testing.T, store domain.EventStore, channelName string, n int) {
+	t.Helper()
+	channel, _ := domain.NewChannel(channelName)
+	rocket := domain.NewRocket(channel)
+	msgNum, _ := domain.NewMessageNumber(1)
+	speed, _ := domain.NewSpeed(10000)
+	if err := rocket.Launch(msgNum, "Falcon-9", speed, domain.MissionExploration, 1000); err != nil {
+		t.Fatalf("Expected no error launching, got %v", err)
+	}
+	for i := 0; i < n; i++ {
+		msgNum, _ := domain.NewMessageNumber(i + 2)
+		if err := rocket.IncreaseSpeed(msgNum, 100, int64(1001+i)); err != nil {
+			t.Fatalf("Expected no error increasing speed, got %v", err)
+		}
+	}
+	for _, event := range rocket.GetUncommittedEvents() {
+		if err := store.AppendEvent(event); err != nil {
+			t.Fatalf("Expected no error appending event, got %v", err)
+		}
+	}
+}
+
+// lastSegmentPath returns the path of the newest segment in dir.
+func lastSegmentPath(t *testing.T, dir string) string {
+	t.Helper()
+	bases, err := listSegments(dir)
+	if err != nil || len(bases) == 0 {
+		t.Fatalf("Expected segments in %s, got %v (err %v)", dir, bases, err)
+	}
+	return filepath.Join(dir, segmentName(bases[len(bases)-1]))
+}
+
+// TestFileEventStoreReopen verifies that events survive closing and reopening the store.
+// Expected result: the reopened store rebuilds the channel index and replays to the same state.
+func TestFileEventStoreReopen(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	store, err := NewFileEventStore(FileEventStoreConfig{Dir: dir})
+	if err != nil {
+		t.Fatalf("Expected no error opening store, got %v", err)
+	}
+	appendLaunchAndSpeedUps(t, store, "rocket-a", 2)
+	appendLaunchAndSpeedUps(t, store, "rocket-b", 0)
+	if err := store.Close(); err != nil {
+		t.Fatalf("Expected no error closing store, got %v", err)
+	}
+
+	// Act
+	reopened, err := NewFileEventStore(FileEventStoreConfig{Dir: dir})
+	if err != nil {
+		t.Fatalf("Expected no error reopening store, got %v", err)
+	}
+	defer reopened.Close()
+
+	// Assert
+	if len(reopened.GetAllChannels()) != 2 {
+		t.Errorf("Expected 2 channels, got %d", len(reopened.GetAllChannels()))
+	}
+	channel, _ := domain.NewChannel("rocket-a")
+	events, _ := reopened.GetEventsByChannel(channel)
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 events, got %d", len(events))
+	}
+	rocket := domain.NewRocket(channel)
+	_ = rocket.LoadFromHistory(events)
+	if rocket.GetSpeed().Value() != 10200 {
+		t.Errorf("Expected speed 10200, got %d", rocket.GetSpeed().Value())
+	}
+	if rocket.GetRocketType() != "Falcon-9" {
+		t.Errorf("Expected type Falcon-9, got %s", rocket.GetRocketType())
+	}
+}
+
+// TestFileEventStoreLocksDirectory verifies that a directory can only be opened by one store
+// at a time, so that a second writer cannot corrupt the active segment.
+// Expected result: the second open fails with ErrStoreLocked; after Close the directory opens.
+func TestFileEventStoreLocksDirectory(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	store, err := NewFileEventStore(FileEventStoreConfig{Dir: dir})
+	if err != nil {
+		t.Fatalf("Expected no error opening store, got %v", err)
+	}
+
+	// Act
+	_, errLocked := NewFileEventStore(FileEventStoreConfig{Dir: dir})
+	_ = store.Close()
+	reopened, errReopen := NewFileEventStore(FileEventStoreConfig{Dir: dir})
+
+	// Assert
+	if !errors.Is(errLocked, ErrStoreLocked) {
+		t.Errorf("Expected ErrStoreLocked, got %v", errLocked)
+	}
+	if errReopen != nil {
+		t.Fatalf("Expected the directory to open once closed, got %v", errReopen)
+	}
+	_ = reopened.Close()
+}
+
+// TestFileEventStoreSegmentRoll verifies that the log rolls into several segments when it grows.
+// Expected result: more than one segment file and every event is replayed on reopen.
+func TestFileEventStoreSegmentRoll(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	cfg := FileEventStoreConfig{Dir: dir, MaxSegmentBytes: 512, SyncPolicy: SyncNever}
+	store, err := NewFileEventStore(cfg)
+	if err != nil {
+		t.Fatalf("Expected no error opening store, got %v", err)
+	}
+
+	// Act
+	appendLaunchAndSpeedUps(t, store, "rocket-roll", 20)
+	_ = store.Close()
+	reopened, err := NewFileEventStore(cfg)
+	if err != nil {
+		t.Fatalf("Expected no error reopening store, got %v", err)
+	}
+	defer reopened.Close()
+
+	// Assert
+	bases, _ := listSegments(dir)
+	if len(bases) < 2 {
+		t.Errorf("Expected several segments, got %d", len(bases))
+	}
+	channel, _ := domain.NewChannel("rocket-roll")
+	events, _ := reopened.GetEventsByChannel(channel)
+	if len(events) != 21 {
+		t.Errorf("Expected 21 events, got %d", len(events))
+	}
+}
+
+// TestFileEventStoreRetriesFailedRoll verifies that a segment that cannot be created leaves
+// the previous one active, so appends resume once the cause is gone.
+// Expected result: the append that needs the roll fails, the next one succeeds and every
+// committed event is replayed on reopen.
+func TestFileEventStoreRetriesFailedRoll(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	cfg := FileEventStoreConfig{Dir: dir, MaxSegmentBytes: 512, SyncPolicy: SyncAlways}
+	store, err := NewFileEventStore(cfg)
+	if err != nil {
+		t.Fatalf("Expected no error opening store, got %v", err)
+	}
+	channel, _ := domain.NewChannel("rocket-roll")
+	msgNum, _ := domain.NewMessageNumber(1)
+	speed, _ := domain.NewSpeed(1000)
+	launch := &domain.RocketLaunched{Channel: channel, MessageNumber: msgNum, Type: "Falcon-9", Speed: speed, Mission: domain.MissionExploration, Timestamp: 1000}
+	if err := store.AppendEvents(channel, 0, []domain.DomainEvent{launch}); err != nil {
+		t.Fatalf("Expected no error appending, got %v", err)
+	}
+	version := 1
+	// Fill the segment until the next (same-sized) frame needs a new one
+	before := store.activeSize
+	appendSpeedUp(t, store, channel, &version)
+	frame := store.activeSize - before
+	if frame <= 0 { // it started a new segment
+		frame = store.activeSize - segmentHeaderSize
+	}
+	for store.activeSize+frame <= cfg.MaxSegmentBytes {
+		appendSpeedUp(t, store, channel, &version)
+	}
+	// A directory where the next segment goes makes its creation fail
+	blocker := filepath.Join(dir, segmentName(store.nextSeq))
+	if err := os.Mkdir(blocker, 0o755); err != nil {
+		t.Fatalf("Expected no error creating blocker, got %v", err)
+	}
+
+	// Act
+	errRoll := tryAppendSpeedUp(store, channel, &version)
+	_ = os.Remove(blocker)
+	errAfter := tryAppendSpeedUp(store, channel, &version)
+	_ = store.Close()
+	reopened, errReopen := NewFileEventStore(cfg)
+
+	// Assert
+	if errRoll == nil {
+		t.Fatalf("Expected the roll into %s to fail", blocker)
+	}
+	if errAfter != nil {
+		t.Fatalf("Expected the append after the failed roll to succeed, got %v", errAfter)
+	}
+	if errReopen != nil {
+		t.Fatalf("Expected no error reopening store, got %v", errReopen)
+	}
+	defer reopened.Close()
+	events, _ := reopened.GetEventsByChannel(channel)
+	if len(events) != version {
+		t.Errorf("Expected %d events, got %d", version, len(events))
+	}
+}
+
+// tryAppendSpeedUp appends the next speed increase of a channel whose stream is at *version
+func tryAppendSpeedUp(store *FileEventStore, channel *domain.Channel, version *int) error {
+	msgNum, _ := domain.NewMessageNumber(*version + 1)
+	speed, _ := domain.NewSpeed(1000 + *version)
+	event := &domain.RocketSpeedIncreased{Channel: channel, MessageNumber: msgNum, OldSpeed: speed, NewSpeed: speed, Delta: 1, Timestamp: int64(1000 + *version)}
+	if err := store.AppendEvents(channel, *version, []domain.DomainEvent{event}); err != nil {
+		return err
+	}
+	*version++
+	return nil
+}
+
+// appendSpeedUp is tryAppendSpeedUp failing the test on error
+func appendSpeedUp(t *testing.T, store *FileEventStore, channel *domain.Channel, version *int) {
+	t.Helper()
+	if err := tryAppendSpeedUp(store, channel, version); err != nil {
+		t.Fatalf("Expected no error appending, got %v", err)
+	}
+}
+
+// TestFileEventStoreRecoversFromTornRecord simulates a crash in the middle of a write by cutting
+// the last record partway through.
+// Expected result: the torn record is discarded, the earlier ones survive and new appends work.
+func TestFileEventStoreRecoversFromTornRecord(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	store, err := NewFileEventStore(FileEventStoreConfig{Dir: dir})
+	if err != nil {
+		t.Fatalf("Expected no error opening store, got %v", err)
+	}
+	appendLaunchAndSpeedUps(t, store, "rocket-crash", 3)
+	_ = store.Close()
+
+	path := lastSegmentPath(t, dir)
+	info, _ := os.Stat(path)
+	if err := os.Truncate(path, info.Size()-5); err != nil {
+		t.Fatalf("Failed to cut segment: %v", err)
+	}
+
+	// Act
+	reopened, err := NewFileEventStore(FileEventStoreConfig{Dir: dir})
+	if err != nil {
+		t.Fatalf("Expected no error reopening store, got %v", err)
+	}
+	channel, _ := domain.NewChannel("rocket-crash")
+	events, _ := reopened.GetEventsByChannel(channel)
+
+	// Assert
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 surviving events, got %d", len(events))
+	}
+
+	// Appending after recovery must produce a readable log
+	msgNum, _ := domain.NewMessageNumber(4)
+	oldSpeed, _ := domain.NewSpeed(10200)
+	newSpeed, _ := domain.NewSpeed(10300)
+	if err := reopened.AppendEvent(&domain.RocketSpeedIncreased{
+		Channel: channel, MessageNumber: msgNum, OldSpeed: oldSpeed, NewSpeed: newSpeed, Delta: 100, Timestamp: 1004,
+	}); err != nil {
+		t.Fatalf("Expected no error appending after recovery, got %v", err)
+	}
+	_ = reopened.Close()
+
+	again, err := NewFileEventStore(FileEventStoreConfig{Dir: dir})
+	if err != nil {
+		t.Fatalf("Expected no error reopening store, got %v", err)
+	}
+	defer again.Close()
+	events, _ = again.GetEventsByChannel(channel)
+	if len(events) != 4 {
+		t.Errorf("Expected 4 events after recovery and append, got %d", len(events))
+	}
+}
+
+// TestFileEventStoreRecoversFromCorruptChecksum verifies that a record whose bytes were damaged is dropped.
+// Expected result: the last record fails its checksum and is truncated away.
+func TestFileEventStoreRecoversFromCorruptChecksum(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	store, err := NewFileEventStore(FileEventStoreConfig{Dir: dir})
+	if err != nil {
+		t.Fatalf("Expected no error opening store, got %v", err)
+	}
+	appendLaunchAndSpeedUps(t, store, "rocket-corrupt", 1)
+	_ = store.Close()
+
+	path := lastSegmentPath(t, dir)
+	data, _ := os.ReadFile(path)
+	data[len(data)-2] ^= 0xFF
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("Failed to corrupt segment: %v", err)
+	}
+
+	// Act
+	reopened, err := NewFileEventStore(FileEventStoreConfig{Dir: dir})
+	if err != nil {
+		t.Fatalf("Expected no error reopening store, got %v", err)
+	}
+	defer reopened.Close()
+
+	// Assert
+	channel, _ := domain.NewChannel("rocket-corrupt")
+	events, _ := reopened.GetEventsByChannel(channel)
+	if len(events) != 1 {
+		t.Errorf("Expected 1 surviving event, got %d", len(events))
+	}
+}
+
+// TestFileEventStoreRejectsDamagedSealedSegment verifies that damage outside the tail is not silently dropped.
+// Expected result: opening the store fails when a sealed segment is cut short.
+func TestFileEventStoreRejectsDamagedSealedSegment(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	cfg := FileEventStoreConfig{Dir: dir, MaxSegmentBytes: 512}
+	store, err := NewFileEventStore(cfg)
+	if err != nil {
+		t.Fatalf("Expected no error opening store, got %v", err)
+	}
+	appendLaunchAndSpeedUps(t, store, "rocket-sealed", 20)
+	_ = store.Close()
+
+	bases, _ := listSegments(dir)
+	first := filepath.Join(dir, segmentName(bases[0]))
+	info, _ := os.Stat(first)
+	if err := os.Truncate(first, info.Size()-3); err != nil {
+		t.Fatalf("Failed to cut segment: %v", err)
+	}
+
+	// Act
+	_, err = NewFileEventStore(cfg)
+
+	// Assert
+	if err == nil {
+		t.Error("Expected error opening store with a damaged sealed segment, got nil")
+	}
+}
+
+// TestFileEventStoreBatchIsAtomicAcrossCrash verifies that a batch cut off by a crash disappears as a whole.
+// Expected result: none of the events of the torn batch are replayed.
+func TestFileEventStoreBatchIsAtomicAcrossCrash(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	store, err := NewFileEventStore(FileEventStoreConfig{Dir: dir})
+	if err != nil {
+		t.Fatalf("Expected no error opening store, got %v", err)
+	}
+	appendLaunchAndSpeedUps(t, store, "rocket-first", 0)
+
+	channel, _ := domain.NewChannel("rocket-batch")
+	rocket := domain.NewRocket(channel)
+	msgNum1, _ := domain.NewMessageNumber(1)
+	msgNum2, _ := domain.NewMessageNumber(2)
+	speed, _ := domain.NewSpeed(1000)
+	_ = rocket.Launch(msgNum1, "Falcon-9", speed, domain.MissionSatellite, 1)
+	_ = rocket.IncreaseSpeed(msgNum2, 10, 2)
+	if err := store.AppendEvents(channel, 0, rocket.GetUncommittedEvents()); err != nil {
+		t.Fatalf("Expected no error appending batch, got %v", err)
+	}
+	_ = store.Close()
+
+	path := lastSegmentPath(t, dir)
+	info, _ := os.Stat(path)
+	if err := os.Truncate(path, info.Size()-1); err != nil {
+		t.Fatalf("Failed to cut segment: %v", err)
+	}
+
+	// Act
+	reopened, err := NewFileEventStore(FileEventStoreConfig{Dir: dir})
+	if err != nil {
+		t.Fatalf("Expected no error reopening store, got %v", err)
+	}
+	defer reopened.Close()
+
+	// Assert
+	events, _ := reopened.GetEventsByChannel(channel)
+	if len(events) != 0 {
+		t.Errorf("Expected the torn batch to be dropped entirely, got %d events", len(events))
+	}
+	if len(reopened.GetAllChannels()) != 1 {
+		t.Errorf("Expected only the earlier channel to survive, got %v", reopened.GetAllChannels())
+	}
+}
+
+// TestFileEventStoreRejectsStaleVersion verifies the optimistic concurrency check of AppendEvents.
+// Expected result: a *domain.ConcurrencyError with the actual version, and nothing written.
+func TestFileEventStoreRejectsStaleVersion(t *testing.T) {
+	// Arrange
+	store, err := NewFileEventStore(FileEventStoreConfig{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Expected no error opening store, got %v", err)
+	}
+	defer store.Close()
+	appendLaunchAndSpeedUps(t, store, "rocket-occ", 1)
+	channel, _ := domain.NewChannel("rocket-occ")
+	msgNum, _ := domain.NewMessageNumber(3)
+
+	// Act
+	err = store.AppendEvents(channel, 1, []domain.DomainEvent{
+		&domain.RocketExploded{Channel: channel, MessageNumber: msgNum, Reason: "late writer", Timestamp: 3},
+	})
+
+	// Assert
+	var conflict *domain.ConcurrencyError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("Expected ConcurrencyError, got %v", err)
+	}
+	if conflict.Expected != 1 || conflict.Actual != 2 {
+		t.Errorf("Expected versions 1/2, got %d/%d", conflict.Expected, conflict.Actual)
+	}
+	events, _ := store.GetEventsByChannel(channel)
+	if len(events) != 2 {
+		t.Errorf("Expected 2 events, got %d", len(events))
+	}
+}
+
+// TestFileEventStoreReadAllPositions verifies that the global log keeps commit order across restarts.
+// Expected result: positions 1..n in append order, pages split at the limit, same positions after reopen.
+func TestFileEventStoreReadAllPositions(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	store, err := NewFileEventStore(FileEventStoreConfig{Dir: dir})
+	if err != nil {
+		t.Fatalf("Expected no error opening store, got %v", err)
+	}
+	appendLaunchAndSpeedUps(t, store, "rocket-b", 1)
+	appendLaunchAndSpeedUps(t, store, "rocket-a", 2)
+	_ = store.Close()
+
+	// Act
+	reopened, err := NewFileEventStore(FileEventStoreConfig{Dir: dir})
+	if err != nil {
+		t.Fatalf("Expected no error reopening store, got %v", err)
+	}
+	defer reopened.Close()
+	all, _ := reopened.ReadAll(1, 0)
+	page, _ := reopened.ReadAll(2, 2)
+	past, _ := reopened.ReadAll(6, 10)
+
+	// Assert
+	wantChannels := []string{"rocket-b", "rocket-b", "rocket-a", "rocket-a", "rocket-a"}
+	if len(all) != len(wantChannels) {
+		t.Fatalf("Expected %d events, got %d", len(wantChannels), len(all))
+	}
+	for i, recorded := range all {
+		if recorded.Position != int64(i+1) || recorded.Event.GetChannel().Value() != wantChannels[i] {
+			t.Errorf("Event %d: expected position %d on %s, got %d on %s", i, i+1, wantChannels[i], recorded.Position, recorded.Event.GetChannel().Value())
+		}
+	}
+	if len(page) != 2 || page[0].Position != 2 || page[1].Position != 3 {
+		t.Errorf("Expected page with positions 2 and 3, got %+v", page)
+	}
+	if len(past) != 0 {
+		t.Errorf("Expected no events past the end of the log, got %d", len(past))
+	}
+	if channels := reopened.GetAllChannels(); channels[0] != "rocket-b" || channels[1] != "rocket-a" {
+		t.Errorf("Expected channels in order of first event, got %v", channels)
+	}
+}
+
+// TestFileEventStoreKeepsMetadata verifies that event metadata is stored with the event.
+// Expected result: the reopened store returns the metadata the event was appended with.
+func TestFileEventStoreKeepsMetadata(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	store, err := NewFileEventStore(FileEventStoreConfig{Dir: dir})
+	if err != nil {
+		t.Fatalf("Expected no error opening store, got %v", err)
+	}
+	channel, _ := domain.NewChannel("rocket-meta")
+	msgNum, _ := domain.NewMessageNumber(1)
+	metadata := domain.EventMetadata{domain.MetadataEventID: "evt-1", domain.MetadataCorrelationID: "corr-1"}
+	_ = store.AppendEvent(&domain.RocketExploded{Channel: channel, MessageNumber: msgNum, Reason: "test", Timestamp: 1, Metadata: metadata})
+	_ = store.Close()
+
+	// Act
+	reopened, err := NewFileEventStore(FileEventStoreConfig{Dir: dir})
+	if err != nil {
+		t.Fatalf("Expected no error reopening store, got %v", err)
+	}
+	defer reopened.Close()
+	events, _ := reopened.GetEventsByChannel(channel)
+
+	// Assert
+	if len(events) != 1 || events[0].GetMetadata().Get(domain.MetadataCorrelationID) != "corr-1" || events[0].GetMetadata().Get(domain.MetadataEventID) != "evt-1" {
+		t.Errorf("Expected metadata to survive a restart, got %v", events[0].GetMetadata())
+	}
+}
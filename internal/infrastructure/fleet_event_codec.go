@@ -0,0 +1,74 @@
+package infrastructure
+
+import (
+	"rockets/internal/domain"
+)
+
+type fleetCreatedPayload struct {
+	eventHeader
+	FleetID string `json:"fleetId"`
+	Name    string `json:"name"`
+}
+
+type fleetMemberPayload struct {
+	eventHeader
+	Rocket string `json:"rocket"`
+}
+
+// registerFleetEvents registers the current schema of every fleet event
+func registerFleetEvents(c *EventCodec) {
+	_ = c.Register("fleet_created", 1,
+		typedEncoder(func(e *domain.FleetCreated) fleetCreatedPayload {
+			return fleetCreatedPayload{eventHeader: newEventHeader(e), FleetID: e.FleetID, Name: e.Name}
+		}),
+		typedDecoder(func(p fleetCreatedPayload, metadata domain.EventMetadata) (domain.DomainEvent, error) {
+			channel, msgNum, err := p.values()
+			if err != nil {
+				return nil, err
+			}
+			return &domain.FleetCreated{
+				Channel:       channel,
+				MessageNumber: msgNum,
+				FleetID:       p.FleetID,
+				Name:          p.Name,
+				Timestamp:     p.Timestamp,
+				Metadata:      metadata,
+			}, nil
+		}))
+
+	_ = c.Register("fleet_rocket_added", 1,
+		typedEncoder(func(e *domain.FleetRocketAdded) fleetMemberPayload {
+			return fleetMemberPayload{eventHeader: newEventHeader(e), Rocket: e.Rocket}
+		}),
+		typedDecoder(func(p fleetMemberPayload, metadata domain.EventMetadata) (domain.DomainEvent, error) {
+			channel, msgNum, err := p.values()
+			if err != nil {
+				return nil, err
+			}
+			return &domain.FleetRocketAdded{
+				Channel:       channel,
+				MessageNumber: msgNum,
+				Rocket:        p.Rocket,
+				Timestamp:     p.Timestamp,
+				Metadata:      metadata,
+			}, nil
+		}))
+
+	_ = c.Register("fleet_rocket_removed", 1,
+		typedEncoder(func(e *domain.FleetRocketRemoved) fleetMemberPayload {
+			return fleetMemberPayload{eventHeader: newEventHeader(e), Rocket: e.Rocket}
+		}),
+		typedDecoder(func(p fleetMemberPayload, metadata domain.EventMetadata) (domain.DomainEvent, error) {
+			channel, msgNum, err := p.values()
+			if err != nil {
+				return nil, err
+			}
+			return &domain.FleetRocketRemoved{
+				Channel:       channel,
+				MessageNumber: msgNum,
+				Rocket:        p.Rocket,
+				Timestamp:     p.Timestamp,
+				Metadata:      metadata,
+			}, nil
+		}))
+}
@@ -0,0 +1,81 @@
+package infrastructure
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"rockets/internal/domain"
+)
+
+// FleetRepository implements the FleetRepository on top of an event store that holds fleet
+// streams only, so they never show up among the rockets, their exports or their hash chains.
+// Fleets are few and small, so they are rebuilt from their stream on every load.
+type FleetRepository struct {
+	eventStore domain.EventStore
+}
+
+// NewFleetRepository creates a new FleetRepository; eventStore must not be the rockets' store
+func NewFleetRepository(eventStore domain.EventStore) *FleetRepository {
+	return &FleetRepository{eventStore: eventStore}
+}
+
+// GetByID rebuilds a fleet from its stream
+func (r *FleetRepository) GetByID(id string) (*domain.Fleet, error) {
+	fleet, err := domain.NewFleet(id)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := r.eventStore.GetEventsByChannel(fleet.GetStream())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load events: %w", err)
+	}
+	fleet.LoadFromHistory(events)
+	return fleet, nil
+}
+
+// GetEvents gets the events of a fleet's stream
+func (r *FleetRepository) GetEvents(id string) ([]domain.DomainEvent, error) {
+	fleet, err := domain.NewFleet(id)
+	if err != nil {
+		return nil, err
+	}
+	return r.eventStore.GetEventsByChannel(fleet.GetStream())
+}
+
+// Save persists a fleet's uncommitted events as one atomic batch, provided nobody else
+// appended to its stream since it was loaded; otherwise a *domain.ConcurrencyError is returned
+func (r *FleetRepository) Save(fleet *domain.Fleet) error {
+	if fleet == nil {
+		return fmt.Errorf("fleet cannot be nil")
+	}
+
+	events := fleet.GetUncommittedEvents()
+	expectedVersion := fleet.GetVersion() - len(events)
+	if err := r.eventStore.AppendEvents(fleet.GetStream(), expectedVersion, events); err != nil {
+		if errors.Is(err, domain.ErrConcurrencyConflict) {
+			slog.Warn("Concurrent write detected", "fleet", fleet.GetID(), "err", err)
+			return err
+		}
+		return fmt.Errorf("failed to save events: %w", err)
+	}
+
+	slog.Info("Fleet saved successfully", "fleet", fleet.GetID(), "total_events", len(events))
+	fleet.MarkEventsAsCommitted()
+	return nil
+}
+
+// GetAll gets all fleets, in the order they were created
+func (r *FleetRepository) GetAll() ([]*domain.Fleet, error) {
+	var fleets []*domain.Fleet
+	for _, channel := range r.eventStore.GetAllChannels() {
+		fleet, err := r.GetByID(strings.TrimPrefix(channel, domain.FleetStreamPrefix))
+		if err != nil {
+			continue // Skip streams that do not name a valid fleet
+		}
+		fleets = append(fleets, fleet)
+	}
+	return fleets, nil
+}
@@ -0,0 +1,278 @@
+package infrastructure
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"rockets/internal/domain"
+	"rockets/internal/infrastructure/kafka"
+)
+
+// chainHash links an envelope to the hash of the previous event of its channel:
+// sha256(prevHash | type | schemaVersion | payload | metadata), hex encoded, followed by the
+// sealed payload of an encrypted event and a marker for a redacted one (so the hashes of
+// plain events did not change when encryption was introduced).
+// The first event of a channel is chained to the empty string.
+func chainHash(prevHash string, envelope *EventEnvelope) (string, error) {
+	var payload bytes.Buffer
+	if err := json.Compact(&payload, envelope.Payload); err != nil {
+		return "", fmt.Errorf("invalid payload: %w", err)
+	}
+	var metadata []byte
+	if len(envelope.Metadata) > 0 { // an empty map is not stored (omitempty)
+		encoded, err := json.Marshal(envelope.Metadata) // map keys are sorted
+		if err != nil {
+			return "", err
+		}
+		metadata = encoded
+	}
+
+	parts := [][]byte{
+		[]byte(prevHash),
+		[]byte(envelope.Type),
+		[]byte(strconv.Itoa(envelope.SchemaVersion)),
+		payload.Bytes(),
+		metadata,
+	}
+	if len(envelope.Sealed) > 0 {
+		parts = append(parts, envelope.Sealed)
+	}
+	if envelope.Redacted {
+		parts = append(parts, []byte("redacted"))
+	}
+
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write(part)
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// chainHead is the verification state of one channel
+type chainHead struct {
+	version int
+	hash    string
+	broken  bool
+}
+
+// chainVerifier recomputes the hash chains of a log fed to it batch by batch, in log order
+type chainVerifier struct {
+	heads  map[string]*chainHead
+	report *domain.ChainReport
+}
+
+func newChainVerifier() *chainVerifier {
+	return &chainVerifier{
+		heads:  make(map[string]*chainHead),
+		report: &domain.ChainReport{Breaks: []domain.ChainBreak{}},
+	}
+}
+
+// batch checks the records of one append. Batches whose expected version does not match
+// were rejected when they were written and are not part of the history.
+// location describes where the record at index i of the batch is stored.
+func (v *chainVerifier) batch(records []*eventRecord, expectedVersion int, location func(i int) string) {
+	channel, err := records[0].channel()
+	if err != nil {
+		v.damage(location(0), fmt.Sprintf("unreadable record: %v", err))
+		return
+	}
+	head := v.heads[channel]
+	if head == nil {
+		head = &chainHead{}
+		v.heads[channel] = head
+		v.report.Channels++
+	}
+	if expectedVersion != domain.AnyVersion && expectedVersion != head.version {
+		return
+	}
+
+	for i, record := range records {
+		head.version++
+		v.report.Events++
+		if head.broken {
+			continue
+		}
+
+		reason := ""
+		if recordChannel, err := record.channel(); err != nil || recordChannel != channel {
+			reason = "record does not belong to the channel of its batch"
+		} else if record.Hash == "" {
+			reason = "hash missing"
+		} else if expected, err := chainHash(head.hash, &record.EventEnvelope); err != nil {
+			reason = err.Error()
+		} else if expected != record.Hash {
+			reason = "hash does not match the event and its predecessor"
+		}
+
+		if reason != "" {
+			head.broken = true
+			v.report.Breaks = append(v.report.Breaks, domain.ChainBreak{
+				Channel:     channel,
+				EventNumber: head.version,
+				Location:    location(i),
+				Reason:      reason,
+			})
+			continue
+		}
+		head.hash = record.Hash
+	}
+}
+
+// damage reports a part of the log that cannot be attributed to a channel
+func (v *chainVerifier) damage(location, reason string) {
+	v.report.Breaks = append(v.report.Breaks, domain.ChainBreak{Location: location, Reason: reason})
+}
+
+func (v *chainVerifier) result() *domain.ChainReport {
+	v.report.Intact = len(v.report.Breaks) == 0
+	return v.report
+}
+
+// VerifyFileEventLog verifies the hash chains of the segments in dir without opening the
+// store, so it can run against a stopped server. A torn record at the end of the last
+// segment is treated as the end of the log, as recovery does.
+func VerifyFileEventLog(dir string) (*domain.ChainReport, error) {
+	bases, err := listSegments(dir)
+	if err != nil {
+		return nil, err
+	}
+	return verifySegments(dir, bases, -1)
+}
+
+// VerifyChains verifies the hash chains of everything appended so far
+func (s *FileEventStore) VerifyChains() (*domain.ChainReport, error) {
+	s.mu.Lock()
+	bases, err := listSegments(s.cfg.Dir)
+	activeSize := s.activeSize
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return verifySegments(s.cfg.Dir, bases, activeSize)
+}
+
+// verifySegments walks the given segments; the last one is read up to lastSize bytes
+// (all of it if lastSize < 0)
+func verifySegments(dir string, bases []uint64, lastSize int64) (*domain.ChainReport, error) {
+	v := newChainVerifier()
+	nextSeq := uint64(1)
+	for i, base := range bases {
+		name := segmentName(base)
+		last := i == len(bases)-1
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read segment: %w", err)
+		}
+		if last && lastSize >= 0 && int64(len(data)) > lastSize {
+			data = data[:lastSize]
+		}
+		if base != nextSeq {
+			v.damage(name, fmt.Sprintf("segment starts at record %d, expected %d", base, nextSeq))
+			return v.result(), nil
+		}
+		if int64(len(data)) < segmentHeaderSize || string(data[:segmentHeaderSize]) != segmentMagic {
+			v.damage(name, "invalid segment header")
+			return v.result(), nil
+		}
+
+		offset := segmentHeaderSize
+		for offset < int64(len(data)) {
+			payload, size, err := decodeFrame(data[offset:])
+			if err != nil {
+				if last && errors.Is(err, errTornRecord) && lastSize < 0 {
+					break
+				}
+				v.damage(fmt.Sprintf("%s@%d", name, offset), err.Error())
+				return v.result(), nil
+			}
+			records, err := decodeRecords(payload)
+			if err != nil {
+				v.damage(fmt.Sprintf("%s@%d", name, offset), fmt.Sprintf("invalid record: %v", err))
+				return v.result(), nil
+			}
+			for j, record := range records {
+				if record.Seq != nextSeq+uint64(j) {
+					v.damage(fmt.Sprintf("%s@%d", name, offset), fmt.Sprintf("record has sequence %d, expected %d", record.Seq, nextSeq+uint64(j)))
+					return v.result(), nil
+				}
+			}
+			v.batch(records, domain.AnyVersion, func(j int) string {
+				return fmt.Sprintf("%s record %d", name, records[j].Seq)
+			})
+			nextSeq += uint64(len(records))
+			offset += size
+		}
+	}
+	return v.result(), nil
+}
+
+// VerifyKafkaTopic verifies the hash chains of a topic, reading it from the beginning with
+// its own connection
+func VerifyKafkaTopic(cfg KafkaConfig) (*domain.ChainReport, error) {
+	brokers := splitBrokers(cfg.Brokers)
+	if len(brokers) == 0 {
+		return nil, fmt.Errorf("no Kafka brokers configured")
+	}
+	if cfg.Topic == "" {
+		cfg.Topic = defaultKafkaTopic
+	}
+	if cfg.ClientID == "" {
+		cfg.ClientID = defaultKafkaClientID
+	}
+
+	client := kafka.NewClient(brokers, cfg.ClientID+"-verify")
+	defer client.Close()
+	partitions, err := client.Partitions(cfg.Topic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load metadata for topic %s: %w", cfg.Topic, err)
+	}
+
+	v := newChainVerifier()
+	for p := int32(0); p < partitions; p++ {
+		offset := int64(0)
+		for {
+			results, err := client.Fetch(cfg.Topic, map[int32]int64{p: offset}, 0)
+			if err != nil {
+				return nil, err
+			}
+			res := results[p]
+			if res.Err != nil {
+				return nil, fmt.Errorf("partition %d: %w", p, res.Err)
+			}
+			for _, r := range res.Records {
+				if r.Offset < offset {
+					continue
+				}
+				offset = r.Offset + 1
+				location := fmt.Sprintf("partition %d offset %d", p, r.Offset)
+				batch, err := decodeKafkaBatch(r.Value)
+				if err != nil || len(batch.Events) == 0 {
+					v.damage(location, "undecodable record")
+					continue
+				}
+				v.batch(batch.Events, batch.ExpectedVersion, func(int) string { return location })
+			}
+			if offset >= res.HighWatermark || len(res.Records) == 0 {
+				break
+			}
+		}
+	}
+	return v.result(), nil
+}
+
+// VerifyChains verifies the hash chains of the topic
+func (k *KafkaEventStore) VerifyChains() (*domain.ChainReport, error) {
+	if k.producer == nil {
+		return nil, fmt.Errorf("%w: no Kafka brokers configured", domain.ErrHashChainUnsupported)
+	}
+	return VerifyKafkaTopic(k.cfg)
+}
@@ -0,0 +1,193 @@
+package infrastructure
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"regexp"
+	"sync"
+	"testing"
+
+	"rockets/internal/domain"
+)
+
+// rewriteFrame replaces old with new inside the frame that contains it and fixes the frame's
+// length and checksum, as someone editing the log on purpose would.
+func rewriteFrame(t *testing.T, path, old, new string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read segment: %v", err)
+	}
+	var out bytes.Buffer
+	out.Write(data[:segmentHeaderSize])
+	replaced := false
+	for offset := segmentHeaderSize; offset < int64(len(data)); {
+		payload, size, err := decodeFrame(data[offset:])
+		if err != nil {
+			t.Fatalf("Failed to decode frame at %d: %v", offset, err)
+		}
+		if !replaced && bytes.Contains(payload, []byte(old)) {
+			payload = bytes.Replace(payload, []byte(old), []byte(new), 1)
+			replaced = true
+		}
+		header := make([]byte, recordHeaderSize)
+		binary.BigEndian.PutUint32(header[0:4], uint32(len(payload)))
+		binary.BigEndian.PutUint32(header[4:8], crc32.Checksum(payload, crcTable))
+		out.Write(header)
+		out.Write(payload)
+		offset += size
+	}
+	if !replaced {
+		t.Fatalf("Expected %q in %s", old, path)
+	}
+	if err := os.WriteFile(path, out.Bytes(), segmentFilePermission); err != nil {
+		t.Fatalf("Failed to write segment: %v", err)
+	}
+}
+
+// TestFileEventStoreChainIntact verifies that an untouched log passes verification.
+// Expected result: intact report counting every channel and event, from the store and offline.
+func TestFileEventStoreChainIntact(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	store, err := NewFileEventStore(FileEventStoreConfig{Dir: dir, MaxSegmentBytes: 600})
+	if err != nil {
+		t.Fatalf("Expected no error opening store, got %v", err)
+	}
+	appendLaunchAndSpeedUps(t, store, "rocket-a", 4)
+	appendLaunchAndSpeedUps(t, store, "rocket-b", 2)
+
+	// Act
+	live, err := store.VerifyChains()
+	_ = store.Close()
+	offline, offlineErr := VerifyFileEventLog(dir)
+
+	// Assert
+	if err != nil || offlineErr != nil {
+		t.Fatalf("Expected no errors, got %v and %v", err, offlineErr)
+	}
+	for _, report := range []*struct {
+		name   string
+		intact bool
+		events int
+	}{{"live", live.Intact, live.Events}, {"offline", offline.Intact, offline.Events}} {
+		if !report.intact || report.events != 8 {
+			t.Errorf("Expected %s report intact with 8 events, got intact=%v events=%d", report.name, report.intact, report.events)
+		}
+	}
+	if offline.Channels != 2 {
+		t.Errorf("Expected 2 channels, got %d", offline.Channels)
+	}
+}
+
+// TestFileEventStoreChainDetectsRewrite verifies that editing an event is detected even when the
+// frame checksum is recomputed.
+// Expected result: one break on rocket-a at the edited event; rocket-b is not reported.
+func TestFileEventStoreChainDetectsRewrite(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	store, err := NewFileEventStore(FileEventStoreConfig{Dir: dir})
+	if err != nil {
+		t.Fatalf("Expected no error opening store, got %v", err)
+	}
+	appendLaunchAndSpeedUps(t, store, "rocket-a", 4)
+	appendLaunchAndSpeedUps(t, store, "rocket-b", 2)
+	_ = store.Close()
+	rewriteFrame(t, lastSegmentPath(t, dir), `"newSpeed":10200`, `"newSpeed":10900`)
+
+	// Act
+	report, err := VerifyFileEventLog(dir)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if report.Intact || len(report.Breaks) != 1 {
+		t.Fatalf("Expected exactly one break, got %+v", report.Breaks)
+	}
+	if b := report.Breaks[0]; b.Channel != "rocket-a" || b.EventNumber != 3 {
+		t.Errorf("Expected break at event 3 of rocket-a, got %+v", b)
+	}
+}
+
+// TestFileEventStoreChainDetectsMissingHash verifies that stripping the hash from the first
+// event of a channel is not mistaken for an unchained record.
+// Expected result: one break on rocket-a at event 1.
+func TestFileEventStoreChainDetectsMissingHash(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	store, err := NewFileEventStore(FileEventStoreConfig{Dir: dir})
+	if err != nil {
+		t.Fatalf("Expected no error opening store, got %v", err)
+	}
+	appendLaunchAndSpeedUps(t, store, "rocket-a", 2)
+	_ = store.Close()
+	path := lastSegmentPath(t, dir)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read segment: %v", err)
+	}
+	hash := regexp.MustCompile(`,"hash":"[0-9a-f]+"`).Find(data)
+	if hash == nil {
+		t.Fatalf("Expected a hash in %s", path)
+	}
+	rewriteFrame(t, path, string(hash), "")
+
+	// Act
+	report, err := VerifyFileEventLog(dir)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if report.Intact || len(report.Breaks) != 1 {
+		t.Fatalf("Expected exactly one break, got %+v", report.Breaks)
+	}
+	if b := report.Breaks[0]; b.Channel != "rocket-a" || b.EventNumber != 1 || b.Reason != "hash missing" {
+		t.Errorf("Expected missing hash at event 1 of rocket-a, got %+v", b)
+	}
+}
+
+// TestKafkaEventStoreChainAcrossInstances verifies the chain when several instances append
+// to the same channel without an expected version.
+// Expected result: all appends succeed and the topic verifies intact.
+func TestKafkaEventStoreChainAcrossInstances(t *testing.T) {
+	// Arrange
+	broker := startFakeBroker(t, 2)
+	stores := []*KafkaEventStore{openKafkaStore(t, broker), openKafkaStore(t, broker)}
+
+	channel, _ := domain.NewChannel("rocket-shared")
+
+	// Act
+	var wg sync.WaitGroup
+	errs := make(chan error, 13)
+	for i, store := range stores {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < 6+i; n++ {
+				msgNum, _ := domain.NewMessageNumber(n + 1)
+				errs <- store.AppendEvent(&domain.RocketExploded{Channel: channel, MessageNumber: msgNum, Reason: fmt.Sprintf("writer %d", i), Timestamp: int64(n)})
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	report, err := stores[0].VerifyChains()
+
+	// Assert
+	for appendErr := range errs {
+		if appendErr != nil {
+			t.Errorf("Expected every append to succeed, got %v", appendErr)
+		}
+	}
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !report.Intact || report.Events != 13 {
+		t.Errorf("Expected intact chain with 13 events, got intact=%v events=%d breaks=%+v", report.Intact, report.Events, report.Breaks)
+	}
+}
@@ -0,0 +1,356 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultTimeout       = 10 * time.Second
+	metadataRetries      = 20
+	metadataRetryBackoff = 100 * time.Millisecond
+	fetchMaxBytes        = 8 << 20
+)
+
+// Client is a minimal Kafka client. It keeps one connection per broker and sends one
+// request at a time on each of them, so callers that long-poll should use their own Client.
+type Client struct {
+	bootstrap []string
+	clientID  string
+	timeout   time.Duration
+
+	mu      sync.Mutex
+	conns   map[string]*brokerConn
+	brokers map[int32]string           // node id -> address
+	leaders map[string]map[int32]int32 // topic -> partition -> leader node id
+	closed  bool
+}
+
+// brokerConn is a connection to a single broker
+type brokerConn struct {
+	mu            sync.Mutex
+	conn          net.Conn
+	correlationID int32
+}
+
+// FetchResult holds the records fetched from one partition
+type FetchResult struct {
+	Records       []Record
+	HighWatermark int64
+	Err           error
+}
+
+// NewClient creates a client for the given bootstrap brokers ("host:port")
+func NewClient(bootstrap []string, clientID string) *Client {
+	return &Client{
+		bootstrap: bootstrap,
+		clientID:  clientID,
+		timeout:   defaultTimeout,
+		conns:     make(map[string]*brokerConn),
+		brokers:   make(map[int32]string),
+		leaders:   make(map[string]map[int32]int32),
+	}
+}
+
+// Partitions returns the partition count of a topic, creating it if the broker allows it
+func (c *Client) Partitions(topic string) (int32, error) {
+	if err := c.RefreshMetadata(topic); err != nil {
+		return 0, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return int32(len(c.leaders[topic])), nil
+}
+
+// RefreshMetadata reloads brokers and partition leaders for a topic
+func (c *Client) RefreshMetadata(topic string) error {
+	var lastErr error
+	for attempt := 0; attempt < metadataRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(metadataRetryBackoff)
+		}
+		lastErr = c.refreshOnce(topic)
+		if lastErr == nil {
+			return nil
+		}
+		var kerr KError
+		if errors.As(lastErr, &kerr) && !kerr.Retriable() {
+			return lastErr
+		}
+	}
+	return lastErr
+}
+
+func (c *Client) refreshOnce(topic string) error {
+	req := &metadataRequest{topics: []string{topic}, allowAutoTopicCreation: true}
+	var resp metadataResponse
+	var lastErr error
+	for _, addr := range c.metadataAddrs() {
+		d, err := c.roundTrip(addr, apiKeyMetadata, metadataVersion, req.encode, 0)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		resp.decode(d)
+		if d.err != nil {
+			return d.err
+		}
+		lastErr = nil
+		break
+	}
+	if lastErr != nil {
+		return fmt.Errorf("kafka: metadata request failed: %w", lastErr)
+	}
+
+	for _, t := range resp.topics {
+		if t.name != topic {
+			continue
+		}
+		if t.err != ErrNone {
+			return t.err
+		}
+		if len(t.partitions) == 0 {
+			return ErrLeaderNotAvailable
+		}
+		leaders := make(map[int32]int32, len(t.partitions))
+		for _, p := range t.partitions {
+			if p.err != ErrNone {
+				return p.err
+			}
+			leaders[p.index] = p.leader
+		}
+
+		c.mu.Lock()
+		for _, b := range resp.brokers {
+			c.brokers[b.nodeID] = net.JoinHostPort(b.host, strconv.Itoa(int(b.port)))
+		}
+		c.leaders[topic] = leaders
+		c.mu.Unlock()
+		return nil
+	}
+	return ErrUnknownTopicOrPartition
+}
+
+// Produce appends records to a partition and returns the offset of the first one.
+// It waits for every in-sync replica to acknowledge the write.
+func (c *Client) Produce(topic string, partition int32, records []Record) (int64, error) {
+	addr, err := c.leaderAddr(topic, partition)
+	if err != nil {
+		return 0, err
+	}
+	req := &produceRequest{
+		acks:      -1,
+		timeoutMs: int32(c.timeout / time.Millisecond),
+		topics: []produceTopic{{
+			name:       topic,
+			partitions: []producePartition{{index: partition, records: encodeRecordBatch(0, records)}},
+		}},
+	}
+	d, err := c.roundTrip(addr, apiKeyProduce, produceVersion, req.encode, 0)
+	if err != nil {
+		return 0, err
+	}
+	var resp produceResponse
+	resp.decode(d)
+	if d.err != nil {
+		return 0, d.err
+	}
+	for _, t := range resp.topics {
+		for _, p := range t.partitions {
+			if t.name == topic && p.index == partition {
+				if p.err != ErrNone {
+					return 0, p.err
+				}
+				return p.baseOffset, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("kafka: produce response without partition %d", partition)
+}
+
+// Fetch reads records from several partitions starting at the given offsets.
+// When no data is available the broker holds the request for up to maxWait.
+func (c *Client) Fetch(topic string, offsets map[int32]int64, maxWait time.Duration) (map[int32]FetchResult, error) {
+	// Group partitions by leader
+	byLeader := make(map[string][]fetchPartition)
+	for partition, offset := range offsets {
+		addr, err := c.leaderAddr(topic, partition)
+		if err != nil {
+			return nil, err
+		}
+		byLeader[addr] = append(byLeader[addr], fetchPartition{index: partition, fetchOffset: offset, maxBytes: fetchMaxBytes})
+	}
+
+	type leaderResult struct {
+		results map[int32]FetchResult
+		err     error
+	}
+	out := make(chan leaderResult, len(byLeader))
+	for addr, partitions := range byLeader {
+		go func(addr string, partitions []fetchPartition) {
+			results, err := c.fetchFrom(addr, topic, partitions, maxWait)
+			out <- leaderResult{results: results, err: err}
+		}(addr, partitions)
+	}
+
+	results := make(map[int32]FetchResult, len(offsets))
+	var firstErr error
+	for range byLeader {
+		r := <-out
+		if r.err != nil && firstErr == nil {
+			firstErr = r.err
+		}
+		for p, res := range r.results {
+			results[p] = res
+		}
+	}
+	return results, firstErr
+}
+
+func (c *Client) fetchFrom(addr, topic string, partitions []fetchPartition, maxWait time.Duration) (map[int32]FetchResult, error) {
+	req := &fetchRequest{
+		replicaID: -1,
+		maxWaitMs: int32(maxWait / time.Millisecond),
+		minBytes:  1,
+		maxBytes:  fetchMaxBytes,
+		topics:    []fetchTopic{{name: topic, partitions: partitions}},
+	}
+	d, err := c.roundTrip(addr, apiKeyFetch, fetchVersion, req.encode, maxWait)
+	if err != nil {
+		return nil, err
+	}
+	var resp fetchResponse
+	resp.decode(d)
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	results := make(map[int32]FetchResult)
+	for _, t := range resp.topics {
+		if t.name != topic {
+			continue
+		}
+		for _, p := range t.partitions {
+			if p.err != ErrNone {
+				results[p.index] = FetchResult{Err: p.err}
+				continue
+			}
+			records, err := decodeRecordBatches(p.records)
+			results[p.index] = FetchResult{Records: records, HighWatermark: p.highWatermark, Err: err}
+		}
+	}
+	return results, nil
+}
+
+// Close closes every broker connection
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	for addr, bc := range c.conns {
+		// Closing the socket unblocks a request in flight on it
+		_ = bc.conn.Close()
+		delete(c.conns, addr)
+	}
+	return nil
+}
+
+// leaderAddr returns the address of the leader of a partition, loading metadata if needed
+func (c *Client) leaderAddr(topic string, partition int32) (string, error) {
+	for attempt := 0; attempt < 2; attempt++ {
+		c.mu.Lock()
+		leader, ok := c.leaders[topic][partition]
+		addr, known := c.brokers[leader]
+		c.mu.Unlock()
+		if ok && known {
+			return addr, nil
+		}
+		if err := c.RefreshMetadata(topic); err != nil {
+			return "", err
+		}
+	}
+	return "", ErrLeaderNotAvailable
+}
+
+// metadataAddrs returns the known brokers followed by the bootstrap list
+func (c *Client) metadataAddrs() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	addrs := make([]string, 0, len(c.brokers)+len(c.bootstrap))
+	for _, addr := range c.brokers {
+		addrs = append(addrs, addr)
+	}
+	return append(addrs, c.bootstrap...)
+}
+
+// roundTrip sends a request to a broker and returns a decoder positioned on the response body
+func (c *Client) roundTrip(addr string, apiKey, version int16, body func(*encoder), extra time.Duration) (*decoder, error) {
+	bc, err := c.conn(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	bc.correlationID++
+	correlationID := bc.correlationID
+
+	e := &encoder{}
+	e.int16(apiKey)
+	e.int16(version)
+	e.int32(correlationID)
+	e.nullableString(&c.clientID)
+	body(e)
+
+	_ = bc.conn.SetDeadline(time.Now().Add(c.timeout + extra))
+	if err := writeFrame(bc.conn, e.buf); err != nil {
+		c.dropConn(addr, bc)
+		return nil, fmt.Errorf("kafka: write to %s: %w", addr, err)
+	}
+	payload, err := readFrame(bc.conn)
+	if err != nil {
+		c.dropConn(addr, bc)
+		return nil, fmt.Errorf("kafka: read from %s: %w", addr, err)
+	}
+
+	d := &decoder{buf: payload}
+	if got := d.int32(); got != correlationID {
+		c.dropConn(addr, bc)
+		return nil, fmt.Errorf("kafka: correlation id mismatch: got %d, want %d", got, correlationID)
+	}
+	return d, nil
+}
+
+// conn returns the connection to a broker, dialing it if needed
+func (c *Client) conn(addr string) (*brokerConn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil, fmt.Errorf("kafka: client closed")
+	}
+	if bc, ok := c.conns[addr]; ok {
+		return bc, nil
+	}
+	conn, err := net.DialTimeout("tcp", addr, c.timeout)
+	if err != nil {
+		return nil, fmt.Errorf("kafka: dial %s: %w", addr, err)
+	}
+	bc := &brokerConn{conn: conn}
+	c.conns[addr] = bc
+	return bc, nil
+}
+
+// dropConn closes a broken connection so the next request redials
+func (c *Client) dropConn(addr string, bc *brokerConn) {
+	_ = bc.conn.Close()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conns[addr] == bc {
+		delete(c.conns, addr)
+	}
+}
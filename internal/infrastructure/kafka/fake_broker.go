@@ -0,0 +1,368 @@
+package kafka
+
+import (
+	"errors"
+	"log/slog"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// FakeBroker is a single-node, in-memory Kafka broker that speaks the same subset of the
+// wire protocol as Client. Topics are created on first use with a fixed partition count.
+type FakeBroker struct {
+	listener   net.Listener
+	partitions int32
+
+	mu      sync.Mutex
+	topics  map[string][]*fakePartition
+	changed chan struct{} // closed and replaced whenever a partition grows
+	conns   map[net.Conn]struct{}
+	closed  bool
+	wg      sync.WaitGroup
+}
+
+type fakeBatch struct {
+	baseOffset int64
+	nextOffset int64
+	data       []byte
+}
+
+type fakePartition struct {
+	batches    []fakeBatch
+	nextOffset int64
+}
+
+// NewFakeBroker starts a broker on a random local port
+func NewFakeBroker(partitions int) (*FakeBroker, error) {
+	if partitions <= 0 {
+		partitions = 1
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	b := &FakeBroker{
+		listener:   listener,
+		partitions: int32(partitions),
+		topics:     make(map[string][]*fakePartition),
+		changed:    make(chan struct{}),
+		conns:      make(map[net.Conn]struct{}),
+	}
+	b.wg.Add(1)
+	go b.acceptLoop()
+	return b, nil
+}
+
+// Addr returns the "host:port" the broker listens on
+func (b *FakeBroker) Addr() string {
+	return b.listener.Addr().String()
+}
+
+// Close stops the broker and drops every connection
+func (b *FakeBroker) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	close(b.changed)
+	for conn := range b.conns {
+		_ = conn.Close()
+	}
+	b.mu.Unlock()
+
+	err := b.listener.Close()
+	b.wg.Wait()
+	return err
+}
+
+func (b *FakeBroker) acceptLoop() {
+	defer b.wg.Done()
+	for {
+		conn, err := b.listener.Accept()
+		if err != nil {
+			return
+		}
+		b.mu.Lock()
+		if b.closed {
+			b.mu.Unlock()
+			_ = conn.Close()
+			return
+		}
+		b.conns[conn] = struct{}{}
+		b.mu.Unlock()
+
+		b.wg.Add(1)
+		go b.serve(conn)
+	}
+}
+
+func (b *FakeBroker) serve(conn net.Conn) {
+	defer b.wg.Done()
+	defer func() {
+		b.mu.Lock()
+		delete(b.conns, conn)
+		b.mu.Unlock()
+		_ = conn.Close()
+	}()
+
+	for {
+		payload, err := readFrame(conn)
+		if err != nil {
+			return
+		}
+		d := &decoder{buf: payload}
+		header := requestHeader{
+			apiKey:        d.int16(),
+			apiVersion:    d.int16(),
+			correlationID: d.int32(),
+			clientID:      d.nullableString(),
+		}
+		if d.err != nil {
+			return
+		}
+
+		e := &encoder{}
+		e.int32(header.correlationID)
+		if !b.handle(header, d, e) {
+			slog.Debug("Fake broker closing connection", "api_key", header.apiKey, "api_version", header.apiVersion)
+			return
+		}
+		if err := writeFrame(conn, e.buf); err != nil {
+			return
+		}
+	}
+}
+
+// handle dispatches a request and encodes its response; false closes the connection,
+// which is what real brokers do with requests they cannot parse
+func (b *FakeBroker) handle(header requestHeader, d *decoder, e *encoder) bool {
+	switch {
+	case header.apiKey == apiKeyAPIVersions:
+		resp := &apiVersionsResponse{apis: []apiVersionRange{
+			{apiKey: apiKeyProduce, minVersion: produceVersion, maxVersion: produceVersion},
+			{apiKey: apiKeyFetch, minVersion: fetchVersion, maxVersion: fetchVersion},
+			{apiKey: apiKeyMetadata, minVersion: metadataVersion, maxVersion: metadataVersion},
+			{apiKey: apiKeyAPIVersions, minVersion: apiVersionsVersion, maxVersion: apiVersionsVersion},
+		}}
+		if header.apiVersion != apiVersionsVersion {
+			resp.err = ErrUnsupportedVersion
+		}
+		resp.encode(e)
+		return true
+
+	case header.apiKey == apiKeyMetadata && header.apiVersion == metadataVersion:
+		var req metadataRequest
+		req.decode(d)
+		if d.err != nil {
+			return false
+		}
+		b.metadata(&req).encode(e)
+		return true
+
+	case header.apiKey == apiKeyProduce && header.apiVersion == produceVersion:
+		var req produceRequest
+		req.decode(d)
+		if d.err != nil {
+			return false
+		}
+		b.produce(&req).encode(e)
+		return true
+
+	case header.apiKey == apiKeyFetch && header.apiVersion == fetchVersion:
+		var req fetchRequest
+		req.decode(d)
+		if d.err != nil {
+			return false
+		}
+		b.fetch(&req).encode(e)
+		return true
+
+	default:
+		return false
+	}
+}
+
+func (b *FakeBroker) metadata(req *metadataRequest) *metadataResponse {
+	host, portStr, _ := net.SplitHostPort(b.Addr())
+	port, _ := strconv.Atoi(portStr)
+	clusterID := "fake-cluster"
+	resp := &metadataResponse{
+		brokers:      []brokerMetadata{{nodeID: 0, host: host, port: int32(port)}},
+		clusterID:    &clusterID,
+		controllerID: 0,
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	names := req.topics
+	if names == nil {
+		for name := range b.topics {
+			names = append(names, name)
+		}
+	}
+	for _, name := range names {
+		partitions, ok := b.topics[name]
+		if !ok && req.allowAutoTopicCreation {
+			partitions = b.createTopicLocked(name)
+			ok = true
+		}
+		if !ok {
+			resp.topics = append(resp.topics, topicMetadata{err: ErrUnknownTopicOrPartition, name: name})
+			continue
+		}
+		t := topicMetadata{name: name}
+		for i := range partitions {
+			t.partitions = append(t.partitions, partitionMetadata{
+				index: int32(i), leader: 0, replicas: []int32{0}, isr: []int32{0},
+			})
+		}
+		resp.topics = append(resp.topics, t)
+	}
+	return resp
+}
+
+func (b *FakeBroker) produce(req *produceRequest) *produceResponse {
+	resp := &produceResponse{}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	grew := false
+	for _, t := range req.topics {
+		tr := produceTopicResponse{name: t.name}
+		partitions := b.topics[t.name]
+		for _, p := range t.partitions {
+			pr := producePartitionResponse{index: p.index, logAppendTime: -1}
+			if p.index < 0 || int(p.index) >= len(partitions) {
+				pr.err = ErrUnknownTopicOrPartition
+				tr.partitions = append(tr.partitions, pr)
+				continue
+			}
+			part := partitions[p.index]
+			pr.baseOffset = part.nextOffset
+			if err := part.append(p.records); err != nil {
+				pr.err = ErrCorruptMessage
+			} else {
+				grew = true
+			}
+			tr.partitions = append(tr.partitions, pr)
+		}
+		resp.topics = append(resp.topics, tr)
+	}
+
+	if grew && !b.closed {
+		close(b.changed)
+		b.changed = make(chan struct{})
+	}
+	return resp
+}
+
+func (b *FakeBroker) fetch(req *fetchRequest) *fetchResponse {
+	deadline := time.Now().Add(time.Duration(req.maxWaitMs) * time.Millisecond)
+	for {
+		b.mu.Lock()
+		resp, hasData := b.readLocked(req)
+		changed := b.changed
+		closed := b.closed
+		b.mu.Unlock()
+
+		wait := time.Until(deadline)
+		if hasData || closed || wait <= 0 {
+			return resp
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-changed:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+func (b *FakeBroker) readLocked(req *fetchRequest) (*fetchResponse, bool) {
+	resp := &fetchResponse{}
+	hasData := false
+	for _, t := range req.topics {
+		tr := fetchTopicResponse{name: t.name}
+		partitions := b.topics[t.name]
+		for _, p := range t.partitions {
+			pr := fetchPartitionResponse{index: p.index}
+			if p.index < 0 || int(p.index) >= len(partitions) {
+				pr.err = ErrUnknownTopicOrPartition
+				tr.partitions = append(tr.partitions, pr)
+				continue
+			}
+			part := partitions[p.index]
+			pr.highWatermark = part.nextOffset
+			pr.lastStableOffset = part.nextOffset
+			if p.fetchOffset > part.nextOffset || p.fetchOffset < 0 {
+				pr.err = ErrOffsetOutOfRange
+				tr.partitions = append(tr.partitions, pr)
+				continue
+			}
+			pr.records = part.read(p.fetchOffset, int(p.maxBytes))
+			if len(pr.records) > 0 {
+				hasData = true
+			}
+			tr.partitions = append(tr.partitions, pr)
+		}
+		resp.topics = append(resp.topics, tr)
+	}
+	return resp, hasData
+}
+
+func (b *FakeBroker) createTopicLocked(name string) []*fakePartition {
+	partitions := make([]*fakePartition, b.partitions)
+	for i := range partitions {
+		partitions[i] = &fakePartition{}
+	}
+	b.topics[name] = partitions
+	return partitions
+}
+
+// append validates a record set and stores its batches with broker-assigned offsets
+func (p *fakePartition) append(records []byte) error {
+	var pending []fakeBatch
+	next := p.nextOffset
+	for len(records) > 0 {
+		info, ok, err := nextBatch(records)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.New("truncated record batch")
+		}
+		data := make([]byte, info.size)
+		copy(data, records[:info.size])
+		setBaseOffset(data, next)
+		batch := fakeBatch{baseOffset: next, nextOffset: next + int64(info.lastOffsetDelta) + 1, data: data}
+		pending = append(pending, batch)
+		next = batch.nextOffset
+		records = records[info.size:]
+	}
+	p.batches = append(p.batches, pending...)
+	p.nextOffset = next
+	return nil
+}
+
+// read returns whole batches starting with the one holding offset, up to maxBytes
+// (always at least one batch, like a real broker)
+func (p *fakePartition) read(offset int64, maxBytes int) []byte {
+	var out []byte
+	for _, batch := range p.batches {
+		if batch.nextOffset <= offset {
+			continue
+		}
+		if len(out) > 0 && len(out)+len(batch.data) > maxBytes {
+			break
+		}
+		out = append(out, batch.data...)
+	}
+	return out
+}
@@ -0,0 +1,130 @@
+package kafka
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+// TestMurmur2MatchesJavaClient verifies the partitioner hash against the Java client's test vectors.
+// Expected result: identical hashes, so keys land on the same partitions as with other clients.
+func TestMurmur2MatchesJavaClient(t *testing.T) {
+	cases := map[string]int32{
+		"21":                         -973932308,
+		"foobar":                     -790332482,
+		"a-little-bit-long-string":   -985981536,
+		"a-little-bit-longer-string": -1486304829,
+		"lkjh234lh9fiuh90y23oiuhsafujhadof229phr9h19h89h8": -58897971,
+		"abc": 479470107,
+	}
+
+	for key, want := range cases {
+		if got := murmur2([]byte(key)); got != want {
+			t.Errorf("murmur2(%q) = %d, want %d", key, got, want)
+		}
+	}
+}
+
+// TestRecordBatchRoundTrip verifies that an encoded v2 record batch decodes to the same records.
+// Expected result: keys, values, headers, timestamps and offsets survive the round trip.
+func TestRecordBatchRoundTrip(t *testing.T) {
+	// Arrange
+	records := []Record{
+		{Timestamp: 1000, Key: []byte("rocket-1"), Value: []byte("launched")},
+		{Timestamp: 1005, Key: []byte("rocket-1"), Value: []byte("faster"), Headers: []Header{{Key: "h", Value: []byte("v")}}},
+	}
+
+	// Act
+	batch := encodeRecordBatch(42, records)
+	decoded, err := decodeRecordBatches(batch)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error decoding, got %v", err)
+	}
+	if len(decoded) != 2 {
+		t.Fatalf("Expected 2 records, got %d", len(decoded))
+	}
+	if decoded[1].Offset != 43 || decoded[1].Timestamp != 1005 {
+		t.Errorf("Expected offset 43 at 1005, got %d at %d", decoded[1].Offset, decoded[1].Timestamp)
+	}
+	if !bytes.Equal(decoded[1].Value, []byte("faster")) || string(decoded[1].Headers[0].Value) != "v" {
+		t.Errorf("Unexpected record contents: %+v", decoded[1])
+	}
+}
+
+// TestRecordBatchDetectsCorruption verifies that a damaged batch fails its CRC check.
+// Expected result: decoding returns ErrCorruptMessage.
+func TestRecordBatchDetectsCorruption(t *testing.T) {
+	// Arrange
+	batch := encodeRecordBatch(0, []Record{{Key: []byte("k"), Value: []byte("value")}})
+	batch[len(batch)-3] ^= 0xFF
+
+	// Act
+	_, err := decodeRecordBatches(batch)
+
+	// Assert
+	if err != ErrCorruptMessage {
+		t.Errorf("Expected ErrCorruptMessage, got %v", err)
+	}
+}
+
+// TestClientProduceFetchAgainstFakeBroker verifies produce and fetch over the wire protocol.
+// Expected result: offsets are assigned per partition and a long-poll fetch wakes up on new data.
+func TestClientProduceFetchAgainstFakeBroker(t *testing.T) {
+	// Arrange
+	broker, err := NewFakeBroker(2)
+	if err != nil {
+		t.Fatalf("Failed to start broker: %v", err)
+	}
+	defer broker.Close()
+	producer := NewClient([]string{broker.Addr()}, "test-producer")
+	defer producer.Close()
+	consumer := NewClient([]string{broker.Addr()}, "test-consumer")
+	defer consumer.Close()
+
+	partitions, err := producer.Partitions("events")
+	if err != nil || partitions != 2 {
+		t.Fatalf("Expected 2 partitions, got %d (err %v)", partitions, err)
+	}
+
+	// Act
+	first, err := producer.Produce("events", 1, []Record{{Key: []byte("a"), Value: []byte("1")}, {Key: []byte("a"), Value: []byte("2")}})
+	if err != nil {
+		t.Fatalf("Expected no error producing, got %v", err)
+	}
+	second, _ := producer.Produce("events", 1, []Record{{Key: []byte("a"), Value: []byte("3")}})
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_, _ = producer.Produce("events", 0, []Record{{Key: []byte("b"), Value: []byte("late")}})
+	}()
+	waited, err := consumer.Fetch("events", map[int32]int64{0: 0}, 2*time.Second)
+
+	// Assert
+	if first != 0 || second != 2 {
+		t.Errorf("Expected base offsets 0 and 2, got %d and %d", first, second)
+	}
+	if err != nil || len(waited[0].Records) != 1 || string(waited[0].Records[0].Value) != "late" {
+		t.Errorf("Expected long-poll fetch to return the late record, got %+v (err %v)", waited[0], err)
+	}
+
+	fetched, err := consumer.Fetch("events", map[int32]int64{1: 1}, 0)
+	if err != nil {
+		t.Fatalf("Expected no error fetching, got %v", err)
+	}
+	res := fetched[1]
+	if res.HighWatermark != 3 {
+		t.Errorf("Expected high watermark 3, got %d", res.HighWatermark)
+	}
+	// The first batch is returned whole; records before the fetch offset are the caller's to skip
+	var values []string
+	for _, r := range res.Records {
+		if r.Offset >= 1 {
+			values = append(values, string(r.Value))
+		}
+	}
+	if len(values) != 2 || values[0] != "2" || values[1] != "3" {
+		t.Errorf("Expected values [2 3], got %v", values)
+	}
+}
@@ -0,0 +1,369 @@
+package kafka
+
+// metadataRequest is Metadata v4
+type metadataRequest struct {
+	topics                 []string // nil means every topic
+	allowAutoTopicCreation bool
+}
+
+func (r *metadataRequest) encode(e *encoder) {
+	if r.topics == nil {
+		e.int32(-1)
+	} else {
+		e.arrayLen(len(r.topics))
+		for _, t := range r.topics {
+			e.string(t)
+		}
+	}
+	e.bool(r.allowAutoTopicCreation)
+}
+
+func (r *metadataRequest) decode(d *decoder) {
+	n := d.arrayLen()
+	if n >= 0 {
+		r.topics = make([]string, 0, n)
+		for i := 0; i < n; i++ {
+			r.topics = append(r.topics, d.string())
+		}
+	}
+	r.allowAutoTopicCreation = d.bool()
+}
+
+type brokerMetadata struct {
+	nodeID int32
+	host   string
+	port   int32
+}
+
+type partitionMetadata struct {
+	err      KError
+	index    int32
+	leader   int32
+	replicas []int32
+	isr      []int32
+}
+
+type topicMetadata struct {
+	err        KError
+	name       string
+	internal   bool
+	partitions []partitionMetadata
+}
+
+// metadataResponse is Metadata v4
+type metadataResponse struct {
+	brokers      []brokerMetadata
+	clusterID    *string
+	controllerID int32
+	topics       []topicMetadata
+}
+
+func (r *metadataResponse) encode(e *encoder) {
+	e.int32(0) // throttle time
+	e.arrayLen(len(r.brokers))
+	for _, b := range r.brokers {
+		e.int32(b.nodeID)
+		e.string(b.host)
+		e.int32(b.port)
+		e.nullableString(nil) // rack
+	}
+	e.nullableString(r.clusterID)
+	e.int32(r.controllerID)
+	e.arrayLen(len(r.topics))
+	for _, t := range r.topics {
+		e.int16(int16(t.err))
+		e.string(t.name)
+		e.bool(t.internal)
+		e.arrayLen(len(t.partitions))
+		for _, p := range t.partitions {
+			e.int16(int16(p.err))
+			e.int32(p.index)
+			e.int32(p.leader)
+			encodeInt32s(e, p.replicas)
+			encodeInt32s(e, p.isr)
+		}
+	}
+}
+
+func (r *metadataResponse) decode(d *decoder) {
+	d.int32() // throttle time
+	n := d.arrayLen()
+	for i := 0; i < n && d.err == nil; i++ {
+		b := brokerMetadata{nodeID: d.int32(), host: d.string(), port: d.int32()}
+		d.nullableString() // rack
+		r.brokers = append(r.brokers, b)
+	}
+	r.clusterID = d.nullableString()
+	r.controllerID = d.int32()
+	n = d.arrayLen()
+	for i := 0; i < n && d.err == nil; i++ {
+		t := topicMetadata{err: KError(d.int16()), name: d.string(), internal: d.bool()}
+		pn := d.arrayLen()
+		for j := 0; j < pn && d.err == nil; j++ {
+			t.partitions = append(t.partitions, partitionMetadata{
+				err:      KError(d.int16()),
+				index:    d.int32(),
+				leader:   d.int32(),
+				replicas: decodeInt32s(d),
+				isr:      decodeInt32s(d),
+			})
+		}
+		r.topics = append(r.topics, t)
+	}
+}
+
+type producePartition struct {
+	index   int32
+	records []byte
+}
+
+type produceTopic struct {
+	name       string
+	partitions []producePartition
+}
+
+// produceRequest is Produce v3
+type produceRequest struct {
+	transactionalID *string
+	acks            int16
+	timeoutMs       int32
+	topics          []produceTopic
+}
+
+func (r *produceRequest) encode(e *encoder) {
+	e.nullableString(r.transactionalID)
+	e.int16(r.acks)
+	e.int32(r.timeoutMs)
+	e.arrayLen(len(r.topics))
+	for _, t := range r.topics {
+		e.string(t.name)
+		e.arrayLen(len(t.partitions))
+		for _, p := range t.partitions {
+			e.int32(p.index)
+			e.bytes(p.records)
+		}
+	}
+}
+
+func (r *produceRequest) decode(d *decoder) {
+	r.transactionalID = d.nullableString()
+	r.acks = d.int16()
+	r.timeoutMs = d.int32()
+	n := d.arrayLen()
+	for i := 0; i < n && d.err == nil; i++ {
+		t := produceTopic{name: d.string()}
+		pn := d.arrayLen()
+		for j := 0; j < pn && d.err == nil; j++ {
+			t.partitions = append(t.partitions, producePartition{index: d.int32(), records: d.bytes()})
+		}
+		r.topics = append(r.topics, t)
+	}
+}
+
+type producePartitionResponse struct {
+	index         int32
+	err           KError
+	baseOffset    int64
+	logAppendTime int64
+}
+
+type produceTopicResponse struct {
+	name       string
+	partitions []producePartitionResponse
+}
+
+// produceResponse is Produce v3
+type produceResponse struct {
+	topics []produceTopicResponse
+}
+
+func (r *produceResponse) encode(e *encoder) {
+	e.arrayLen(len(r.topics))
+	for _, t := range r.topics {
+		e.string(t.name)
+		e.arrayLen(len(t.partitions))
+		for _, p := range t.partitions {
+			e.int32(p.index)
+			e.int16(int16(p.err))
+			e.int64(p.baseOffset)
+			e.int64(p.logAppendTime)
+		}
+	}
+	e.int32(0) // throttle time
+}
+
+func (r *produceResponse) decode(d *decoder) {
+	n := d.arrayLen()
+	for i := 0; i < n && d.err == nil; i++ {
+		t := produceTopicResponse{name: d.string()}
+		pn := d.arrayLen()
+		for j := 0; j < pn && d.err == nil; j++ {
+			t.partitions = append(t.partitions, producePartitionResponse{
+				index:         d.int32(),
+				err:           KError(d.int16()),
+				baseOffset:    d.int64(),
+				logAppendTime: d.int64(),
+			})
+		}
+		r.topics = append(r.topics, t)
+	}
+	d.int32() // throttle time
+}
+
+type fetchPartition struct {
+	index       int32
+	fetchOffset int64
+	maxBytes    int32
+}
+
+type fetchTopic struct {
+	name       string
+	partitions []fetchPartition
+}
+
+// fetchRequest is Fetch v4
+type fetchRequest struct {
+	replicaID      int32
+	maxWaitMs      int32
+	minBytes       int32
+	maxBytes       int32
+	isolationLevel int8
+	topics         []fetchTopic
+}
+
+func (r *fetchRequest) encode(e *encoder) {
+	e.int32(r.replicaID)
+	e.int32(r.maxWaitMs)
+	e.int32(r.minBytes)
+	e.int32(r.maxBytes)
+	e.int8(r.isolationLevel)
+	e.arrayLen(len(r.topics))
+	for _, t := range r.topics {
+		e.string(t.name)
+		e.arrayLen(len(t.partitions))
+		for _, p := range t.partitions {
+			e.int32(p.index)
+			e.int64(p.fetchOffset)
+			e.int32(p.maxBytes)
+		}
+	}
+}
+
+func (r *fetchRequest) decode(d *decoder) {
+	r.replicaID = d.int32()
+	r.maxWaitMs = d.int32()
+	r.minBytes = d.int32()
+	r.maxBytes = d.int32()
+	r.isolationLevel = d.int8()
+	n := d.arrayLen()
+	for i := 0; i < n && d.err == nil; i++ {
+		t := fetchTopic{name: d.string()}
+		pn := d.arrayLen()
+		for j := 0; j < pn && d.err == nil; j++ {
+			t.partitions = append(t.partitions, fetchPartition{
+				index:       d.int32(),
+				fetchOffset: d.int64(),
+				maxBytes:    d.int32(),
+			})
+		}
+		r.topics = append(r.topics, t)
+	}
+}
+
+type fetchPartitionResponse struct {
+	index            int32
+	err              KError
+	highWatermark    int64
+	lastStableOffset int64
+	records          []byte
+}
+
+type fetchTopicResponse struct {
+	name       string
+	partitions []fetchPartitionResponse
+}
+
+// fetchResponse is Fetch v4
+type fetchResponse struct {
+	topics []fetchTopicResponse
+}
+
+func (r *fetchResponse) encode(e *encoder) {
+	e.int32(0) // throttle time
+	e.arrayLen(len(r.topics))
+	for _, t := range r.topics {
+		e.string(t.name)
+		e.arrayLen(len(t.partitions))
+		for _, p := range t.partitions {
+			e.int32(p.index)
+			e.int16(int16(p.err))
+			e.int64(p.highWatermark)
+			e.int64(p.lastStableOffset)
+			e.int32(-1) // aborted transactions
+			e.bytes(p.records)
+		}
+	}
+}
+
+func (r *fetchResponse) decode(d *decoder) {
+	d.int32() // throttle time
+	n := d.arrayLen()
+	for i := 0; i < n && d.err == nil; i++ {
+		t := fetchTopicResponse{name: d.string()}
+		pn := d.arrayLen()
+		for j := 0; j < pn && d.err == nil; j++ {
+			p := fetchPartitionResponse{
+				index:            d.int32(),
+				err:              KError(d.int16()),
+				highWatermark:    d.int64(),
+				lastStableOffset: d.int64(),
+			}
+			aborted := d.arrayLen()
+			for k := 0; k < aborted && d.err == nil; k++ {
+				d.int64() // producer id
+				d.int64() // first offset
+			}
+			p.records = d.bytes()
+			t.partitions = append(t.partitions, p)
+		}
+		r.topics = append(r.topics, t)
+	}
+}
+
+type apiVersionRange struct {
+	apiKey     int16
+	minVersion int16
+	maxVersion int16
+}
+
+// apiVersionsResponse is ApiVersions v0
+type apiVersionsResponse struct {
+	err  KError
+	apis []apiVersionRange
+}
+
+func (r *apiVersionsResponse) encode(e *encoder) {
+	e.int16(int16(r.err))
+	e.arrayLen(len(r.apis))
+	for _, a := range r.apis {
+		e.int16(a.apiKey)
+		e.int16(a.minVersion)
+		e.int16(a.maxVersion)
+	}
+}
+
+func encodeInt32s(e *encoder, values []int32) {
+	e.arrayLen(len(values))
+	for _, v := range values {
+		e.int32(v)
+	}
+}
+
+func decodeInt32s(d *decoder) []int32 {
+	n := d.arrayLen()
+	values := make([]int32, 0, max(n, 0))
+	for i := 0; i < n && d.err == nil; i++ {
+		values = append(values, d.int32())
+	}
+	return values
+}
@@ -0,0 +1,297 @@
+// Package kafka implements the subset of the Kafka wire protocol used by the event store:
+// Metadata (v4), Produce (v3) and Fetch (v4) with v2 record batches, plus an in-process
+// broker that speaks the same protocol so tests can run without a real cluster.
+package kafka
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// API keys and the versions spoken by this package
+const (
+	apiKeyProduce     int16 = 0
+	apiKeyFetch       int16 = 1
+	apiKeyMetadata    int16 = 3
+	apiKeyAPIVersions int16 = 18
+
+	produceVersion     int16 = 3
+	fetchVersion       int16 = 4
+	metadataVersion    int16 = 4
+	apiVersionsVersion int16 = 0
+)
+
+const maxFrameSize = 100 << 20
+
+// KError is a Kafka protocol error code
+type KError int16
+
+const (
+	ErrNone                    KError = 0
+	ErrOffsetOutOfRange        KError = 1
+	ErrCorruptMessage          KError = 2
+	ErrUnknownTopicOrPartition KError = 3
+	ErrLeaderNotAvailable      KError = 5
+	ErrNotLeaderForPartition   KError = 6
+	ErrRequestTimedOut         KError = 7
+	ErrUnsupportedVersion      KError = 35
+	ErrInvalidRequest          KError = 42
+)
+
+func (e KError) Error() string {
+	switch e {
+	case ErrNone:
+		return "kafka: no error"
+	case ErrOffsetOutOfRange:
+		return "kafka: offset out of range"
+	case ErrCorruptMessage:
+		return "kafka: corrupt message"
+	case ErrUnknownTopicOrPartition:
+		return "kafka: unknown topic or partition"
+	case ErrLeaderNotAvailable:
+		return "kafka: leader not available"
+	case ErrNotLeaderForPartition:
+		return "kafka: not leader for partition"
+	case ErrRequestTimedOut:
+		return "kafka: request timed out"
+	case ErrUnsupportedVersion:
+		return "kafka: unsupported version"
+	case ErrInvalidRequest:
+		return "kafka: invalid request"
+	default:
+		return fmt.Sprintf("kafka: error code %d", int16(e))
+	}
+}
+
+// Retriable reports whether the request may succeed after refreshing metadata
+func (e KError) Retriable() bool {
+	switch e {
+	case ErrUnknownTopicOrPartition, ErrLeaderNotAvailable, ErrNotLeaderForPartition, ErrRequestTimedOut:
+		return true
+	default:
+		return false
+	}
+}
+
+// errMalformed is returned when a message cannot be decoded
+var errMalformed = errors.New("kafka: malformed message")
+
+// encoder builds a protocol message in big-endian order
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) int8(v int8)   { e.buf = append(e.buf, byte(v)) }
+func (e *encoder) int16(v int16) { e.buf = binary.BigEndian.AppendUint16(e.buf, uint16(v)) }
+func (e *encoder) int32(v int32) { e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(v)) }
+func (e *encoder) int64(v int64) { e.buf = binary.BigEndian.AppendUint64(e.buf, uint64(v)) }
+func (e *encoder) raw(v []byte)  { e.buf = append(e.buf, v...) }
+
+func (e *encoder) bool(v bool) {
+	if v {
+		e.int8(1)
+		return
+	}
+	e.int8(0)
+}
+
+func (e *encoder) string(v string) {
+	e.int16(int16(len(v)))
+	e.buf = append(e.buf, v...)
+}
+
+func (e *encoder) nullableString(v *string) {
+	if v == nil {
+		e.int16(-1)
+		return
+	}
+	e.string(*v)
+}
+
+func (e *encoder) bytes(v []byte) {
+	if v == nil {
+		e.int32(-1)
+		return
+	}
+	e.int32(int32(len(v)))
+	e.buf = append(e.buf, v...)
+}
+
+func (e *encoder) arrayLen(n int) { e.int32(int32(n)) }
+
+func (e *encoder) varint(v int64) { e.buf = binary.AppendVarint(e.buf, v) }
+
+func (e *encoder) varintBytes(v []byte) {
+	if v == nil {
+		e.varint(-1)
+		return
+	}
+	e.varint(int64(len(v)))
+	e.buf = append(e.buf, v...)
+}
+
+// decoder reads a protocol message; the first error sticks and later reads return zero values
+type decoder struct {
+	buf []byte
+	off int
+	err error
+}
+
+func (d *decoder) need(n int) bool {
+	if d.err != nil {
+		return false
+	}
+	if n < 0 || len(d.buf)-d.off < n {
+		d.err = errMalformed
+		return false
+	}
+	return true
+}
+
+func (d *decoder) int8() int8 {
+	if !d.need(1) {
+		return 0
+	}
+	v := int8(d.buf[d.off])
+	d.off++
+	return v
+}
+
+func (d *decoder) int16() int16 {
+	if !d.need(2) {
+		return 0
+	}
+	v := int16(binary.BigEndian.Uint16(d.buf[d.off:]))
+	d.off += 2
+	return v
+}
+
+func (d *decoder) int32() int32 {
+	if !d.need(4) {
+		return 0
+	}
+	v := int32(binary.BigEndian.Uint32(d.buf[d.off:]))
+	d.off += 4
+	return v
+}
+
+func (d *decoder) int64() int64 {
+	if !d.need(8) {
+		return 0
+	}
+	v := int64(binary.BigEndian.Uint64(d.buf[d.off:]))
+	d.off += 8
+	return v
+}
+
+func (d *decoder) bool() bool { return d.int8() != 0 }
+
+func (d *decoder) string() string {
+	n := int(d.int16())
+	if !d.need(n) {
+		return ""
+	}
+	v := string(d.buf[d.off : d.off+n])
+	d.off += n
+	return v
+}
+
+func (d *decoder) nullableString() *string {
+	n := int(d.int16())
+	if n == -1 || d.err != nil {
+		return nil
+	}
+	if !d.need(n) {
+		return nil
+	}
+	v := string(d.buf[d.off : d.off+n])
+	d.off += n
+	return &v
+}
+
+func (d *decoder) bytes() []byte {
+	n := int(d.int32())
+	if n == -1 || d.err != nil {
+		return nil
+	}
+	if !d.need(n) {
+		return nil
+	}
+	v := d.buf[d.off : d.off+n]
+	d.off += n
+	return v
+}
+
+// arrayLen reads an array length; null arrays are returned as -1
+func (d *decoder) arrayLen() int {
+	n := int(d.int32())
+	if d.err == nil && n > len(d.buf)-d.off {
+		d.err = errMalformed
+		return 0
+	}
+	return n
+}
+
+func (d *decoder) varint() int64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(d.buf[d.off:])
+	if n <= 0 {
+		d.err = errMalformed
+		return 0
+	}
+	d.off += n
+	return v
+}
+
+func (d *decoder) varintBytes() []byte {
+	n := int(d.varint())
+	if n == -1 || d.err != nil {
+		return nil
+	}
+	if !d.need(n) {
+		return nil
+	}
+	v := d.buf[d.off : d.off+n]
+	d.off += n
+	return v
+}
+
+func (d *decoder) remaining() int { return len(d.buf) - d.off }
+
+// requestHeader is the v1 request header
+type requestHeader struct {
+	apiKey        int16
+	apiVersion    int16
+	correlationID int32
+	clientID      *string
+}
+
+// writeFrame writes a size-prefixed message
+func writeFrame(w io.Writer, payload []byte) error {
+	frame := make([]byte, 4+len(payload))
+	binary.BigEndian.PutUint32(frame, uint32(len(payload)))
+	copy(frame[4:], payload)
+	_, err := w.Write(frame)
+	return err
+}
+
+// readFrame reads a size-prefixed message
+func readFrame(r io.Reader) ([]byte, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n > maxFrameSize {
+		return nil, fmt.Errorf("kafka: frame of %d bytes exceeds limit", n)
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
@@ -0,0 +1,236 @@
+package kafka
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+)
+
+// Record batch v2 layout (all offsets in bytes)
+const (
+	batchMagic           = 2
+	batchLengthOffset    = 8
+	batchMagicOffset     = 16
+	batchCRCOffset       = 17
+	batchAttrOffset      = 21
+	batchLastDeltaOffset = 23
+	batchHeaderSize      = 61
+
+	attrCompressionMask = 0x07
+	attrControlBatch    = 0x20
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// Header is a record header
+type Header struct {
+	Key   string
+	Value []byte
+}
+
+// Record is a single Kafka record
+type Record struct {
+	Offset    int64 // assigned by the broker, filled in when decoding
+	Timestamp int64 // milliseconds since epoch
+	Key       []byte
+	Value     []byte
+	Headers   []Header
+}
+
+// encodeRecordBatch builds a v2 record batch (uncompressed, non-transactional)
+func encodeRecordBatch(baseOffset int64, records []Record) []byte {
+	var firstTs, maxTs int64
+	if len(records) > 0 {
+		firstTs = records[0].Timestamp
+		maxTs = firstTs
+	}
+	for _, r := range records {
+		if r.Timestamp > maxTs {
+			maxTs = r.Timestamp
+		}
+	}
+
+	body := &encoder{}
+	for i, r := range records {
+		rec := &encoder{}
+		rec.int8(0) // attributes
+		rec.varint(r.Timestamp - firstTs)
+		rec.varint(int64(i))
+		rec.varintBytes(r.Key)
+		rec.varintBytes(r.Value)
+		rec.varint(int64(len(r.Headers)))
+		for _, h := range r.Headers {
+			rec.varintBytes([]byte(h.Key))
+			rec.varintBytes(h.Value)
+		}
+		body.varint(int64(len(rec.buf)))
+		body.raw(rec.buf)
+	}
+
+	e := &encoder{}
+	e.int64(baseOffset)
+	e.int32(0)  // batch length, patched below
+	e.int32(-1) // partition leader epoch
+	e.int8(batchMagic)
+	e.int32(0) // crc, patched below
+	e.int16(0) // attributes
+	e.int32(int32(len(records) - 1))
+	e.int64(firstTs)
+	e.int64(maxTs)
+	e.int64(-1) // producer id
+	e.int16(-1) // producer epoch
+	e.int32(-1) // base sequence
+	e.int32(int32(len(records)))
+	e.raw(body.buf)
+
+	buf := e.buf
+	binary.BigEndian.PutUint32(buf[batchLengthOffset:], uint32(len(buf)-batchLengthOffset-4))
+	binary.BigEndian.PutUint32(buf[batchCRCOffset:], crc32.Checksum(buf[batchAttrOffset:], castagnoli))
+	return buf
+}
+
+// batchInfo describes a batch found in a record set
+type batchInfo struct {
+	baseOffset      int64
+	lastOffsetDelta int32
+	size            int
+}
+
+// nextBatch inspects the batch at the start of data without decoding its records.
+// ok is false when data holds only part of a batch, which brokers may return at the end of a fetch.
+func nextBatch(data []byte) (info batchInfo, ok bool, err error) {
+	if len(data) < batchHeaderSize {
+		return info, false, nil
+	}
+	length := int(int32(binary.BigEndian.Uint32(data[batchLengthOffset:])))
+	size := batchLengthOffset + 4 + length
+	if length < batchHeaderSize-batchLengthOffset-4 {
+		return info, false, fmt.Errorf("%w: batch length %d", errMalformed, length)
+	}
+	if len(data) < size {
+		return info, false, nil
+	}
+	if data[batchMagicOffset] != batchMagic {
+		return info, false, fmt.Errorf("kafka: unsupported record batch magic %d", data[batchMagicOffset])
+	}
+	if crc32.Checksum(data[batchAttrOffset:size], castagnoli) != binary.BigEndian.Uint32(data[batchCRCOffset:]) {
+		return info, false, ErrCorruptMessage
+	}
+	return batchInfo{
+		baseOffset:      int64(binary.BigEndian.Uint64(data)),
+		lastOffsetDelta: int32(binary.BigEndian.Uint32(data[batchLastDeltaOffset:])),
+		size:            size,
+	}, true, nil
+}
+
+// decodeRecordBatches decodes every complete batch in a record set, skipping control batches
+func decodeRecordBatches(data []byte) ([]Record, error) {
+	var records []Record
+	for len(data) > 0 {
+		info, ok, err := nextBatch(data)
+		if err != nil {
+			return records, err
+		}
+		if !ok {
+			break // trailing partial batch
+		}
+		batch := data[:info.size]
+		data = data[info.size:]
+
+		attributes := int16(binary.BigEndian.Uint16(batch[batchAttrOffset:]))
+		if attributes&attrCompressionMask != 0 {
+			return records, fmt.Errorf("kafka: compressed record batches are not supported")
+		}
+		if attributes&attrControlBatch != 0 {
+			continue
+		}
+
+		d := &decoder{buf: batch, off: 27}
+		firstTs := d.int64()
+		d.off = 57
+		count := int(d.int32())
+		for i := 0; i < count; i++ {
+			length := int(d.varint())
+			if !d.need(length) {
+				break
+			}
+			rec := &decoder{buf: d.buf[d.off : d.off+length]}
+			d.off += length
+
+			rec.int8() // attributes
+			tsDelta := rec.varint()
+			offsetDelta := rec.varint()
+			key := rec.varintBytes()
+			value := rec.varintBytes()
+			headerCount := int(rec.varint())
+			var headers []Header
+			for h := 0; h < headerCount && rec.err == nil; h++ {
+				hk := rec.varintBytes()
+				hv := rec.varintBytes()
+				headers = append(headers, Header{Key: string(hk), Value: hv})
+			}
+			if rec.err != nil {
+				return records, rec.err
+			}
+			records = append(records, Record{
+				Offset:    info.baseOffset + offsetDelta,
+				Timestamp: firstTs + tsDelta,
+				Key:       key,
+				Value:     value,
+				Headers:   headers,
+			})
+		}
+		if d.err != nil {
+			return records, d.err
+		}
+	}
+	return records, nil
+}
+
+// setBaseOffset rewrites the base offset of a batch (not covered by the CRC)
+func setBaseOffset(batch []byte, baseOffset int64) {
+	binary.BigEndian.PutUint64(batch, uint64(baseOffset))
+}
+
+// murmur2 is the hash used by the Java client's default partitioner
+func murmur2(data []byte) int32 {
+	const (
+		seed uint32 = 0x9747b28c
+		m    uint32 = 0x5bd1e995
+		r           = 24
+	)
+	length := len(data)
+	h := seed ^ uint32(length)
+	for i := 0; i+4 <= length; i += 4 {
+		k := binary.LittleEndian.Uint32(data[i:])
+		k *= m
+		k ^= k >> r
+		k *= m
+		h *= m
+		h ^= k
+	}
+	tail := length &^ 3
+	switch length % 4 {
+	case 3:
+		h ^= uint32(data[tail+2]) << 16
+		fallthrough
+	case 2:
+		h ^= uint32(data[tail+1]) << 8
+		fallthrough
+	case 1:
+		h ^= uint32(data[tail])
+		h *= m
+	}
+	h ^= h >> 13
+	h *= m
+	h ^= h >> 15
+	return int32(h)
+}
+
+// PartitionForKey returns the partition the Java default partitioner picks for a key
+func PartitionForKey(key []byte, partitions int32) int32 {
+	if partitions <= 0 {
+		return 0
+	}
+	return (murmur2(key) & 0x7fffffff) % partitions
+}
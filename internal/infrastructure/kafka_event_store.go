@@ -1,60 +1,503 @@
 package infrastructure
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"rockets/internal/domain"
+	"rockets/internal/infrastructure/kafka"
 )
 
+const (
+	defaultKafkaTopic    = "rocket-events"
+	defaultKafkaClientID = "rockets-server"
+	kafkaPollWait        = 500 * time.Millisecond
+	kafkaRetryBackoff    = time.Second
+	defaultAppendTimeout = 10 * time.Second
+	kafkaPinnedRetries   = 20 // attempts of an append without expected version
+)
+
+// KafkaConfig configures a KafkaEventStore
+type KafkaConfig struct {
+	Brokers  string // comma-separated "host:port" list; empty keeps events in memory only
+	Topic    string // topic holding every rocket event (default: rocket-events)
+	ClientID string
+	Codec    *EventCodec // event serialization (default: DefaultEventCodec)
+	// AppendTimeout bounds how long an append waits for the consumer to apply it (default: 10s)
+	AppendTimeout time.Duration
+}
+
+// kafkaProducerHeader identifies the store instance that produced a record
+const kafkaProducerHeader = "rockets-producer"
+
 // KafkaEventStore implements the event store using Kafka.
-// Note: for now real Kafka is disabled; we only simulate by storing in memory.
+// Events are produced to a topic keyed (and therefore partitioned) by channel. The read cache is
+// a projection of the topic: it is rebuilt by consuming every partition on startup and kept up
+// to date by a background consumer, so writes from other instances become visible too.
+//
+// Each append is a single Kafka record holding the whole batch and the version it expects.
+// Every consumer applies the records of a partition in the same order and drops a batch whose
+// expected version no longer matches, so all instances agree on which writer won a conflict.
 type KafkaEventStore struct {
-	brokers string //not used, it's only simulated
-	mu      sync.RWMutex
-	events  map[string][]domain.DomainEvent // cache ordered by insertion
+	cfg        KafkaConfig
+	index      *eventIndex // cache ordered by insertion
+	producer   *kafka.Client
+	consumer   *kafka.Client
+	instanceID string
+
+	partitions int32
+	mu         sync.Mutex
+	consumed   map[int32]int64           // next offset to read per partition
+	outcomes   map[int32]map[int64]error // results of this instance's appends, until collected
+	abandoned  map[int32]map[int64]bool  // appends nobody waits for any more; their result is dropped
+	progress   chan struct{}             // closed and replaced whenever consumed advances
+
+	stop chan struct{}
+	done chan struct{}
 }
 
-// NewKafkaEventStore creates a new event store
-func NewKafkaEventStore(brokers string) *KafkaEventStore {
-	return &KafkaEventStore{
-		brokers: brokers,
-		events:  make(map[string][]domain.DomainEvent),
+// NewKafkaEventStore creates a new event store. With no brokers configured it only keeps
+// events in memory; otherwise it replays the topic before returning.
+func NewKafkaEventStore(cfg KafkaConfig) (*KafkaEventStore, error) {
+	if cfg.Topic == "" {
+		cfg.Topic = defaultKafkaTopic
 	}
+	if cfg.ClientID == "" {
+		cfg.ClientID = defaultKafkaClientID
+	}
+	if cfg.Codec == nil {
+		cfg.Codec = DefaultEventCodec()
+	}
+	if cfg.AppendTimeout <= 0 {
+		cfg.AppendTimeout = defaultAppendTimeout
+	}
+	k := &KafkaEventStore{
+		cfg:       cfg,
+		index:     newEventIndex(),
+		consumed:  make(map[int32]int64),
+		outcomes:  make(map[int32]map[int64]error),
+		abandoned: make(map[int32]map[int64]bool),
+		progress:  make(chan struct{}),
+	}
+
+	brokers := splitBrokers(cfg.Brokers)
+	if len(brokers) == 0 {
+		return k, nil
+	}
+
+	instanceID, err := newInstanceID()
+	if err != nil {
+		return nil, err
+	}
+	k.instanceID = instanceID
+	k.producer = kafka.NewClient(brokers, cfg.ClientID+"-producer")
+	k.consumer = kafka.NewClient(brokers, cfg.ClientID+"-consumer")
+
+	partitions, err := k.producer.Partitions(cfg.Topic)
+	if err != nil {
+		k.closeClients()
+		return nil, fmt.Errorf("failed to load metadata for topic %s: %w", cfg.Topic, err)
+	}
+	k.partitions = partitions
+	for p := int32(0); p < partitions; p++ {
+		k.consumed[p] = 0
+	}
+
+	if err := k.catchUp(); err != nil {
+		k.closeClients()
+		return nil, fmt.Errorf("failed to replay topic %s: %w", cfg.Topic, err)
+	}
+
+	k.stop = make(chan struct{})
+	k.done = make(chan struct{})
+	go k.consumeLoop()
+
+	slog.Info("Kafka event store ready",
+		"brokers", cfg.Brokers,
+		"topic", cfg.Topic,
+		"partitions", partitions,
+		"channels", len(k.index.channels()))
+
+	return k, nil
 }
 
-// AppendEvent append un evento al log
+// AppendEvent appends a single event without a version check
 func (k *KafkaEventStore) AppendEvent(event domain.DomainEvent) error {
-	// Enviar a Kafka (simulado)
-	slog.Debug("Event stored", "type", event.GetEventType(), "channel", event.GetChannel().Value())
+	return k.AppendEvents(event.GetChannel(), domain.AnyVersion, []domain.DomainEvent{event})
+}
 
-	// Save to in-memory cache (arrival order)
-	k.mu.Lock()
-	defer k.mu.Unlock()
-	channel := event.GetChannel().Value()
-	k.events[channel] = append(k.events[channel], event)
+// AppendEvents produces a batch to the channel's partition as one record and waits until the
+// consumer has applied (or rejected) it
+func (k *KafkaEventStore) AppendEvents(channel *domain.Channel, expectedVersion int, events []domain.DomainEvent) error {
+	if err := validateBatch(channel, events); err != nil {
+		return err
+	}
+	if len(events) == 0 {
+		return nil
+	}
 
-	return nil
+	if k.producer == nil {
+		// No broker configured: save to in-memory cache (arrival order)
+		if err := k.index.appendBatch(channel.Value(), expectedVersion, events, ""); err != nil {
+			return err
+		}
+		slog.Debug("Events stored", "channel", channel.Value(), "count", len(events))
+		return nil
+	}
+
+	// Every batch in the topic expects a version, so that each one is chained to the event
+	// that really precedes it. Without a version the batch is pinned to the version this
+	// instance knows and produced again if another instance wrote first.
+	pinned := expectedVersion == domain.AnyVersion
+	for attempt := 1; ; attempt++ {
+		version, prevHash := k.index.head(channel.Value())
+		if !pinned && version != expectedVersion {
+			// Fail fast on a conflict this instance already knows about
+			return &domain.ConcurrencyError{Channel: channel.Value(), Expected: expectedVersion, Actual: version}
+		}
+		err := k.produceBatch(channel, version, events, prevHash)
+		if !pinned || !errors.Is(err, domain.ErrConcurrencyConflict) || attempt >= kafkaPinnedRetries {
+			return err
+		}
+	}
+}
+
+// produceBatch produces a batch to the channel's partition as one record and waits until
+// the consumer has applied (or rejected) it
+func (k *KafkaEventStore) produceBatch(channel *domain.Channel, expectedVersion int, events []domain.DomainEvent, prevHash string) error {
+	records, _, err := newEventRecords(k.cfg.Codec, 0, events, prevHash)
+	if err != nil {
+		return err
+	}
+	value, err := json.Marshal(kafkaBatch{ExpectedVersion: expectedVersion, Events: records})
+	if err != nil {
+		return fmt.Errorf("failed to encode record: %w", err)
+	}
+
+	key := []byte(channel.Value())
+	partition := kafka.PartitionForKey(key, k.partitions)
+	offset, err := k.producer.Produce(k.cfg.Topic, partition, []kafka.Record{{
+		Timestamp: time.Now().UnixMilli(),
+		Key:       key,
+		Value:     value,
+		Headers:   []kafka.Header{{Key: kafkaProducerHeader, Value: []byte(k.instanceID)}},
+	}})
+	if err != nil {
+		return fmt.Errorf("failed to produce events: %w", err)
+	}
+
+	slog.Debug("Events produced",
+		"channel", channel.Value(),
+		"count", len(events),
+		"partition", partition,
+		"offset", offset)
+
+	return k.waitConsumed(partition, offset)
+}
+
+// kafkaBatch is the value of a Kafka record: the events of one append and the version they expect
+type kafkaBatch struct {
+	ExpectedVersion int            `json:"expectedVersion"`
+	Events          []*eventRecord `json:"events"`
+}
+
+// decodeKafkaBatch decodes a record value
+func decodeKafkaBatch(value []byte) (*kafkaBatch, error) {
+	var batch kafkaBatch
+	if err := json.Unmarshal(value, &batch); err != nil {
+		return nil, err
+	}
+	if len(batch.Events) == 0 {
+		return nil, fmt.Errorf("empty record batch")
+	}
+	return &batch, nil
 }
 
 // GetEventsByChannel gets all events for a channel
 func (k *KafkaEventStore) GetEventsByChannel(channel *domain.Channel) ([]domain.DomainEvent, error) {
-	k.mu.RLock()
-	defer k.mu.RUnlock()
-	items := k.events[channel.Value()]
-	// Return a copy to avoid exposing internal slice
-	copySlice := make([]domain.DomainEvent, len(items))
-	copy(copySlice, items)
-	return copySlice, nil
+	return k.index.byChannel(channel.Value()), nil
 }
 
 // GetAllChannels gets all channels that have events
 func (k *KafkaEventStore) GetAllChannels() []string {
-	k.mu.RLock()
-	defer k.mu.RUnlock()
-	channels := make([]string, 0, len(k.events))
-	for ch := range k.events {
-		channels = append(channels, ch)
+	return k.index.channels()
+}
+
+// ReadAll reads the global log in the order this instance consumed the topic.
+// Events of a channel share a partition, so their relative order is the same on every
+// instance; the interleaving of different partitions is not.
+func (k *KafkaEventStore) ReadAll(fromPosition int64, limit int) ([]domain.RecordedEvent, error) {
+	return k.index.readAll(fromPosition, limit), nil
+}
+
+// SubscribeAll delivers the global log from fromPosition, then live events
+func (k *KafkaEventStore) SubscribeAll(ctx context.Context, fromPosition int64, handler func(domain.RecordedEvent) error) error {
+	return k.index.subscribeAll(ctx, fromPosition, handler)
+}
+
+// SubscribeToChannel delivers the events of a channel after the first fromVersion, then live events
+func (k *KafkaEventStore) SubscribeToChannel(ctx context.Context, channel *domain.Channel, fromVersion int, handler func(domain.RecordedEvent) error) error {
+	if channel == nil {
+		return fmt.Errorf("channel cannot be nil")
+	}
+	return k.index.subscribeChannel(ctx, channel.Value(), fromVersion, handler)
+}
+
+// Close stops the consumer and closes the broker connections
+func (k *KafkaEventStore) Close() error {
+	if k.stop != nil {
+		close(k.stop)
+		// Closing the consumer aborts a pending long-poll
+		_ = k.consumer.Close()
+		<-k.done
+	}
+	k.closeClients()
+	return nil
+}
+
+// catchUp consumes every partition up to its current high watermark
+func (k *KafkaEventStore) catchUp() error {
+	for {
+		offsets := k.offsets()
+		results, err := k.consumer.Fetch(k.cfg.Topic, offsets, 0)
+		if err != nil {
+			return err
+		}
+		done := true
+		for _, partition := range sortedPartitions(results) {
+			res := results[partition]
+			if res.Err != nil {
+				return fmt.Errorf("partition %d: %w", partition, res.Err)
+			}
+			k.apply(partition, res.Records)
+			if k.offsets()[partition] < res.HighWatermark {
+				done = false
+			}
+		}
+		if done {
+			return nil
+		}
+	}
+}
+
+// consumeLoop long-polls the topic and applies new records to the cache
+func (k *KafkaEventStore) consumeLoop() {
+	defer close(k.done)
+	for {
+		select {
+		case <-k.stop:
+			return
+		default:
+		}
+
+		results, err := k.consumer.Fetch(k.cfg.Topic, k.offsets(), kafkaPollWait)
+		if err != nil {
+			select {
+			case <-k.stop:
+				return
+			default:
+			}
+			slog.Error("Kafka fetch failed", "topic", k.cfg.Topic, "err", err)
+			if !k.sleep(kafkaRetryBackoff) {
+				return
+			}
+			_ = k.consumer.RefreshMetadata(k.cfg.Topic)
+			continue
+		}
+		for _, partition := range sortedPartitions(results) {
+			res := results[partition]
+			if res.Err != nil {
+				slog.Error("Kafka partition fetch failed", "topic", k.cfg.Topic, "partition", partition, "err", res.Err)
+				continue
+			}
+			k.apply(partition, res.Records)
+		}
+	}
+}
+
+// sortedPartitions returns the partitions of a fetch in ascending order, so that global
+// positions do not depend on map iteration order
+func sortedPartitions(results map[int32]kafka.FetchResult) []int32 {
+	partitions := make([]int32, 0, len(results))
+	for partition := range results {
+		partitions = append(partitions, partition)
+	}
+	sort.Slice(partitions, func(i, j int) bool { return partitions[i] < partitions[j] })
+	return partitions
+}
+
+// apply adds the records of a partition to the cache, skipping those already consumed
+func (k *KafkaEventStore) apply(partition int32, records []kafka.Record) {
+	k.mu.Lock()
+	next := k.consumed[partition]
+	k.mu.Unlock()
+
+	advanced := false
+	outcomes := make(map[int64]error)
+	for _, r := range records {
+		if r.Offset < next {
+			continue
+		}
+		next = r.Offset + 1
+		advanced = true
+
+		err := k.applyRecord(r)
+		if err != nil && !errors.Is(err, domain.ErrConcurrencyConflict) {
+			slog.Error("Skipping invalid Kafka record", "partition", partition, "offset", r.Offset, "err", err)
+		}
+		if producedBy(r) == k.instanceID {
+			outcomes[r.Offset] = err
+		}
+	}
+
+	if !advanced {
+		return
+	}
+	k.mu.Lock()
+	k.consumed[partition] = next
+	if len(outcomes) > 0 {
+		if k.outcomes[partition] == nil {
+			k.outcomes[partition] = make(map[int64]error)
+		}
+		for offset, err := range outcomes {
+			if k.abandoned[partition][offset] {
+				delete(k.abandoned[partition], offset)
+				continue
+			}
+			k.outcomes[partition][offset] = err
+		}
+	}
+	close(k.progress)
+	k.progress = make(chan struct{})
+	k.mu.Unlock()
+}
+
+// applyRecord decodes a record and appends its batch to the cache if the version still matches
+func (k *KafkaEventStore) applyRecord(r kafka.Record) error {
+	batch, err := decodeKafkaBatch(r.Value)
+	if err != nil {
+		return fmt.Errorf("undecodable record: %w", err)
+	}
+	events := make([]domain.DomainEvent, 0, len(batch.Events))
+	for _, record := range batch.Events {
+		event, err := record.toDomainEvent(k.cfg.Codec)
+		if err != nil {
+			return err
+		}
+		events = append(events, event)
+	}
+	channel := events[0].GetChannel()
+	if err := validateBatch(channel, events); err != nil {
+		return err
+	}
+	return k.index.appendBatch(channel.Value(), batch.ExpectedVersion, events, batch.Events[len(batch.Events)-1].Hash)
+}
+
+// producedBy returns the instance id stored in a record's headers
+func producedBy(r kafka.Record) string {
+	for _, h := range r.Headers {
+		if h.Key == kafkaProducerHeader {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+// waitConsumed blocks until the record at offset has been consumed and returns the
+// outcome of applying it to the cache. After cfg.AppendTimeout it gives up with
+// domain.ErrOutcomeUnknown, as the record may still be consumed later.
+func (k *KafkaEventStore) waitConsumed(partition int32, offset int64) error {
+	timeout := time.NewTimer(k.cfg.AppendTimeout)
+	defer timeout.Stop()
+	expired := false
+	for {
+		k.mu.Lock()
+		if k.consumed[partition] > offset {
+			outcome := k.outcomes[partition][offset]
+			delete(k.outcomes[partition], offset)
+			k.mu.Unlock()
+			return outcome
+		}
+		if expired {
+			// Nobody will collect the outcome: the consumer drops it instead of keeping it
+			if k.abandoned[partition] == nil {
+				k.abandoned[partition] = make(map[int64]bool)
+			}
+			k.abandoned[partition][offset] = true
+			k.mu.Unlock()
+			return fmt.Errorf("%w: offset %d of partition %d was not consumed within %s",
+				domain.ErrOutcomeUnknown, offset, partition, k.cfg.AppendTimeout)
+		}
+		progress := k.progress
+		k.mu.Unlock()
+
+		select {
+		case <-progress:
+		case <-k.stop:
+			return fmt.Errorf("event store is closed")
+		case <-timeout.C:
+			expired = true
+		}
+	}
+}
+
+// offsets returns a copy of the next offsets to fetch
+func (k *KafkaEventStore) offsets() map[int32]int64 {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	offsets := make(map[int32]int64, len(k.consumed))
+	for p, o := range k.consumed {
+		offsets[p] = o
+	}
+	return offsets
+}
+
+// sleep waits for d or until the store is closed
+func (k *KafkaEventStore) sleep(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-k.stop:
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func (k *KafkaEventStore) closeClients() {
+	if k.producer != nil {
+		_ = k.producer.Close()
+	}
+	if k.consumer != nil {
+		_ = k.consumer.Close()
+	}
+}
+
+// newInstanceID returns a random identifier for this store instance
+func newInstanceID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("failed to generate instance id: %w", err)
+	}
+	return hex.EncodeToString(b[:]), nil
+}
+
+// splitBrokers parses a comma-separated broker list
+func splitBrokers(brokers string) []string {
+	var out []string
+	for _, b := range strings.Split(brokers, ",") {
+		if b = strings.TrimSpace(b); b != "" {
+			out = append(out, b)
+		}
 	}
-	return channels
+	return out
 }
@@ -0,0 +1,186 @@
+package infrastructure
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"rockets/internal/domain"
+	"rockets/internal/infrastructure/kafka"
+)
+
+// startFakeBroker starts an in-process broker that is closed when the test ends.
+func startFakeBroker(t *testing.T, partitions int) *kafka.FakeBroker {
+	t.Helper()
+	broker, err := kafka.NewFakeBroker(partitions)
+	if err != nil {
+		t.Fatalf("Failed to start fake broker: %v", err)
+	}
+	t.Cleanup(func() { _ = broker.Close() })
+	return broker
+}
+
+// openKafkaStore connects a store to the broker and closes it when the test ends.
+func openKafkaStore(t *testing.T, broker *kafka.FakeBroker) *KafkaEventStore {
+	t.Helper()
+	store, err := NewKafkaEventStore(KafkaConfig{Brokers: broker.Addr(), Topic: "test-events"})
+	if err != nil {
+		t.Fatalf("Expected no error connecting to broker, got %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	return store
+}
+
+// TestKafkaEventStoreRebuildsCacheFromTopic verifies that a new store instance replays the topic.
+// Expected result: a second instance sees every channel with its events in order.
+func TestKafkaEventStoreRebuildsCacheFromTopic(t *testing.T) {
+	// Arrange
+	broker := startFakeBroker(t, 3)
+	writer := openKafkaStore(t, broker)
+	appendLaunchAndSpeedUps(t, writer, "rocket-a", 3)
+	appendLaunchAndSpeedUps(t, writer, "rocket-b", 1)
+
+	// Act
+	reader := openKafkaStore(t, broker)
+
+	// Assert
+	if len(reader.GetAllChannels()) != 2 {
+		t.Errorf("Expected 2 channels, got %d", len(reader.GetAllChannels()))
+	}
+	channel, _ := domain.NewChannel("rocket-a")
+	events, _ := reader.GetEventsByChannel(channel)
+	if len(events) != 4 {
+		t.Fatalf("Expected 4 events, got %d", len(events))
+	}
+	for i, event := range events {
+		if event.GetMessageNumber().Value() != i+1 {
+			t.Errorf("Expected message %d at position %d, got %d", i+1, i, event.GetMessageNumber().Value())
+		}
+	}
+}
+
+// TestKafkaEventStoreReadYourWrites verifies that AppendEvent returns only once the event is readable.
+// Expected result: the writer's cache holds the event as soon as AppendEvent returns.
+func TestKafkaEventStoreReadYourWrites(t *testing.T) {
+	// Arrange
+	broker := startFakeBroker(t, 2)
+	store := openKafkaStore(t, broker)
+
+	// Act
+	appendLaunchAndSpeedUps(t, store, "rocket-ryw", 0)
+
+	// Assert
+	channel, _ := domain.NewChannel("rocket-ryw")
+	events, _ := store.GetEventsByChannel(channel)
+	if len(events) != 1 {
+		t.Errorf("Expected 1 event right after append, got %d", len(events))
+	}
+}
+
+// TestKafkaEventStoreSeesOtherInstances verifies that the background consumer picks up writes
+// made by another instance after startup.
+// Expected result: the reader's cache eventually contains the writer's event.
+func TestKafkaEventStoreSeesOtherInstances(t *testing.T) {
+	// Arrange
+	broker := startFakeBroker(t, 2)
+	reader := openKafkaStore(t, broker)
+	writer := openKafkaStore(t, broker)
+
+	// Act
+	appendLaunchAndSpeedUps(t, writer, "rocket-remote", 0)
+
+	// Assert
+	channel, _ := domain.NewChannel("rocket-remote")
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		events, _ := reader.GetEventsByChannel(channel)
+		if len(events) == 1 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected reader to consume 1 event, got %d", len(events))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestKafkaEventStoreWithoutBrokers verifies the in-memory mode used when no brokers are configured.
+// Expected result: events are kept in memory and no connection is attempted.
+func TestKafkaEventStoreWithoutBrokers(t *testing.T) {
+	// Arrange
+	store, err := NewKafkaEventStore(KafkaConfig{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Act
+	appendLaunchAndSpeedUps(t, store, "rocket-memory", 1)
+
+	// Assert
+	channel, _ := domain.NewChannel("rocket-memory")
+	events, _ := store.GetEventsByChannel(channel)
+	if len(events) != 2 {
+		t.Errorf("Expected 2 events, got %d", len(events))
+	}
+}
+
+// TestKafkaEventStoreConflictAcrossInstances verifies that two instances appending at the same
+// version cannot both win.
+// Expected result: the second append fails with a ConcurrencyError and both caches agree.
+func TestKafkaEventStoreConflictAcrossInstances(t *testing.T) {
+	// Arrange
+	broker := startFakeBroker(t, 2)
+	first := openKafkaStore(t, broker)
+	second := openKafkaStore(t, broker)
+	channel, _ := domain.NewChannel("rocket-race")
+	msgNum, _ := domain.NewMessageNumber(1)
+	speed, _ := domain.NewSpeed(100)
+	launch := func(rocketType string) []domain.DomainEvent {
+		return []domain.DomainEvent{&domain.RocketLaunched{
+			Channel: channel, MessageNumber: msgNum, Type: rocketType, Speed: speed, Mission: domain.MissionSatellite, Timestamp: 1,
+		}}
+	}
+
+	// Act
+	errFirst := first.AppendEvents(channel, 0, launch("first"))
+	// second has not consumed the first write yet, so it only finds out through the log
+	errSecond := second.AppendEvents(channel, 0, launch("second"))
+
+	// Assert
+	if errFirst != nil {
+		t.Fatalf("Expected first append to succeed, got %v", errFirst)
+	}
+	if !errors.Is(errSecond, domain.ErrConcurrencyConflict) {
+		t.Fatalf("Expected second append to conflict, got %v", errSecond)
+	}
+	for _, store := range []*KafkaEventStore{first, second} {
+		events, _ := store.GetEventsByChannel(channel)
+		if len(events) != 1 || events[0].(*domain.RocketLaunched).Type != "first" {
+			t.Errorf("Expected only the first launch in every cache, got %d events", len(events))
+		}
+	}
+}
+
+// TestKafkaEventStoreAppendOutcomeUnknown verifies an append whose record is not consumed in time.
+// Expected result: ErrOutcomeUnknown, and the outcome consumed later is dropped instead of kept.
+func TestKafkaEventStoreAppendOutcomeUnknown(t *testing.T) {
+	// Arrange
+	store, err := NewKafkaEventStore(KafkaConfig{AppendTimeout: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Act
+	waitErr := store.waitConsumed(0, 0)
+	store.apply(0, []kafka.Record{{Offset: 0, Value: []byte(`{}`)}})
+
+	// Assert
+	if !errors.Is(waitErr, domain.ErrOutcomeUnknown) {
+		t.Errorf("Expected ErrOutcomeUnknown, got %v", waitErr)
+	}
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if len(store.outcomes[0]) != 0 || len(store.abandoned[0]) != 0 {
+		t.Errorf("Expected no outcome kept, got %v and %v", store.outcomes[0], store.abandoned[0])
+	}
+}
@@ -0,0 +1,200 @@
+package infrastructure
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// dataKeySize is the size of a data key (AES-256)
+const dataKeySize = 32
+
+// keyExtension is the extension of the key files of a FileKeyStore
+const keyExtension = ".key"
+
+// destroyedKey is what the key file of an erased channel holds instead of a key
+var destroyedKey = []byte("destroyed\n")
+
+var (
+	// ErrKeyDestroyed is returned for the key of an erased channel
+	ErrKeyDestroyed = errors.New("data key destroyed")
+	// ErrKeyNotFound is returned for a channel that never had a key
+	ErrKeyNotFound = errors.New("data key not found")
+)
+
+// KeyStore keeps the data key every channel's event payloads are encrypted with
+type KeyStore interface {
+	// DataKey returns the key of a channel, creating it on first use
+	DataKey(channel string) ([]byte, error)
+	// LookupKey returns the key of a channel without creating it
+	LookupKey(channel string) ([]byte, error)
+	// DestroyKey destroys the key of a channel; it cannot be created again
+	DestroyKey(channel string) error
+}
+
+// FileKeyStore keeps one key file per channel in a directory. A destroyed key is overwritten
+// with a marker, so the channel keeps no key. The keys are only as gone as the storage makes
+// them: keep the directory away from the event store and its backups.
+type FileKeyStore struct {
+	dir string
+
+	mu   sync.Mutex
+	keys map[string][]byte // loaded keys; nil for destroyed ones
+}
+
+// NewFileKeyStore creates a key store in dir
+func NewFileKeyStore(dir string) (*FileKeyStore, error) {
+	if strings.TrimSpace(dir) == "" {
+		return nil, fmt.Errorf("key directory cannot be empty")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create key directory: %w", err)
+	}
+	return &FileKeyStore{dir: dir, keys: make(map[string][]byte)}, nil
+}
+
+// DataKey returns the key of a channel, creating it on first use
+func (s *FileKeyStore) DataKey(channel string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key, err := s.loadLocked(channel)
+	if !errors.Is(err, ErrKeyNotFound) {
+		return key, err
+	}
+
+	key = make([]byte, dataKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+	encoded := []byte(base64.StdEncoding.EncodeToString(key) + "\n")
+	if err := s.writeLocked(channel, encoded); err != nil {
+		return nil, err
+	}
+	s.keys[channel] = key
+	return key, nil
+}
+
+// LookupKey returns the key of a channel without creating it
+func (s *FileKeyStore) LookupKey(channel string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.loadLocked(channel)
+}
+
+// DestroyKey overwrites the key of a channel with the destroyed marker
+func (s *FileKeyStore) DestroyKey(channel string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.writeLocked(channel, destroyedKey); err != nil {
+		return err
+	}
+	s.keys[channel] = nil
+	return nil
+}
+
+// loadLocked returns the key of a channel from the cache or its file
+func (s *FileKeyStore) loadLocked(channel string) ([]byte, error) {
+	if key, ok := s.keys[channel]; ok {
+		if key == nil {
+			return nil, ErrKeyDestroyed
+		}
+		return key, nil
+	}
+
+	data, err := os.ReadFile(s.path(channel))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: channel %s", ErrKeyNotFound, channel)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read data key: %w", err)
+	}
+	if bytes.Equal(data, destroyedKey) {
+		s.keys[channel] = nil
+		return nil, ErrKeyDestroyed
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(key) != dataKeySize {
+		return nil, fmt.Errorf("invalid data key of channel %s", channel)
+	}
+	s.keys[channel] = key
+	return key, nil
+}
+
+// writeLocked replaces the key file of a channel atomically (temporary file + rename)
+func (s *FileKeyStore) writeLocked(channel string, data []byte) error {
+	tmp, err := os.CreateTemp(s.dir, ".key-*")
+	if err != nil {
+		return fmt.Errorf("failed to create key file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write key file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to sync key file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close key file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path(channel)); err != nil {
+		return fmt.Errorf("failed to publish key file: %w", err)
+	}
+	syncDir(s.dir)
+	return nil
+}
+
+// path returns the key file of a channel
+func (s *FileKeyStore) path(channel string) string {
+	return filepath.Join(s.dir, url.PathEscape(channel)+keyExtension)
+}
+
+// sealPayload encrypts a payload with AES-GCM; the nonce is prepended to the ciphertext.
+// aad binds the ciphertext to the event it belongs to.
+func sealPayload(key, payload, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return gcm.Seal(nonce, nonce, payload, aad), nil
+}
+
+// openPayload decrypts a payload sealed by sealPayload
+func openPayload(key, sealed, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, fmt.Errorf("sealed payload too short")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	payload, err := gcm.Open(nil, nonce, ciphertext, aad)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt payload: %w", err)
+	}
+	return payload, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
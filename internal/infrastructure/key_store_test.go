@@ -0,0 +1,75 @@
+package infrastructure
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"rockets/internal/domain"
+)
+
+// TestEncryptedStoreRedactsErasedChannel verifies crypto-shredding with a file event store.
+// Expected result: payloads and metadata are not stored in clear; once the data key is
+// destroyed the reopened store returns the history redacted, without timestamp or metadata,
+// the tombstone in clear, and the hash chains stay intact.
+func TestEncryptedStoreRedactsErasedChannel(t *testing.T) {
+	// Arrange
+	dir, keyDir := t.TempDir(), t.TempDir()
+	keys, _ := NewFileKeyStore(keyDir)
+	store, err := NewFileEventStore(FileEventStoreConfig{Dir: dir, Codec: EncryptedEventCodec(keys)})
+	if err != nil {
+		t.Fatalf("Expected no error opening store, got %v", err)
+	}
+	channel, _ := domain.NewChannel("rocket-secret")
+	rocket := domain.NewRocket(channel)
+	msgNum, _ := domain.NewMessageNumber(1)
+	speed, _ := domain.NewSpeed(1000)
+	rocket.SetCommandMetadata(domain.EventMetadata{domain.MetadataCorrelationID: "corr-nightjar"})
+	_ = rocket.LaunchNamed(msgNum, "Falcon-9", speed, domain.NewMissionName("Operation Nightjar", domain.MissionSatellite), 1000)
+	rocket.SetCommandMetadata(nil) // the tombstone is written by an admin, not the producer
+	_ = rocket.Erase("contract terminated", 2000)
+	if err := store.AppendEvents(channel, 0, rocket.GetUncommittedEvents()); err != nil {
+		t.Fatalf("Expected no error appending, got %v", err)
+	}
+	stored, _ := store.GetEventsByChannel(channel)
+	_ = store.Close()
+	segment, _ := os.ReadFile(lastSegmentPath(t, dir))
+
+	// Act
+	_ = keys.DestroyKey("rocket-secret")
+	reopened, err := NewFileEventStore(FileEventStoreConfig{Dir: dir, Codec: EncryptedEventCodec(keys)})
+	if err != nil {
+		t.Fatalf("Expected no error reopening store, got %v", err)
+	}
+	defer reopened.Close()
+	events, _ := reopened.GetEventsByChannel(channel)
+	report, _ := VerifyFileEventLog(dir)
+
+	// Assert
+	for _, secret := range []string{"Nightjar", "Falcon-9", "corr-nightjar"} {
+		if bytes.Contains(segment, []byte(secret)) {
+			t.Errorf("Expected %q not to be stored in clear", secret)
+		}
+	}
+	if _, ok := stored[0].(*domain.RedactedEvent); !ok {
+		t.Errorf("Expected the store to redact the launch once the tombstone is appended, got %T", stored[0])
+	}
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(events))
+	}
+	if redacted, ok := events[0].(*domain.RedactedEvent); !ok || redacted.GetEventType() != "rocket_launched" {
+		t.Errorf("Expected a redacted rocket_launched, got %T", events[0])
+	}
+	if events[0].GetTimestamp() != 0 || len(events[0].GetMetadata()) != 0 {
+		t.Errorf("Expected no timestamp or metadata on the redacted event, got %d %v", events[0].GetTimestamp(), events[0].GetMetadata())
+	}
+	if erased, ok := events[1].(*domain.ChannelErased); !ok || erased.Reason != "contract terminated" {
+		t.Errorf("Expected the tombstone in clear, got %T", events[1])
+	}
+	if report == nil || !report.Intact {
+		t.Errorf("Expected intact hash chains, got %+v", report)
+	}
+	if _, err := keys.DataKey("rocket-secret"); err != ErrKeyDestroyed {
+		t.Errorf("Expected ErrKeyDestroyed, got %v", err)
+	}
+}
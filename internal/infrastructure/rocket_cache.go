@@ -0,0 +1,181 @@
+package infrastructure
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CacheConfig bounds the rockets the repository keeps in memory.
+// Rockets that are evicted are rebuilt from the event store (or a snapshot) on their next access.
+type CacheConfig struct {
+	MaxEntries int           // maximum number of cached rockets (0 = unbounded)
+	TTL        time.Duration // evict rockets not accessed for this long (0 = never)
+}
+
+// CacheStats are the counters of the rocket cache
+type CacheStats struct {
+	Hits      int64 `json:"hits"`
+	Misses    int64 `json:"misses"`
+	Evictions int64 `json:"evictions"`
+	Size      int   `json:"size"`
+}
+
+// rocketCache is an LRU cache of rockets with an optional idle TTL.
+// The list is ordered by last access, so expired entries are always at the back.
+type rocketCache struct {
+	cfg     CacheConfig
+	now     func() time.Time
+	onEvict func(channel string)
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List // front = most recently used
+
+	hits      atomic.Int64
+	misses    atomic.Int64
+	evictions atomic.Int64
+}
+
+// cacheItem is one element of the LRU list
+type cacheItem struct {
+	channel    string
+	entry      *cachedRocket
+	lastAccess time.Time
+}
+
+func newRocketCache(cfg CacheConfig, onEvict func(channel string)) *rocketCache {
+	return &rocketCache{
+		cfg:     cfg,
+		now:     time.Now,
+		onEvict: onEvict,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// get returns the cached rocket of a channel and marks it as recently used
+func (c *rocketCache) get(channel string) (*cachedRocket, bool) {
+	entry, ok := c.lookup(channel, true)
+	if ok {
+		c.hits.Add(1)
+	} else {
+		c.misses.Add(1)
+	}
+	return entry, ok
+}
+
+// peek returns the cached rocket of a channel without counting a hit or miss and without
+// marking it as recently used
+func (c *rocketCache) peek(channel string) (*cachedRocket, bool) {
+	return c.lookup(channel, false)
+}
+
+// lookup returns the cached rocket of a channel, marking it as recently used if touch is set
+func (c *rocketCache) lookup(channel string, touch bool) (*cachedRocket, bool) {
+	now := c.now()
+	c.mu.Lock()
+	evicted := c.expireLocked(now)
+	var entry *cachedRocket
+	el, ok := c.entries[channel]
+	if ok {
+		item := el.Value.(*cacheItem)
+		if touch {
+			item.lastAccess = now
+			c.lru.MoveToFront(el)
+		}
+		entry = item.entry
+	}
+	c.mu.Unlock()
+	c.notify(evicted)
+	return entry, ok
+}
+
+// loadOrStore caches entry unless the channel is cached already, and returns the cached one
+func (c *rocketCache) loadOrStore(channel string, entry *cachedRocket) *cachedRocket {
+	return c.put(channel, entry, false)
+}
+
+// store caches entry, replacing the cached rocket of the channel if there is one
+func (c *rocketCache) store(channel string, entry *cachedRocket) {
+	c.put(channel, entry, true)
+}
+
+func (c *rocketCache) put(channel string, entry *cachedRocket, replace bool) *cachedRocket {
+	now := c.now()
+	c.mu.Lock()
+	evicted := c.expireLocked(now)
+	if el, ok := c.entries[channel]; ok {
+		item := el.Value.(*cacheItem)
+		item.lastAccess = now
+		c.lru.MoveToFront(el)
+		if replace {
+			item.entry = entry
+		}
+		entry = item.entry
+	} else {
+		c.entries[channel] = c.lru.PushFront(&cacheItem{channel: channel, entry: entry, lastAccess: now})
+		for c.cfg.MaxEntries > 0 && c.lru.Len() > c.cfg.MaxEntries {
+			evicted = append(evicted, c.removeLocked(c.lru.Back()))
+		}
+	}
+	c.mu.Unlock()
+	c.notify(evicted)
+	return entry
+}
+
+// delete drops a channel from the cache (not counted as an eviction)
+func (c *rocketCache) delete(channel string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[channel]; ok {
+		c.lru.Remove(el)
+		delete(c.entries, channel)
+	}
+}
+
+// stats returns the current counters
+func (c *rocketCache) stats() CacheStats {
+	c.mu.Lock()
+	size := c.lru.Len()
+	c.mu.Unlock()
+	return CacheStats{
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Evictions: c.evictions.Load(),
+		Size:      size,
+	}
+}
+
+// expireLocked removes the entries idle for longer than the TTL
+func (c *rocketCache) expireLocked(now time.Time) []string {
+	if c.cfg.TTL <= 0 {
+		return nil
+	}
+	var evicted []string
+	for el := c.lru.Back(); el != nil; el = c.lru.Back() {
+		if now.Sub(el.Value.(*cacheItem).lastAccess) < c.cfg.TTL {
+			break
+		}
+		evicted = append(evicted, c.removeLocked(el))
+	}
+	return evicted
+}
+
+func (c *rocketCache) removeLocked(el *list.Element) string {
+	item := c.lru.Remove(el).(*cacheItem)
+	delete(c.entries, item.channel)
+	c.evictions.Add(1)
+	return item.channel
+}
+
+// notify reports evicted channels outside the cache lock
+func (c *rocketCache) notify(evicted []string) {
+	if c.onEvict == nil {
+		return
+	}
+	for _, channel := range evicted {
+		c.onEvict(channel)
+	}
+}
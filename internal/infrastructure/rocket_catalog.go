@@ -0,0 +1,65 @@
+package infrastructure
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"rockets/internal/domain"
+)
+
+// rocketCatalogFile is the JSON representation of a rocket type catalog:
+//
+//	{"policy": "reject", "types": [{"name": "Falcon-9", "maxSpeed": 30000, "stages": 2,
+//	  "allowedMissions": ["satellite", "resupply"]}]}
+type rocketCatalogFile struct {
+	Policy string `json:"policy"`
+	Types  []struct {
+		Name            string   `json:"name"`
+		MaxSpeed        int      `json:"maxSpeed"`
+		Stages          int      `json:"stages"`
+		AllowedMissions []string `json:"allowedMissions"`
+	} `json:"types"`
+}
+
+// LoadRocketCatalog reads a rocket type catalog from a JSON file
+func LoadRocketCatalog(path string) (*domain.RocketCatalog, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open rocket catalog: %w", err)
+	}
+	defer file.Close()
+
+	catalog, err := ParseRocketCatalog(file)
+	if err != nil {
+		return nil, fmt.Errorf("rocket catalog %s: %w", path, err)
+	}
+	return catalog, nil
+}
+
+// ParseRocketCatalog reads a rocket type catalog in the JSON format of LoadRocketCatalog.
+// Missions are normalized like the ones of incoming messages; unknown fields are an error,
+// to catch typos in limits that would otherwise be silently ignored.
+func ParseRocketCatalog(r io.Reader) (*domain.RocketCatalog, error) {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	var file rocketCatalogFile
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("invalid catalog: %w", err)
+	}
+
+	specs := make([]domain.RocketTypeSpec, 0, len(file.Types))
+	for _, t := range file.Types {
+		spec := domain.RocketTypeSpec{Name: t.Name, MaxSpeed: t.MaxSpeed, Stages: t.Stages}
+		for _, mission := range t.AllowedMissions {
+			normalized := domain.NewMission(mission)
+			if normalized == domain.MissionUnknown {
+				return nil, fmt.Errorf("rocket type %q: unknown mission %q", t.Name, mission)
+			}
+			spec.AllowedMissions = append(spec.AllowedMissions, normalized)
+		}
+		specs = append(specs, spec)
+	}
+	return domain.NewRocketCatalog(domain.EnvelopePolicy(file.Policy), specs...)
+}
@@ -0,0 +1,54 @@
+package infrastructure
+
+import (
+	"strings"
+	"testing"
+
+	"rockets/internal/domain"
+)
+
+// TestParseRocketCatalog verifies that a JSON catalog is parsed with normalized missions.
+// Expected result: warn policy, Falcon-9 found case-insensitively with its limits.
+func TestParseRocketCatalog(t *testing.T) {
+	// Arrange
+	input := `{"policy":"warn","types":[{"name":"Falcon-9","maxSpeed":30000,"stages":2,"allowedMissions":["SATELLITE","resupply"]}]}`
+
+	// Act
+	catalog, err := ParseRocketCatalog(strings.NewReader(input))
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if catalog.Policy() != domain.EnvelopeWarn {
+		t.Errorf("Expected policy warn, got %s", catalog.Policy())
+	}
+	spec, ok := catalog.Lookup("FALCON-9")
+	if !ok {
+		t.Fatalf("Expected Falcon-9 to be in the catalog")
+	}
+	if spec.MaxSpeed != 30000 || spec.Stages != 2 || len(spec.AllowedMissions) != 2 || spec.AllowedMissions[0] != domain.MissionSatellite {
+		t.Errorf("Unexpected spec %+v", spec)
+	}
+}
+
+// TestParseRocketCatalogRejectsInvalid verifies that mistakes in the catalog fail at startup.
+// Expected result: an error for each invalid catalog.
+func TestParseRocketCatalogRejectsInvalid(t *testing.T) {
+	inputs := map[string]string{
+		"unknown policy":  `{"policy":"ignore","types":[]}`,
+		"unknown field":   `{"types":[{"name":"Falcon-9","maxSpeeed":30000}]}`,
+		"unknown mission": `{"types":[{"name":"Falcon-9","allowedMissions":["tourism"]}]}`,
+		"duplicate type":  `{"types":[{"name":"Falcon-9"},{"name":"falcon-9"}]}`,
+		"negative speed":  `{"types":[{"name":"Falcon-9","maxSpeed":-1}]}`,
+	}
+	for name, input := range inputs {
+		// Act
+		_, err := ParseRocketCatalog(strings.NewReader(input))
+
+		// Assert
+		if err == nil {
+			t.Errorf("%s: expected an error, got nil", name)
+		}
+	}
+}
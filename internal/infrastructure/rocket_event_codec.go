@@ -0,0 +1,374 @@
+package infrastructure
+
+import (
+	"fmt"
+
+	"rockets/internal/domain"
+)
+
+// eventHeader holds the fields every event payload carries
+type eventHeader struct {
+	Channel       string `json:"channel"`
+	MessageNumber int    `json:"messageNumber"`
+	Timestamp     int64  `json:"timestamp"`
+}
+
+func newEventHeader(event domain.DomainEvent) eventHeader {
+	return eventHeader{
+		Channel:       event.GetChannel().Value(),
+		MessageNumber: event.GetMessageNumber().Value(),
+		Timestamp:     event.GetTimestamp(),
+	}
+}
+
+// values rebuilds the channel and message number value objects
+func (h eventHeader) values() (*domain.Channel, *domain.MessageNumber, error) {
+	channel, err := domain.NewChannel(h.Channel)
+	if err != nil {
+		return nil, nil, err
+	}
+	msgNum, err := domain.NewMessageNumber(h.MessageNumber)
+	if err != nil {
+		return nil, nil, err
+	}
+	return channel, msgNum, nil
+}
+
+type launchedPayload struct {
+	eventHeader
+	Type       string `json:"type"`
+	Speed      int    `json:"speed"`
+	Mission    string `json:"mission"`
+	RawMission string `json:"rawMission,omitempty"`
+	Stages     int    `json:"stages,omitempty"`
+}
+
+type speedChangedPayload struct {
+	eventHeader
+	OldSpeed int `json:"oldSpeed"`
+	NewSpeed int `json:"newSpeed"`
+	Delta    int `json:"delta"`
+}
+
+type explodedPayload struct {
+	eventHeader
+	Reason string `json:"reason"`
+}
+
+type missionChangedPayload struct {
+	eventHeader
+	OldMission string `json:"oldMission"`
+	NewMission string `json:"newMission"`
+	RawMission string `json:"rawMission,omitempty"`
+}
+
+type landedPayload struct {
+	eventHeader
+	Site string `json:"site"`
+}
+
+type refueledPayload struct {
+	eventHeader
+	Amount int `json:"amount"`
+	Fuel   int `json:"fuel"`
+}
+
+type stageSeparatedPayload struct {
+	eventHeader
+	RemainingStages int `json:"remainingStages"`
+}
+
+type envelopeExceededPayload struct {
+	eventHeader
+	Command    string `json:"command"`
+	RocketType string `json:"rocketType"`
+	Rule       string `json:"rule"`
+	Detail     string `json:"detail"`
+	Rejected   bool   `json:"rejected"`
+}
+
+type timeRegressedPayload struct {
+	eventHeader
+	Command      string `json:"command"`
+	PreviousTime int64  `json:"previousTime"`
+	Rejected     bool   `json:"rejected"`
+}
+
+type messagesSkippedPayload struct {
+	eventHeader
+	From   int    `json:"from"`
+	Reason string `json:"reason,omitempty"`
+}
+
+type channelErasedPayload struct {
+	eventHeader
+	Reason string `json:"reason"`
+}
+
+// registerRocketEvents registers the current schema of every rocket event
+func registerRocketEvents(c *EventCodec) {
+	_ = c.Register("rocket_launched", 1,
+		typedEncoder(func(e *domain.RocketLaunched) launchedPayload {
+			return launchedPayload{eventHeader: newEventHeader(e), Type: e.Type, Speed: e.Speed.Value(), Mission: string(e.Mission), RawMission: e.RawMission, Stages: e.Stages}
+		}),
+		typedDecoder(func(p launchedPayload, metadata domain.EventMetadata) (domain.DomainEvent, error) {
+			channel, msgNum, err := p.values()
+			if err != nil {
+				return nil, err
+			}
+			speed, err := domain.NewSpeed(p.Speed)
+			if err != nil {
+				return nil, err
+			}
+			return &domain.RocketLaunched{
+				Channel:       channel,
+				MessageNumber: msgNum,
+				Type:          p.Type,
+				Speed:         speed,
+				Mission:       domain.Mission(p.Mission),
+				RawMission:    p.RawMission,
+				Stages:        p.Stages,
+				Timestamp:     p.Timestamp,
+				Metadata:      metadata,
+			}, nil
+		}))
+
+	_ = c.Register("rocket_speed_increased", 1,
+		typedEncoder(func(e *domain.RocketSpeedIncreased) speedChangedPayload {
+			return speedChangedPayload{eventHeader: newEventHeader(e), OldSpeed: e.OldSpeed.Value(), NewSpeed: e.NewSpeed.Value(), Delta: e.Delta}
+		}),
+		typedDecoder(func(p speedChangedPayload, metadata domain.EventMetadata) (domain.DomainEvent, error) {
+			channel, msgNum, oldSpeed, newSpeed, err := p.values()
+			if err != nil {
+				return nil, err
+			}
+			return &domain.RocketSpeedIncreased{
+				Channel:       channel,
+				MessageNumber: msgNum,
+				OldSpeed:      oldSpeed,
+				NewSpeed:      newSpeed,
+				Delta:         p.Delta,
+				Timestamp:     p.Timestamp,
+				Metadata:      metadata,
+			}, nil
+		}))
+
+	_ = c.Register("rocket_speed_decreased", 1,
+		typedEncoder(func(e *domain.RocketSpeedDecreased) speedChangedPayload {
+			return speedChangedPayload{eventHeader: newEventHeader(e), OldSpeed: e.OldSpeed.Value(), NewSpeed: e.NewSpeed.Value(), Delta: e.Delta}
+		}),
+		typedDecoder(func(p speedChangedPayload, metadata domain.EventMetadata) (domain.DomainEvent, error) {
+			channel, msgNum, oldSpeed, newSpeed, err := p.values()
+			if err != nil {
+				return nil, err
+			}
+			return &domain.RocketSpeedDecreased{
+				Channel:       channel,
+				MessageNumber: msgNum,
+				OldSpeed:      oldSpeed,
+				NewSpeed:      newSpeed,
+				Delta:         p.Delta,
+				Timestamp:     p.Timestamp,
+				Metadata:      metadata,
+			}, nil
+		}))
+
+	_ = c.Register("rocket_exploded", 1,
+		typedEncoder(func(e *domain.RocketExploded) explodedPayload {
+			return explodedPayload{eventHeader: newEventHeader(e), Reason: e.Reason}
+		}),
+		typedDecoder(func(p explodedPayload, metadata domain.EventMetadata) (domain.DomainEvent, error) {
+			channel, msgNum, err := p.eventHeader.values()
+			if err != nil {
+				return nil, err
+			}
+			return &domain.RocketExploded{
+				Channel:       channel,
+				MessageNumber: msgNum,
+				Reason:        p.Reason,
+				Timestamp:     p.Timestamp,
+				Metadata:      metadata,
+			}, nil
+		}))
+
+	_ = c.Register("messages_skipped", 1,
+		typedEncoder(func(e *domain.MessagesSkipped) messagesSkippedPayload {
+			return messagesSkippedPayload{eventHeader: newEventHeader(e), From: e.From, Reason: e.Reason}
+		}),
+		typedDecoder(func(p messagesSkippedPayload, metadata domain.EventMetadata) (domain.DomainEvent, error) {
+			channel, msgNum, err := p.values()
+			if err != nil {
+				return nil, err
+			}
+			if p.From <= 0 || p.From > msgNum.Value() {
+				return nil, fmt.Errorf("invalid skipped range %d..%d", p.From, msgNum.Value())
+			}
+			return &domain.MessagesSkipped{
+				Channel:       channel,
+				MessageNumber: msgNum,
+				From:          p.From,
+				Reason:        p.Reason,
+				Timestamp:     p.Timestamp,
+				Metadata:      metadata,
+			}, nil
+		}))
+
+	_ = c.Register("channel_erased", 1,
+		typedEncoder(func(e *domain.ChannelErased) channelErasedPayload {
+			return channelErasedPayload{eventHeader: newEventHeader(e), Reason: e.Reason}
+		}),
+		typedDecoder(func(p channelErasedPayload, metadata domain.EventMetadata) (domain.DomainEvent, error) {
+			channel, msgNum, err := p.values()
+			if err != nil {
+				return nil, err
+			}
+			return &domain.ChannelErased{
+				Channel:       channel,
+				MessageNumber: msgNum,
+				Reason:        p.Reason,
+				Timestamp:     p.Timestamp,
+				Metadata:      metadata,
+			}, nil
+		}))
+
+	_ = c.Register("rocket_mission_changed", 1,
+		typedEncoder(func(e *domain.RocketMissionChanged) missionChangedPayload {
+			return missionChangedPayload{eventHeader: newEventHeader(e), OldMission: string(e.OldMission), NewMission: string(e.NewMission), RawMission: e.RawMission}
+		}),
+		typedDecoder(func(p missionChangedPayload, metadata domain.EventMetadata) (domain.DomainEvent, error) {
+			channel, msgNum, err := p.values()
+			if err != nil {
+				return nil, err
+			}
+			return &domain.RocketMissionChanged{
+				Channel:       channel,
+				MessageNumber: msgNum,
+				OldMission:    domain.Mission(p.OldMission),
+				NewMission:    domain.Mission(p.NewMission),
+				RawMission:    p.RawMission,
+				Timestamp:     p.Timestamp,
+				Metadata:      metadata,
+			}, nil
+		}))
+
+	_ = c.Register("rocket_landed", 1,
+		typedEncoder(func(e *domain.RocketLanded) landedPayload {
+			return landedPayload{eventHeader: newEventHeader(e), Site: e.Site}
+		}),
+		typedDecoder(func(p landedPayload, metadata domain.EventMetadata) (domain.DomainEvent, error) {
+			channel, msgNum, err := p.values()
+			if err != nil {
+				return nil, err
+			}
+			return &domain.RocketLanded{
+				Channel:       channel,
+				MessageNumber: msgNum,
+				Site:          p.Site,
+				Timestamp:     p.Timestamp,
+				Metadata:      metadata,
+			}, nil
+		}))
+
+	_ = c.Register("rocket_refueled", 1,
+		typedEncoder(func(e *domain.RocketRefueled) refueledPayload {
+			return refueledPayload{eventHeader: newEventHeader(e), Amount: e.Amount, Fuel: e.Fuel}
+		}),
+		typedDecoder(func(p refueledPayload, metadata domain.EventMetadata) (domain.DomainEvent, error) {
+			channel, msgNum, err := p.values()
+			if err != nil {
+				return nil, err
+			}
+			return &domain.RocketRefueled{
+				Channel:       channel,
+				MessageNumber: msgNum,
+				Amount:        p.Amount,
+				Fuel:          p.Fuel,
+				Timestamp:     p.Timestamp,
+				Metadata:      metadata,
+			}, nil
+		}))
+
+	_ = c.Register("rocket_stage_separated", 1,
+		typedEncoder(func(e *domain.RocketStageSeparated) stageSeparatedPayload {
+			return stageSeparatedPayload{eventHeader: newEventHeader(e), RemainingStages: e.RemainingStages}
+		}),
+		typedDecoder(func(p stageSeparatedPayload, metadata domain.EventMetadata) (domain.DomainEvent, error) {
+			channel, msgNum, err := p.values()
+			if err != nil {
+				return nil, err
+			}
+			return &domain.RocketStageSeparated{
+				Channel:         channel,
+				MessageNumber:   msgNum,
+				RemainingStages: p.RemainingStages,
+				Timestamp:       p.Timestamp,
+				Metadata:        metadata,
+			}, nil
+		}))
+
+	_ = c.Register("rocket_envelope_exceeded", 1,
+		typedEncoder(func(e *domain.RocketEnvelopeExceeded) envelopeExceededPayload {
+			return envelopeExceededPayload{
+				eventHeader: newEventHeader(e),
+				Command:     string(e.Command),
+				RocketType:  e.RocketType,
+				Rule:        e.Rule,
+				Detail:      e.Detail,
+				Rejected:    e.Rejected,
+			}
+		}),
+		typedDecoder(func(p envelopeExceededPayload, metadata domain.EventMetadata) (domain.DomainEvent, error) {
+			channel, msgNum, err := p.values()
+			if err != nil {
+				return nil, err
+			}
+			return &domain.RocketEnvelopeExceeded{
+				Channel:       channel,
+				MessageNumber: msgNum,
+				Command:       domain.RocketCommand(p.Command),
+				RocketType:    p.RocketType,
+				Rule:          p.Rule,
+				Detail:        p.Detail,
+				Rejected:      p.Rejected,
+				Timestamp:     p.Timestamp,
+				Metadata:      metadata,
+			}, nil
+		}))
+
+	_ = c.Register("rocket_time_regressed", 1,
+		typedEncoder(func(e *domain.RocketTimeRegressed) timeRegressedPayload {
+			return timeRegressedPayload{eventHeader: newEventHeader(e), Command: string(e.Command), PreviousTime: e.PreviousTime, Rejected: e.Rejected}
+		}),
+		typedDecoder(func(p timeRegressedPayload, metadata domain.EventMetadata) (domain.DomainEvent, error) {
+			channel, msgNum, err := p.values()
+			if err != nil {
+				return nil, err
+			}
+			return &domain.RocketTimeRegressed{
+				Channel:       channel,
+				MessageNumber: msgNum,
+				Command:       domain.RocketCommand(p.Command),
+				PreviousTime:  p.PreviousTime,
+				Rejected:      p.Rejected,
+				Timestamp:     p.Timestamp,
+				Metadata:      metadata,
+			}, nil
+		}))
+}
+
+// values rebuilds the value objects of a speed change
+func (p speedChangedPayload) values() (*domain.Channel, *domain.MessageNumber, *domain.Speed, *domain.Speed, error) {
+	channel, msgNum, err := p.eventHeader.values()
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	oldSpeed, err := domain.NewSpeed(p.OldSpeed)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	newSpeed, err := domain.NewSpeed(p.NewSpeed)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	return channel, msgNum, oldSpeed, newSpeed, nil
+}
@@ -1,24 +1,97 @@
 package infrastructure
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"sync"
+	"time"
 
 	"rockets/internal/domain"
 )
 
-// RocketRepository implements the RocketRepository using in-memory cache and KafkaEventStore simulation
+// RocketRepository implements the RocketRepository using in-memory cache on top of an event store
 type RocketRepository struct {
-	eventStore *KafkaEventStore
-	cache      sync.Map
+	eventStore  domain.EventStore
+	cacheConfig CacheConfig
+	cache       *rocketCache
+
+	publisher domain.EventPublisher // optional: receives every committed batch
+
+	snapshots      domain.SnapshotStore
+	snapshotPolicy SnapshotPolicy
+	progressMu     sync.Mutex
+	progress       map[string]*snapshotProgress
+}
+
+// cachedRocket is a cache entry; the rocket carries the stream version it reflects (GetVersion)
+type cachedRocket struct {
+	rocket *domain.Rocket
+}
+
+// snapshotProgress tracks what happened on a channel since its last snapshot
+type snapshotProgress struct {
+	eventsSince int
+	lastAt      time.Time
+}
+
+// RepositoryOption configures optional features of the RocketRepository
+type RepositoryOption func(*RocketRepository)
+
+// WithSnapshots makes the repository hydrate rockets from snapshots and take new ones
+// according to the policy
+func WithSnapshots(store domain.SnapshotStore, policy SnapshotPolicy) RepositoryOption {
+	return func(r *RocketRepository) {
+		r.snapshots = store
+		r.snapshotPolicy = policy
+	}
+}
+
+// WithEventPublisher makes the repository publish the events of every batch it commits
+func WithEventPublisher(publisher domain.EventPublisher) RepositoryOption {
+	return func(r *RocketRepository) {
+		r.publisher = publisher
+	}
+}
+
+// WithCache bounds the rocket cache (by default every rocket stays in memory)
+func WithCache(cfg CacheConfig) RepositoryOption {
+	return func(r *RocketRepository) {
+		r.cacheConfig = cfg
+	}
 }
 
 // NewRocketRepository creates a new RocketRepository
-func NewRocketRepository(eventStore *KafkaEventStore) *RocketRepository {
-	return &RocketRepository{
+func NewRocketRepository(eventStore domain.EventStore, opts ...RepositoryOption) *RocketRepository {
+	r := &RocketRepository{
 		eventStore: eventStore,
+		progress:   make(map[string]*snapshotProgress),
+	}
+	for _, opt := range opts {
+		opt(r)
 	}
+	r.cache = newRocketCache(r.cacheConfig, r.evicted)
+	return r
+}
+
+// CacheStats returns the hit, miss and eviction counters of the rocket cache
+func (r *RocketRepository) CacheStats() CacheStats {
+	return r.cache.stats()
+}
+
+// Invalidate drops the cached rocket of a channel, e.g. after events were appended to the
+// store without going through the repository
+func (r *RocketRepository) Invalidate(channel *domain.Channel) {
+	r.cache.delete(channel.Value())
+}
+
+// evicted forgets the snapshot progress of a rocket that left the cache; it is rebuilt
+// on the next hydration
+func (r *RocketRepository) evicted(channel string) {
+	r.progressMu.Lock()
+	delete(r.progress, channel)
+	r.progressMu.Unlock()
+	slog.Debug("Rocket evicted from cache", "channel", channel)
 }
 
 // GetByChannel get a rocket by channel - FAKECONSUMER
@@ -28,59 +101,225 @@ func (r *RocketRepository) GetByChannel(channel *domain.Channel) (*domain.Rocket
 	}
 
 	// Try to get from in-memory cache first
-	if cached, ok := r.cache.Load(channel.Value()); ok {
-		return cached.(*domain.Rocket), nil
+	if cached, ok := r.cache.get(channel.Value()); ok {
+		return cached.rocket, nil
+	}
+
+	rocket, err := r.hydrate(channel)
+	if err != nil {
+		return nil, err
 	}
 
+	// Save to cache (unless another goroutine hydrated it first)
+	cached := r.cache.loadOrStore(channel.Value(), &cachedRocket{rocket: rocket})
+
+	return cached.rocket, nil
+}
+
+// Peek gets a rocket like GetByChannel, but a rocket that is not cached is rebuilt without
+// being added to the cache, and the lookup counts neither as a hit nor as a miss
+func (r *RocketRepository) Peek(channel *domain.Channel) (*domain.Rocket, error) {
+	if channel == nil {
+		return nil, fmt.Errorf("channel cannot be nil")
+	}
+	if cached, ok := r.cache.peek(channel.Value()); ok {
+		return cached.rocket, nil
+	}
+	return r.hydrate(channel)
+}
+
+// hydrate rebuilds a rocket from its latest snapshot (if any) plus the newer events
+func (r *RocketRepository) hydrate(channel *domain.Channel) (*domain.Rocket, error) {
 	// Create new rocket if it doesn't exist
 	rocket := domain.NewRocket(channel)
 
-	// Replay from the event store (in-memory stub)
 	events, err := r.eventStore.GetEventsByChannel(channel)
-	if err == nil && len(events) > 0 {
-		_ = rocket.LoadFromHistory(events)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load events: %w", err)
 	}
 
-	// Save to cache
-	r.cache.Store(channel.Value(), rocket)
+	snapshot := r.loadSnapshot(channel, events)
+	if snapshot != nil {
+		if err := rocket.RestoreFromSnapshot(snapshot); err != nil {
+			slog.Warn("Ignoring unusable snapshot", "channel", channel.Value(), "err", err)
+			rocket = domain.NewRocket(channel)
+			snapshot = nil
+		}
+	}
+
+	// Replay only the events the snapshot does not cover
+	replay := events
+	if snapshot != nil {
+		replay = events[snapshot.Version:]
+	}
+	if len(replay) > 0 {
+		_ = rocket.LoadFromHistory(replay)
+	}
+
+	if r.snapshots != nil {
+		lastAt := time.Now()
+		if snapshot != nil {
+			lastAt = time.UnixMilli(snapshot.TakenAt)
+		}
+		r.progressMu.Lock()
+		r.progress[channel.Value()] = &snapshotProgress{eventsSince: len(replay), lastAt: lastAt}
+		r.progressMu.Unlock()
+	}
+
+	slog.Debug("Rocket hydrated",
+		"channel", channel.Value(),
+		"from_snapshot", snapshot != nil,
+		"replayed_events", len(replay),
+		"total_events", len(events))
 
 	return rocket, nil
 }
 
-// Save persists a rocket
+// loadSnapshot returns the channel's snapshot if it is consistent with the stored events
+func (r *RocketRepository) loadSnapshot(channel *domain.Channel, events []domain.DomainEvent) *domain.RocketSnapshot {
+	if r.snapshots == nil || len(events) == 0 {
+		return nil
+	}
+	snapshot, err := r.snapshots.LoadSnapshot(channel)
+	if err != nil {
+		slog.Warn("Failed to load snapshot, replaying full history", "channel", channel.Value(), "err", err)
+		return nil
+	}
+	if snapshot == nil {
+		return nil
+	}
+	// An erased channel is rebuilt from its tombstone; a snapshot could predate the erasure
+	if _, erased := events[len(events)-1].(*domain.ChannelErased); erased {
+		return nil
+	}
+	// A snapshot ahead of the log (e.g. events lost in a crash) cannot be trusted
+	last := events[len(events)-1].GetMessageNumber().Value()
+	if snapshot.LastMessageNumber > last || snapshot.Version > len(events) {
+		slog.Warn("Snapshot is ahead of the event store, replaying full history",
+			"channel", channel.Value(),
+			"snapshot_message_number", snapshot.LastMessageNumber,
+			"last_message_number", last,
+			"snapshot_version", snapshot.Version,
+			"version", len(events))
+		return nil
+	}
+	return snapshot
+}
+
+// Save persists a rocket's uncommitted events as one atomic batch.
+// The batch is only written if nobody else appended to the channel since the rocket was
+// loaded; otherwise a *domain.ConcurrencyError is returned and the cached copy is dropped,
+// so the next GetByChannel sees the other writer's events.
 func (r *RocketRepository) Save(rocket *domain.Rocket) error {
 	if rocket == nil {
 		return fmt.Errorf("rocket cannot be nil")
 	}
 
-	slog.Debug("Saving rocket", "channel", rocket.GetChannel().Value(), "pending_events", len(rocket.GetUncommittedEvents()))
+	channel := rocket.GetChannel()
+	events := rocket.GetUncommittedEvents()
+	slog.Debug("Saving rocket", "channel", channel.Value(), "pending_events", len(events))
 
-	// Save to cache
-	r.cache.Store(rocket.GetChannel().Value(), rocket)
+	// The rocket's version counts the events it applied, the new ones included: the batch only
+	// fits if the stream is still at the version the rocket was loaded at. Otherwise the
+	// append fails with a conflict and the caller reloads the rocket.
+	expectedVersion := rocket.GetVersion() - len(events)
 
-	// Save events to the event store
-	for _, event := range rocket.GetUncommittedEvents() {
+	for _, event := range events {
 		slog.Debug("Persisting event",
-			"channel", rocket.GetChannel().Value(),
+			"channel", channel.Value(),
 			"type", event.GetEventType(),
 			"message_number", event.GetMessageNumber().Value())
-		if err := r.eventStore.AppendEvent(event); err != nil {
-			slog.Error("Failed to persist event",
-				"channel", rocket.GetChannel().Value(),
-				"err", err)
-			return fmt.Errorf("failed to save event: %w", err)
+	}
+
+	if err := r.eventStore.AppendEvents(channel, expectedVersion, events); err != nil {
+		// The in-memory rocket already applied the rejected events: forget it
+		r.cache.delete(channel.Value())
+		if errors.Is(err, domain.ErrConcurrencyConflict) {
+			slog.Warn("Concurrent write detected", "channel", channel.Value(), "err", err)
+			return err
 		}
+		slog.Error("Failed to persist events",
+			"channel", channel.Value(),
+			"err", err)
+		return fmt.Errorf("failed to save events: %w", err)
 	}
 
-	slog.Info("Rocket saved successfully", "channel", rocket.GetChannel().Value(), "total_events", len(rocket.GetUncommittedEvents()))
+	// Save to cache
+	if cached, ok := r.cache.peek(channel.Value()); !ok || cached.rocket != rocket {
+		r.cache.store(channel.Value(), &cachedRocket{rocket: rocket})
+	}
+
+	committed := len(events)
+	slog.Info("Rocket saved successfully", "channel", channel.Value(), "total_events", committed)
 
 	// Mark events as committed
 	rocket.MarkEventsAsCommitted()
 
+	// Only committed events are published; a failed append returned above
+	if r.publisher != nil {
+		r.publisher.Publish(events)
+	}
+
+	if rocket.GetStatus() == domain.StatusErased {
+		r.dropSnapshot(channel)
+		return nil
+	}
+	r.maybeSnapshot(rocket, committed)
+
 	return nil
 }
 
-// GetAll gets all rockets (reconstructed from the event store)
+// dropSnapshot deletes the snapshot of an erased channel, which holds the state it had
+func (r *RocketRepository) dropSnapshot(channel *domain.Channel) {
+	if r.snapshots == nil {
+		return
+	}
+	if err := r.snapshots.DeleteSnapshot(channel); err != nil {
+		slog.Error("Failed to delete snapshot of erased channel", "channel", channel.Value(), "err", err)
+	}
+}
+
+// maybeSnapshot takes a snapshot of a freshly committed rocket when the policy says so.
+// Failures are only logged: snapshots are an optimization, the event store is the source of truth.
+func (r *RocketRepository) maybeSnapshot(rocket *domain.Rocket, committed int) {
+	if r.snapshots == nil || committed == 0 {
+		return
+	}
+
+	channel := rocket.GetChannel().Value()
+	now := time.Now()
+
+	r.progressMu.Lock()
+	progress, ok := r.progress[channel]
+	if !ok {
+		progress = &snapshotProgress{lastAt: now}
+		r.progress[channel] = progress
+	}
+	progress.eventsSince += committed
+	due := r.snapshotPolicy.due(progress.eventsSince, progress.lastAt, now)
+	r.progressMu.Unlock()
+
+	if !due {
+		return
+	}
+
+	snapshot := rocket.Snapshot(now.UnixMilli())
+	if err := r.snapshots.SaveSnapshot(snapshot); err != nil {
+		slog.Error("Failed to save snapshot", "channel", channel, "err", err)
+		return
+	}
+
+	r.progressMu.Lock()
+	progress.eventsSince = 0
+	progress.lastAt = now
+	r.progressMu.Unlock()
+
+	slog.Debug("Snapshot saved", "channel", channel, "last_message_number", snapshot.LastMessageNumber)
+}
+
+// GetAll gets all rockets (reconstructed from the event store).
+// Rockets that are not cached are rebuilt without being added to the cache, so a listing
+// does not evict the rockets that are actually in use.
 func (r *RocketRepository) GetAll() ([]*domain.Rocket, error) {
 	// Get all channels from the event store
 	channels := r.eventStore.GetAllChannels()
@@ -92,8 +331,7 @@ func (r *RocketRepository) GetAll() ([]*domain.Rocket, error) {
 			continue // Skip invalid channels
 		}
 
-		// Use GetByChannel which does automatic replay
-		rocket, err := r.GetByChannel(channel)
+		rocket, err := r.Peek(channel)
 		if err != nil {
 			continue
 		}
@@ -0,0 +1,358 @@
+package infrastructure
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"rockets/internal/domain"
+)
+
+// newMemoryStore creates an event store that keeps events in memory only.
+func newMemoryStore(t *testing.T) *KafkaEventStore {
+	t.Helper()
+	store, err := NewKafkaEventStore(KafkaConfig{})
+	if err != nil {
+		t.Fatalf("Expected no error creating store, got %v", err)
+	}
+	return store
+}
+
+// TestRepositoryHydratesFromSnapshot verifies that a rocket is rebuilt from its snapshot plus newer events.
+// The snapshot carries a rocket type that no event has, so it only survives if older events are skipped.
+// Expected result: type from the snapshot, speed and last message from the newer events.
+func TestRepositoryHydratesFromSnapshot(t *testing.T) {
+	// Arrange
+	store := newMemoryStore(t)
+	appendLaunchAndSpeedUps(t, store, "rocket-snap", 4) // messages 1..5, speed 10400
+	snapshots := NewInMemorySnapshotStore()
+	_ = snapshots.SaveSnapshot(&domain.RocketSnapshot{
+		Channel:           "rocket-snap",
+		RocketType:        "from-snapshot",
+		Status:            string(domain.StatusFlying),
+		Speed:             10200,
+		Mission:           string(domain.MissionExploration),
+		LastMessageNumber: 3,
+		Version:           3,
+	})
+	repository := NewRocketRepository(store, WithSnapshots(snapshots, SnapshotPolicy{EveryEvents: 100}))
+
+	// Act
+	channel, _ := domain.NewChannel("rocket-snap")
+	rocket, err := repository.GetByChannel(channel)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if rocket.GetRocketType() != "from-snapshot" {
+		t.Errorf("Expected type from-snapshot (older events skipped), got %s", rocket.GetRocketType())
+	}
+	if rocket.GetSpeed().Value() != 10400 {
+		t.Errorf("Expected speed 10400, got %d", rocket.GetSpeed().Value())
+	}
+	if rocket.GetLastMessageNumber().Value() != 5 {
+		t.Errorf("Expected last message 5, got %d", rocket.GetLastMessageNumber().Value())
+	}
+}
+
+// TestRepositoryIgnoresSnapshotAheadOfLog verifies that a snapshot newer than the stored events is discarded.
+// Expected result: the rocket is replayed from the full history.
+func TestRepositoryIgnoresSnapshotAheadOfLog(t *testing.T) {
+	// Arrange
+	store := newMemoryStore(t)
+	appendLaunchAndSpeedUps(t, store, "rocket-ahead", 1)
+	snapshots := NewInMemorySnapshotStore()
+	_ = snapshots.SaveSnapshot(&domain.RocketSnapshot{
+		Channel:           "rocket-ahead",
+		RocketType:        "from-snapshot",
+		Status:            string(domain.StatusExploded),
+		LastMessageNumber: 9,
+	})
+	repository := NewRocketRepository(store, WithSnapshots(snapshots, SnapshotPolicy{EveryEvents: 100}))
+
+	// Act
+	channel, _ := domain.NewChannel("rocket-ahead")
+	rocket, _ := repository.GetByChannel(channel)
+
+	// Assert
+	if rocket.GetRocketType() != "Falcon-9" || rocket.GetStatus() != domain.StatusFlying {
+		t.Errorf("Expected full replay (Falcon-9, flying), got %s, %s", rocket.GetRocketType(), rocket.GetStatus())
+	}
+}
+
+// TestRepositoryTakesSnapshotEveryNEvents verifies the event-count snapshot policy.
+// Expected result: no snapshot after 2 events, a snapshot at message 3 after the third.
+func TestRepositoryTakesSnapshotEveryNEvents(t *testing.T) {
+	// Arrange
+	store := newMemoryStore(t)
+	snapshots := NewInMemorySnapshotStore()
+	repository := NewRocketRepository(store, WithSnapshots(snapshots, SnapshotPolicy{EveryEvents: 3}))
+	channel, _ := domain.NewChannel("rocket-policy")
+	rocket, _ := repository.GetByChannel(channel)
+
+	msgNum1, _ := domain.NewMessageNumber(1)
+	speed, _ := domain.NewSpeed(1000)
+	_ = rocket.Launch(msgNum1, "Falcon-9", speed, domain.MissionSatellite, 1)
+	_ = repository.Save(rocket)
+	msgNum2, _ := domain.NewMessageNumber(2)
+	_ = rocket.IncreaseSpeed(msgNum2, 100, 2)
+	_ = repository.Save(rocket)
+
+	snapshot, _ := snapshots.LoadSnapshot(channel)
+	if snapshot != nil {
+		t.Fatalf("Expected no snapshot after 2 events, got one at message %d", snapshot.LastMessageNumber)
+	}
+
+	// Act
+	msgNum3, _ := domain.NewMessageNumber(3)
+	_ = rocket.IncreaseSpeed(msgNum3, 100, 3)
+	_ = repository.Save(rocket)
+
+	// Assert
+	snapshot, _ = snapshots.LoadSnapshot(channel)
+	if snapshot == nil {
+		t.Fatal("Expected a snapshot after 3 events, got none")
+	}
+	if snapshot.LastMessageNumber != 3 || snapshot.Speed != 1200 {
+		t.Errorf("Expected snapshot at message 3 with speed 1200, got %d / %d", snapshot.LastMessageNumber, snapshot.Speed)
+	}
+}
+
+// TestFileSnapshotStoreRoundTrip verifies that snapshots are persisted per channel on disk.
+// Expected result: the loaded snapshot equals the saved one; unknown channels return nil.
+func TestFileSnapshotStoreRoundTrip(t *testing.T) {
+	// Arrange
+	store, err := NewFileSnapshotStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	saved := &domain.RocketSnapshot{Channel: "rocket/with/slashes", RocketType: "Starship", Status: "flying", Speed: 5, LastMessageNumber: 7}
+
+	// Act
+	if err := store.SaveSnapshot(saved); err != nil {
+		t.Fatalf("Expected no error saving, got %v", err)
+	}
+	channel, _ := domain.NewChannel("rocket/with/slashes")
+	loaded, err := store.LoadSnapshot(channel)
+	other, _ := domain.NewChannel("rocket-none")
+	missing, _ := store.LoadSnapshot(other)
+
+	// Assert
+	if err != nil || loaded == nil || *loaded != *saved {
+		t.Errorf("Expected %+v, got %+v (err %v)", saved, loaded, err)
+	}
+	if missing != nil {
+		t.Errorf("Expected nil for unknown channel, got %+v", missing)
+	}
+}
+
+// TestRepositoryRejectsInterleavedWriters verifies that two repositories over one store cannot
+// both commit on top of the same version of a channel.
+// Expected result: the second Save conflicts, and a reload sees the winner's events.
+func TestRepositoryRejectsInterleavedWriters(t *testing.T) {
+	// Arrange
+	store := newMemoryStore(t)
+	appendLaunchAndSpeedUps(t, store, "rocket-shared", 0)
+	instanceA := NewRocketRepository(store)
+	instanceB := NewRocketRepository(store)
+	channel, _ := domain.NewChannel("rocket-shared")
+	rocketA, _ := instanceA.GetByChannel(channel)
+	rocketB, _ := instanceB.GetByChannel(channel)
+	msgNum2, _ := domain.NewMessageNumber(2)
+	_ = rocketA.IncreaseSpeed(msgNum2, 100, 2)
+	_ = rocketB.DecreaseSpeed(msgNum2, 100, 2)
+
+	// Act
+	errA := instanceA.Save(rocketA)
+	errB := instanceB.Save(rocketB)
+
+	// Assert
+	if errA != nil {
+		t.Fatalf("Expected first save to succeed, got %v", errA)
+	}
+	if !errors.Is(errB, domain.ErrConcurrencyConflict) {
+		t.Fatalf("Expected second save to conflict, got %v", errB)
+	}
+	reloaded, _ := instanceB.GetByChannel(channel)
+	if reloaded.GetSpeed().Value() != 10100 {
+		t.Errorf("Expected reloaded speed 10100, got %d", reloaded.GetSpeed().Value())
+	}
+	events, _ := store.GetEventsByChannel(channel)
+	if len(events) != 2 {
+		t.Errorf("Expected 2 stored events, got %d", len(events))
+	}
+}
+
+// TestRepositoryEvictsLeastRecentlyUsed verifies the size bound of the rocket cache.
+// Expected result: the least recently used rocket is evicted and rebuilt with the same state on its next access.
+func TestRepositoryEvictsLeastRecentlyUsed(t *testing.T) {
+	// Arrange
+	store := newMemoryStore(t)
+	for _, name := range []string{"rocket-a", "rocket-b", "rocket-c"} {
+		appendLaunchAndSpeedUps(t, store, name, 2)
+	}
+	repository := NewRocketRepository(store, WithCache(CacheConfig{MaxEntries: 2}))
+	a, _ := domain.NewChannel("rocket-a")
+	b, _ := domain.NewChannel("rocket-b")
+	c, _ := domain.NewChannel("rocket-c")
+
+	// Act
+	_, _ = repository.GetByChannel(a)
+	first, _ := repository.GetByChannel(b)
+	_, _ = repository.GetByChannel(a) // a is now more recent than b
+	_, _ = repository.GetByChannel(c) // evicts b
+	again, err := repository.GetByChannel(b)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if again == first {
+		t.Error("Expected rocket-b to be rebuilt after eviction")
+	}
+	if again.GetSpeed().Value() != 10200 || again.GetLastMessageNumber().Value() != 3 {
+		t.Errorf("Expected rebuilt rocket at speed 10200 after message 3, got %d after %d", again.GetSpeed().Value(), again.GetLastMessageNumber().Value())
+	}
+	stats := repository.CacheStats()
+	if stats.Hits != 1 || stats.Misses != 4 || stats.Evictions != 2 || stats.Size != 2 {
+		t.Errorf("Expected 1 hit, 4 misses, 2 evictions, size 2, got %+v", stats)
+	}
+}
+
+// TestRepositoryListingLeavesCacheUntouched verifies that GetAll neither counts cache hits
+// nor makes the rockets it lists recently used.
+// Expected result: same stats before and after the listing; rocket-b, the least recently used
+// before the listing, is still the one evicted for rocket-c.
+func TestRepositoryListingLeavesCacheUntouched(t *testing.T) {
+	// Arrange
+	store := newMemoryStore(t)
+	for _, name := range []string{"rocket-a", "rocket-b", "rocket-c"} {
+		appendLaunchAndSpeedUps(t, store, name, 2)
+	}
+	repository := NewRocketRepository(store, WithCache(CacheConfig{MaxEntries: 2}))
+	a, _ := domain.NewChannel("rocket-a")
+	b, _ := domain.NewChannel("rocket-b")
+	c, _ := domain.NewChannel("rocket-c")
+	first, _ := repository.GetByChannel(b)
+	_, _ = repository.GetByChannel(a)
+	before := repository.CacheStats()
+
+	// Act
+	rockets, err := repository.GetAll()
+	after := repository.CacheStats()
+	_, _ = repository.GetByChannel(c) // evicts b, the least recently used
+	again, _ := repository.GetByChannel(b)
+
+	// Assert
+	if err != nil || len(rockets) != 3 {
+		t.Fatalf("Expected 3 rockets, got %d (err %v)", len(rockets), err)
+	}
+	if after != before {
+		t.Errorf("Expected the listing to leave the stats at %+v, got %+v", before, after)
+	}
+	if again == first {
+		t.Error("Expected rocket-b to be evicted despite the listing")
+	}
+}
+
+// TestRepositoryExpiresIdleRockets verifies the TTL of the rocket cache.
+// Expected result: a rocket idle for longer than the TTL is evicted; a recently used one stays.
+func TestRepositoryExpiresIdleRockets(t *testing.T) {
+	// Arrange
+	store := newMemoryStore(t)
+	appendLaunchAndSpeedUps(t, store, "rocket-idle", 0)
+	appendLaunchAndSpeedUps(t, store, "rocket-busy", 0)
+	repository := NewRocketRepository(store, WithCache(CacheConfig{TTL: time.Minute}))
+	now := time.Unix(1000, 0)
+	repository.cache.now = func() time.Time { return now }
+	idle, _ := domain.NewChannel("rocket-idle")
+	busy, _ := domain.NewChannel("rocket-busy")
+	_, _ = repository.GetByChannel(idle)
+	_, _ = repository.GetByChannel(busy)
+
+	// Act
+	now = now.Add(40 * time.Second)
+	_, _ = repository.GetByChannel(busy)
+	now = now.Add(40 * time.Second)
+	_, _ = repository.GetByChannel(busy)
+
+	// Assert
+	stats := repository.CacheStats()
+	if stats.Evictions != 1 || stats.Size != 1 || stats.Hits != 2 {
+		t.Errorf("Expected only rocket-idle to expire, got %+v", stats)
+	}
+}
+
+// TestRepositorySaveAfterEviction verifies that a rocket evicted between load and save cannot overwrite newer events.
+// Expected result: the save is rejected as a conflict and a reload sees the other writer's event.
+func TestRepositorySaveAfterEviction(t *testing.T) {
+	// Arrange
+	store := newMemoryStore(t)
+	appendLaunchAndSpeedUps(t, store, "rocket-evicted", 1)
+	repository := NewRocketRepository(store, WithCache(CacheConfig{MaxEntries: 1}))
+	channel, _ := domain.NewChannel("rocket-evicted")
+	other, _ := domain.NewChannel("rocket-other")
+	rocket, _ := repository.GetByChannel(channel)
+	_, _ = repository.GetByChannel(other) // evicts rocket-evicted
+	msgNum, _ := domain.NewMessageNumber(3)
+	writer, _ := NewRocketRepository(store).GetByChannel(channel)
+	_ = writer.Explode(msgNum, "other writer", 2000)
+	_ = store.AppendEvents(channel, 2, writer.GetUncommittedEvents())
+	_ = rocket.IncreaseSpeed(msgNum, 100, 2000)
+
+	// Act
+	err := repository.Save(rocket)
+
+	// Assert
+	if !errors.Is(err, domain.ErrConcurrencyConflict) {
+		t.Fatalf("Expected a concurrency conflict, got %v", err)
+	}
+	reloaded, _ := repository.GetByChannel(channel)
+	if reloaded.GetStatus() != domain.StatusExploded || reloaded.GetVersion() != 3 {
+		t.Errorf("Expected reloaded rocket exploded at version 3, got %s at %d", reloaded.GetStatus(), reloaded.GetVersion())
+	}
+}
+
+// TestRepositoryAggregateVersion verifies that the aggregate version, not the message number,
+// drives concurrency checks and snapshot replay.
+// Expected result: an evicted rocket nobody else wrote to still saves, every event carries
+// its version, and a rocket restored from a snapshot replays every event after the snapshot's
+// version, even one that does not have a higher message number.
+func TestRepositoryAggregateVersion(t *testing.T) {
+	// Arrange
+	store := newMemoryStore(t)
+	appendLaunchAndSpeedUps(t, store, "rocket-gap", 1)
+	snapshots := NewInMemorySnapshotStore()
+	repository := NewRocketRepository(store,
+		WithCache(CacheConfig{MaxEntries: 1}),
+		WithSnapshots(snapshots, SnapshotPolicy{EveryEvents: 1}))
+	channel, _ := domain.NewChannel("rocket-gap")
+	other, _ := domain.NewChannel("rocket-other")
+	rocket, _ := repository.GetByChannel(channel)
+	_, _ = repository.GetByChannel(other) // evicts rocket-gap
+	msgNum, _ := domain.NewMessageNumber(10)
+	_ = rocket.IncreaseSpeed(msgNum, 100, 2000)
+
+	// Act
+	err := repository.Save(rocket)
+	_, _ = repository.GetByChannel(other)
+	_ = store.AppendEvents(channel, 3, []domain.DomainEvent{&domain.RocketExploded{Channel: channel, MessageNumber: msgNum, Timestamp: 3000}})
+	restored, _ := repository.GetByChannel(channel)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected the evicted rocket to save, got %v", err)
+	}
+	events, _ := store.GetEventsByChannel(channel)
+	if got := events[2].GetMetadata().Get(domain.MetadataVersion); got != "3" {
+		t.Errorf("Expected the new event to carry version 3, got %q", got)
+	}
+	if snapshot, _ := snapshots.LoadSnapshot(channel); snapshot == nil || snapshot.Version != 3 {
+		t.Fatalf("Expected a snapshot at version 3, got %+v", snapshot)
+	}
+	if restored.GetVersion() != 4 || restored.GetStatus() != domain.StatusExploded || restored.GetSpeed().Value() != 10200 {
+		t.Errorf("Expected exploded rocket at version 4 with speed 10200, got %s at %d with %d",
+			restored.GetStatus(), restored.GetVersion(), restored.GetSpeed().Value())
+	}
+}
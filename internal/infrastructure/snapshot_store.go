@@ -0,0 +1,160 @@
+package infrastructure
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"rockets/internal/domain"
+)
+
+const snapshotExtension = ".snapshot.json"
+
+// SnapshotPolicy decides when the repository takes a new snapshot of a rocket.
+// A snapshot is due when either threshold is reached; zero disables a threshold.
+type SnapshotPolicy struct {
+	EveryEvents int           // events committed since the last snapshot
+	Interval    time.Duration // time elapsed since the last snapshot
+}
+
+// due reports whether a snapshot should be taken
+func (p SnapshotPolicy) due(eventsSince int, lastAt time.Time, now time.Time) bool {
+	if eventsSince == 0 {
+		return false
+	}
+	if p.EveryEvents > 0 && eventsSince >= p.EveryEvents {
+		return true
+	}
+	return p.Interval > 0 && now.Sub(lastAt) >= p.Interval
+}
+
+// InMemorySnapshotStore keeps the latest snapshot of every channel in memory
+type InMemorySnapshotStore struct {
+	mu        sync.RWMutex
+	snapshots map[string]*domain.RocketSnapshot
+}
+
+// NewInMemorySnapshotStore creates an empty in-memory snapshot store
+func NewInMemorySnapshotStore() *InMemorySnapshotStore {
+	return &InMemorySnapshotStore{
+		snapshots: make(map[string]*domain.RocketSnapshot),
+	}
+}
+
+// SaveSnapshot stores a copy of the snapshot, replacing the previous one
+func (s *InMemorySnapshotStore) SaveSnapshot(snapshot *domain.RocketSnapshot) error {
+	if snapshot == nil {
+		return fmt.Errorf("snapshot cannot be nil")
+	}
+	copySnapshot := *snapshot
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.snapshots[snapshot.Channel] = &copySnapshot
+	return nil
+}
+
+// LoadSnapshot returns the latest snapshot of a channel, or nil
+func (s *InMemorySnapshotStore) LoadSnapshot(channel *domain.Channel) (*domain.RocketSnapshot, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	snapshot, ok := s.snapshots[channel.Value()]
+	if !ok {
+		return nil, nil
+	}
+	copySnapshot := *snapshot
+	return &copySnapshot, nil
+}
+
+// DeleteSnapshot forgets the snapshot of a channel
+func (s *InMemorySnapshotStore) DeleteSnapshot(channel *domain.Channel) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.snapshots, channel.Value())
+	return nil
+}
+
+// FileSnapshotStore keeps the latest snapshot of every channel as a JSON file
+type FileSnapshotStore struct {
+	dir string
+}
+
+// NewFileSnapshotStore creates a snapshot store in dir
+func NewFileSnapshotStore(dir string) (*FileSnapshotStore, error) {
+	if strings.TrimSpace(dir) == "" {
+		return nil, fmt.Errorf("snapshot directory cannot be empty")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	return &FileSnapshotStore{dir: dir}, nil
+}
+
+// SaveSnapshot writes the snapshot atomically (temporary file + rename)
+func (s *FileSnapshotStore) SaveSnapshot(snapshot *domain.RocketSnapshot) error {
+	if snapshot == nil {
+		return fmt.Errorf("snapshot cannot be nil")
+	}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(s.dir, ".snapshot-*")
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to sync snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close snapshot: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path(snapshot.Channel)); err != nil {
+		return fmt.Errorf("failed to publish snapshot: %w", err)
+	}
+	syncDir(s.dir)
+	return nil
+}
+
+// LoadSnapshot returns the latest snapshot of a channel, or nil
+func (s *FileSnapshotStore) LoadSnapshot(channel *domain.Channel) (*domain.RocketSnapshot, error) {
+	data, err := os.ReadFile(s.path(channel.Value()))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	var snapshot domain.RocketSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+	return &snapshot, nil
+}
+
+// DeleteSnapshot removes the snapshot file of a channel
+func (s *FileSnapshotStore) DeleteSnapshot(channel *domain.Channel) error {
+	if err := os.Remove(s.path(channel.Value())); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete snapshot: %w", err)
+	}
+	syncDir(s.dir)
+	return nil
+}
+
+// path returns the file holding the snapshot of a channel
+func (s *FileSnapshotStore) path(channel string) string {
+	return filepath.Join(s.dir, url.PathEscape(channel)+snapshotExtension)
+}
@@ -0,0 +1,60 @@
+package infrastructure
+
+import (
+	"context"
+
+	"rockets/internal/domain"
+)
+
+// subscriptionPageSize is how many events a subscription reads from the index at a time
+const subscriptionPageSize = 256
+
+// subscribeAll delivers the global log from fromPosition: the stored history first, then
+// every event as it is added. Both come from the same cursor over the log, so the switch
+// from history to live events has no gaps or duplicates.
+func (i *eventIndex) subscribeAll(ctx context.Context, fromPosition int64, handler func(domain.RecordedEvent) error) error {
+	if fromPosition < 1 {
+		fromPosition = 1
+	}
+	return deliver(ctx, handler, func() ([]domain.RecordedEvent, <-chan struct{}) {
+		page, changed := i.readAllOrWait(fromPosition, subscriptionPageSize)
+		if len(page) > 0 {
+			fromPosition = page[len(page)-1].Position + 1
+		}
+		return page, changed
+	})
+}
+
+// subscribeChannel delivers the events of one channel after its first fromVersion events,
+// history first and then live, like subscribeAll
+func (i *eventIndex) subscribeChannel(ctx context.Context, channel string, fromVersion int, handler func(domain.RecordedEvent) error) error {
+	return deliver(ctx, handler, func() ([]domain.RecordedEvent, <-chan struct{}) {
+		page, changed := i.readChannelOrWait(channel, fromVersion, subscriptionPageSize)
+		fromVersion += len(page)
+		return page, changed
+	})
+}
+
+// deliver passes pages to handler until ctx is done or handler fails, waiting for new
+// events whenever the reader has caught up
+func deliver(ctx context.Context, handler func(domain.RecordedEvent) error, next func() ([]domain.RecordedEvent, <-chan struct{})) error {
+	for {
+		page, changed := next()
+		for _, recorded := range page {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := handler(recorded); err != nil {
+				return err
+			}
+		}
+		if len(page) > 0 {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
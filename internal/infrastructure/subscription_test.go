@@ -0,0 +1,113 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"rockets/internal/domain"
+)
+
+// collect subscribes to the global log until want events arrived, then cancels the subscription.
+func collect(t *testing.T, store *KafkaEventStore, from int64, want int) []domain.RecordedEvent {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var got []domain.RecordedEvent
+	err := store.SubscribeAll(ctx, from, func(recorded domain.RecordedEvent) error {
+		got = append(got, recorded)
+		if len(got) == want {
+			cancel()
+		}
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected the subscription to end with context.Canceled, got %v (after %d events)", err, len(got))
+	}
+	return got
+}
+
+// TestSubscribeAllCatchesUpThenFollows verifies the switch from history to live events.
+// Appends run concurrently with the subscription, so some events are read as history and others live.
+// Expected result: every position exactly once, in order.
+func TestSubscribeAllCatchesUpThenFollows(t *testing.T) {
+	// Arrange
+	store, _ := NewKafkaEventStore(KafkaConfig{})
+	appendLaunchAndSpeedUps(t, store, "rocket-history", 4)
+	go func() {
+		for i := 0; i < 20; i++ {
+			appendLaunchAndSpeedUps(t, store, fmt.Sprintf("rocket-live-%d", i), 4)
+		}
+	}()
+
+	// Act
+	got := collect(t, store, 1, 105)
+
+	// Assert
+	for i, recorded := range got {
+		if recorded.Position != int64(i+1) {
+			t.Fatalf("Expected position %d at index %d, got %d", i+1, i, recorded.Position)
+		}
+	}
+}
+
+// TestSubscribeAllFromPosition verifies that a subscription can resume from a position.
+// Expected result: the first delivered event is the one at the requested position.
+func TestSubscribeAllFromPosition(t *testing.T) {
+	// Arrange
+	store, _ := NewKafkaEventStore(KafkaConfig{})
+	appendLaunchAndSpeedUps(t, store, "rocket-resume", 5)
+
+	// Act
+	got := collect(t, store, 4, 3)
+
+	// Assert
+	if got[0].Position != 4 || got[2].Position != 6 {
+		t.Errorf("Expected positions 4..6, got %d..%d", got[0].Position, got[2].Position)
+	}
+}
+
+// TestSubscribeToChannelFiltersAndResumes verifies a per-channel subscription.
+// Expected result: only events of the channel, starting after the first fromVersion ones, including live ones.
+func TestSubscribeToChannelFiltersAndResumes(t *testing.T) {
+	// Arrange
+	store, _ := NewKafkaEventStore(KafkaConfig{})
+	appendLaunchAndSpeedUps(t, store, "rocket-one", 2)
+	appendLaunchAndSpeedUps(t, store, "rocket-other", 2)
+	channel, _ := domain.NewChannel("rocket-one")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var got []domain.RecordedEvent
+	done := make(chan error, 1)
+	go func() {
+		done <- store.SubscribeToChannel(ctx, channel, 1, func(recorded domain.RecordedEvent) error {
+			got = append(got, recorded)
+			if len(got) == 3 {
+				return errors.New("enough")
+			}
+			return nil
+		})
+	}()
+
+	// Act
+	msgNum, _ := domain.NewMessageNumber(4)
+	_ = store.AppendEvent(&domain.RocketExploded{Channel: channel, MessageNumber: msgNum, Reason: "test", Timestamp: 2000})
+	err := <-done
+
+	// Assert
+	if err == nil || err.Error() != "enough" {
+		t.Fatalf("Expected the handler error to end the subscription, got %v", err)
+	}
+	wantNumbers := []int{2, 3, 4}
+	for i, recorded := range got {
+		if recorded.Event.GetChannel().Value() != "rocket-one" || recorded.Event.GetMessageNumber().Value() != wantNumbers[i] {
+			t.Errorf("Event %d: expected message %d of rocket-one, got %d of %s", i, wantNumbers[i], recorded.Event.GetMessageNumber().Value(), recorded.Event.GetChannel().Value())
+		}
+	}
+	if got[2].Position != 7 {
+		t.Errorf("Expected the live event at global position 7, got %d", got[2].Position)
+	}
+}
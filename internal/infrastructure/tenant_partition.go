@@ -0,0 +1,44 @@
+package infrastructure
+
+import (
+	"path/filepath"
+
+	"rockets/internal/domain"
+)
+
+// TenantDir returns the directory of a tenant's partition below base: base itself for the
+// default tenant (where a single-tenant server kept its data), base/tenants/{id} otherwise
+func TenantDir(base, tenantID string) string {
+	if tenantID == domain.DefaultTenantID {
+		return base
+	}
+	return filepath.Join(base, "tenants", tenantID)
+}
+
+// TenantTopic returns the Kafka topic of a tenant: topic itself for the default tenant,
+// {topic}.{id} otherwise
+func TenantTopic(topic, tenantID string) string {
+	if topic == "" {
+		topic = defaultKafkaTopic
+	}
+	if tenantID == domain.DefaultTenantID {
+		return topic
+	}
+	return topic + "." + tenantID
+}
+
+// FleetDir returns the directory of a tenant's fleet streams, base/fleets for the default
+// tenant and base/tenants/{id}/fleets otherwise
+func FleetDir(base, tenantID string) string {
+	return filepath.Join(TenantDir(base, tenantID), "fleets")
+}
+
+// FleetTopic returns the Kafka topic of a tenant's fleet streams: {topic}-fleets for the
+// default tenant, {topic}-fleets.{id} otherwise. Tenant IDs cannot contain '.', so it never
+// matches the topic of a tenant.
+func FleetTopic(topic, tenantID string) string {
+	if topic == "" {
+		topic = defaultKafkaTopic
+	}
+	return TenantTopic(topic+"-fleets", tenantID)
+}
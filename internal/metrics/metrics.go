@@ -0,0 +1,145 @@
+// Package metrics keeps in-process counters and gauges and writes them in the
+// Prometheus text exposition format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Counter is a monotonically increasing value
+type Counter struct {
+	value atomic.Int64
+}
+
+// Inc adds one to the counter
+func (c *Counter) Inc() { c.value.Add(1) }
+
+// Add adds n (>= 0) to the counter
+func (c *Counter) Add(n int64) { c.value.Add(n) }
+
+// Value returns the current count
+func (c *Counter) Value() int64 { return c.value.Load() }
+
+// CounterVec is a family of counters told apart by the value of one label
+type CounterVec struct {
+	label  string
+	mu     sync.Mutex
+	values map[string]*Counter
+}
+
+// With returns the counter of a label value, creating it on first use
+func (v *CounterVec) With(labelValue string) *Counter {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	c, ok := v.values[labelValue]
+	if !ok {
+		c = &Counter{}
+		v.values[labelValue] = c
+	}
+	return c
+}
+
+// metric is one registered metric family
+type metric struct {
+	name  string
+	help  string
+	kind  string // counter or gauge
+	write func(w io.Writer, name string) error
+}
+
+// Registry holds the metrics exposed by the server
+type Registry struct {
+	mu      sync.Mutex
+	metrics map[string]*metric
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{metrics: make(map[string]*metric)}
+}
+
+// NewCounter registers a counter
+func (r *Registry) NewCounter(name, help string) *Counter {
+	c := &Counter{}
+	r.register(name, help, "counter", func(w io.Writer, name string) error {
+		_, err := fmt.Fprintf(w, "%s %d\n", name, c.Value())
+		return err
+	})
+	return c
+}
+
+// NewCounterVec registers a family of counters with one label
+func (r *Registry) NewCounterVec(name, help, label string) *CounterVec {
+	v := &CounterVec{label: label, values: make(map[string]*Counter)}
+	r.register(name, help, "counter", func(w io.Writer, name string) error {
+		v.mu.Lock()
+		labelValues := make([]string, 0, len(v.values))
+		for lv := range v.values {
+			labelValues = append(labelValues, lv)
+		}
+		v.mu.Unlock()
+		sort.Strings(labelValues)
+		for _, lv := range labelValues {
+			if _, err := fmt.Fprintf(w, "%s{%s=\"%s\"} %d\n", name, v.label, escapeLabel(lv), v.With(lv).Value()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return v
+}
+
+// NewCounterFunc registers a counter whose value is read from fn, for components that
+// keep their own counts
+func (r *Registry) NewCounterFunc(name, help string, fn func() int64) {
+	r.register(name, help, "counter", func(w io.Writer, name string) error {
+		_, err := fmt.Fprintf(w, "%s %d\n", name, fn())
+		return err
+	})
+}
+
+// NewGaugeFunc registers a gauge whose value is read from fn
+func (r *Registry) NewGaugeFunc(name, help string, fn func() float64) {
+	r.register(name, help, "gauge", func(w io.Writer, name string) error {
+		_, err := fmt.Fprintf(w, "%s %g\n", name, fn())
+		return err
+	})
+}
+
+// register adds a metric family; registering a name twice replaces the first one
+func (r *Registry) register(name, help, kind string, write func(io.Writer, string) error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.metrics[name] = &metric{name: name, help: help, kind: kind, write: write}
+}
+
+// WriteText writes every metric in the Prometheus text format, sorted by name
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	families := make([]*metric, 0, len(r.metrics))
+	for _, m := range r.metrics {
+		families = append(families, m)
+	}
+	r.mu.Unlock()
+	sort.Slice(families, func(i, j int) bool { return families[i].name < families[j].name })
+
+	for _, m := range families {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", m.name, m.help, m.name, m.kind); err != nil {
+			return err
+		}
+		if err := m.write(w, m.name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// escapeLabel escapes a label value for the text format
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestRegistryWriteText verifies the text exposition of every kind of metric.
+// Expected result: families sorted by name with HELP/TYPE lines and current values.
+func TestRegistryWriteText(t *testing.T) {
+	// Arrange
+	registry := NewRegistry()
+	counter := registry.NewCounter("rockets_b_total", "A counter.")
+	vec := registry.NewCounterVec("rockets_a_total", "A labeled counter.", "reason")
+	registry.NewGaugeFunc("rockets_c", "A gauge.", func() float64 { return 2.5 })
+	counter.Add(3)
+	vec.With("out_of_order").Inc()
+	vec.With(`quote"d`).Inc()
+
+	// Act
+	var out bytes.Buffer
+	err := registry.WriteText(&out)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	want := `# HELP rockets_a_total A labeled counter.
+# TYPE rockets_a_total counter
+rockets_a_total{reason="out_of_order"} 1
+rockets_a_total{reason="quote\"d"} 1
+# HELP rockets_b_total A counter.
+# TYPE rockets_b_total counter
+rockets_b_total 3
+# HELP rockets_c A gauge.
+# TYPE rockets_c gauge
+rockets_c 2.5
+`
+	if out.String() != want {
+		t.Errorf("Unexpected exposition:\n%s\nwant:\n%s", out.String(), want)
+	}
+}